@@ -0,0 +1,61 @@
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+type checkpoint struct {
+	Collection string `json:"collection"`
+	Row        int    `json:"row"`
+}
+
+// Returns the last row handled by a previous import, 0 without a path or file
+func readCheckpoint(path string, collection string) (int, error) {
+	if path == "" {
+		return 0, nil
+	}
+
+	raw, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	saved := checkpoint{}
+
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return 0, fmt.Errorf("invalid-checkpoint|%w", err)
+	}
+
+	if saved.Collection != collection {
+		return 0, fmt.Errorf("invalid-checkpoint|written for %s, importing into %s", saved.Collection, collection)
+	}
+
+	return saved.Row, nil
+}
+
+// Writes through a temporary file so a crash never leaves a half written checkpoint
+func writeCheckpoint(path string, collection string, row int) error {
+	if path == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(checkpoint{Collection: collection, Row: row})
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path+".tmp", raw, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,148 @@
+package bulk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JGugino/pb-go/filter"
+	"github.com/JGugino/pb-go/services"
+)
+
+// Layouts tried in order when a date is given as text
+var dateLayouts = []string{
+	filter.DateTimeLayout,
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// Converts a value read from a file to the type PocketBase expects for the field
+//
+// Text from CSV cells is parsed for numbers, bools, dates and json, multi value fields are split on separator.
+// Empty text clears the field. Values that cannot be converted return an error naming the field.
+func coerceValue(field services.CollectionField, value any, separator string) (any, error) {
+	if text, ok := value.(string); ok && strings.TrimSpace(text) == "" && field.Type != "text" && field.Type != "editor" {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case "number":
+		return coerceNumber(field, value)
+	case "bool":
+		return coerceBool(field, value)
+	case "date", "autodate":
+		return coerceDate(field, value)
+	case "json", "geoPoint":
+		if text, ok := value.(string); ok {
+			var decoded any
+
+			if err := json.Unmarshal([]byte(text), &decoded); err == nil {
+				return decoded, nil
+			}
+		}
+
+		return value, nil
+	case "select", "relation", "file":
+		values := coerceList(value, separator)
+
+		if field.IsMultiple() {
+			return values, nil
+		}
+
+		if len(values) > 1 {
+			return nil, fmt.Errorf("%s: holds a single value, got %d", field.Name, len(values))
+		}
+
+		if len(values) == 0 {
+			return "", nil
+		}
+
+		return values[0], nil
+	}
+
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+func coerceNumber(field services.CollectionField, value any) (any, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return v.Float64()
+	case float64, int, int64:
+		return v, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", field.Name, v)
+		}
+
+		return number, nil
+	}
+
+	return nil, fmt.Errorf("%s: %v is not a number", field.Name, value)
+}
+
+func coerceBool(field services.CollectionField, value any) (any, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true, nil
+		case "0", "f", "false", "n", "no", "off":
+			return false, nil
+		}
+	case json.Number:
+		return v.String() != "0", nil
+	}
+
+	return nil, fmt.Errorf("%s: %v is not a bool", field.Name, value)
+}
+
+func coerceDate(field services.CollectionField, value any) (any, error) {
+	text, ok := value.(string)
+
+	if !ok {
+		return nil, fmt.Errorf("%s: %v is not a date", field.Name, value)
+	}
+
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, strings.TrimSpace(text)); err == nil {
+			return parsed.UTC().Format(filter.DateTimeLayout), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s: %q is not a date", field.Name, text)
+}
+
+// Splits text on the separator, lists are kept with every item turned into text
+func coerceList(value any, separator string) []string {
+	values := []string{}
+
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+	case string:
+		for _, item := range strings.Split(v, separator) {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	default:
+		values = append(values, fmt.Sprint(v))
+	}
+
+	return values
+}
@@ -0,0 +1,745 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// How multi value fields and lists from expanded relations are written
+type ListEncoding int
+
+const (
+	// Joined with the list separator in CSV and kept as arrays in JSON and NDJSON
+	DefaultLists ListEncoding = iota
+	// Joined with the list separator in every format
+	JoinedLists
+	// Written as JSON array text in every format
+	JSONLists
+)
+
+// Options for Export
+//
+// Filter, Sort, Expand and Fields are sent with every list request. Expanded relations are flattened into
+// dotted columns like author.email, a relation to many records gives a list per column. Columns defaults to
+// the visible fields of the collection and of every expanded collection, narrowed down by Fields.
+// With FilesDir set every file is downloaded to FilesDir/collection/record id/filename and the
+// reference is rewritten to that path relative to FilesDir, files already there are not downloaded again.
+type ExportOptions struct {
+	Collection    string
+	Token         string
+	Format        Format
+	Filter        string
+	Sort          string
+	Expand        string
+	Fields        string
+	Columns       []string
+	Comma         rune
+	Lists         ListEncoding
+	ListSeparator string
+	FilesDir      string
+	PerPage       int
+}
+
+type ExportResult struct {
+	Records int
+	Files   int
+}
+
+type Exporter struct {
+	Records     services.RecordService
+	Collections services.CollectionService
+	Files       services.FileService
+}
+
+func NewExporter(pb *services.Pocketbase) *Exporter {
+	return &Exporter{Records: pb.Record, Collections: pb.Collection, Files: pb.File}
+}
+
+type exportRun struct {
+	exporter  *Exporter
+	options   ExportOptions
+	expands   []string
+	schemas   map[string]*services.CollectionSchema
+	loaded    map[string]*services.CollectionSchema
+	columns   []string
+	fields    map[string]services.CollectionField
+	fileToken string
+	result    ExportResult
+}
+
+// Streams every matching record to the writer page by page, the writer is flushed but not closed
+func (exporter *Exporter) Export(writer io.Writer, options ExportOptions) (ExportResult, error) {
+	if options.ListSeparator == "" {
+		options.ListSeparator = DEFAULT_LIST_SEPARATOR
+	}
+
+	if options.PerPage <= 0 {
+		options.PerPage = services.DEFAULT_QUERY_PER_PAGE
+	}
+
+	//Offset pages only stay in place with a sort
+	if options.Sort == "" {
+		options.Sort = "id"
+	}
+
+	options.PerPage = min(options.PerPage, services.MAX_LIST_PER_PAGE)
+
+	run := &exportRun{
+		exporter: exporter,
+		options:  options,
+		expands:  expandPaths(options.Expand),
+		loaded:   map[string]*services.CollectionSchema{},
+	}
+
+	if err := run.loadSchemas(); err != nil {
+		return run.result, err
+	}
+
+	run.columns = options.Columns
+
+	if len(run.columns) == 0 {
+		run.columns = run.defaultColumns()
+	}
+
+	run.fields = map[string]services.CollectionField{}
+
+	for _, column := range run.columns {
+		if field, ok := run.fieldOf(column); ok {
+			run.fields[column] = field
+		}
+	}
+
+	output, err := newRecordWriter(writer, options.Format, options.Comma, run.columns)
+
+	if err != nil {
+		return run.result, err
+	}
+
+	listOptions := services.PocketBaseListOptions{
+		PerPage:   options.PerPage,
+		Sort:      options.Sort,
+		Filter:    options.Filter,
+		Expand:    options.Expand,
+		Fields:    run.apiFields(),
+		SkipTotal: true,
+	}
+
+	for page := 1; ; page++ {
+		if err := run.refreshFileToken(); err != nil {
+			return run.result, err
+		}
+
+		listOptions.Page = page
+		count := 0
+
+		_, err := exporter.Records.StreamRecords(options.Collection, options.Token, listOptions, true, func(record map[string]any) error {
+			count++
+
+			if options.FilesDir != "" {
+				if err := run.downloadFiles(record, ""); err != nil {
+					return err
+				}
+			}
+
+			if err := output.write(run.values(record)); err != nil {
+				return err
+			}
+
+			run.result.Records++
+			return nil
+		})
+
+		if err != nil {
+			return run.result, err
+		}
+
+		if count < options.PerPage {
+			break
+		}
+	}
+
+	return run.result, output.close()
+}
+
+// Splits the expand option into every relation path it walks, parents before their children
+func expandPaths(expand string) []string {
+	paths := []string{}
+	seen := map[string]bool{}
+
+	for _, entry := range strings.Split(expand, ",") {
+		segments := strings.Split(strings.TrimSpace(entry), ".")
+
+		for i := range segments {
+			path := strings.Join(segments[:i+1], ".")
+
+			if path == "" || seen[path] {
+				continue
+			}
+
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// Loads the schema of the collection and of every expanded collection keyed by relation path
+func (run *exportRun) loadSchemas() error {
+	base, err := run.load(run.options.Collection)
+
+	if err != nil {
+		return err
+	}
+
+	run.schemas = map[string]*services.CollectionSchema{"": base}
+
+	for _, path := range run.expands {
+		parentPath, name := "", path
+
+		if index := strings.LastIndex(path, "."); index >= 0 {
+			parentPath, name = path[:index], path[index+1:]
+		}
+
+		parent := run.schemas[parentPath]
+		collection := ""
+
+		//Back relations are named collection_via_field
+		if via, _, ok := strings.Cut(name, "_via_"); ok {
+			collection = via
+		} else if field, ok := parent.Field(name); ok && field.Type == "relation" {
+			collection = field.CollectionId()
+		}
+
+		if collection == "" {
+			return fmt.Errorf("invalid-expand|%s is not a relation of %s", name, parent.Name)
+		}
+
+		schema, err := run.load(collection)
+
+		if err != nil {
+			return err
+		}
+
+		run.schemas[path] = schema
+	}
+
+	return nil
+}
+
+func (run *exportRun) load(collection string) (*services.CollectionSchema, error) {
+	if schema, ok := run.loaded[collection]; ok {
+		return schema, nil
+	}
+
+	schema, err := services.LoadCollectionSchema(run.exporter.Collections, run.options.Token, collection)
+
+	if err != nil {
+		return nil, err
+	}
+
+	run.loaded[collection] = schema
+
+	return schema, nil
+}
+
+// Visible fields of the collection followed by those of the expanded collections, narrowed by Fields
+func (run *exportRun) defaultColumns() []string {
+	columns := []string{}
+
+	for _, path := range append([]string{""}, run.expands...) {
+		prefix := ""
+
+		if path != "" {
+			prefix = path + "."
+		}
+
+		for _, field := range run.schemas[path].Fields {
+			if field.Hidden || field.Type == "password" {
+				continue
+			}
+
+			column := prefix + field.Name
+
+			if run.options.Fields == "" || matchesFields(column, run.options.Fields) {
+				columns = append(columns, column)
+			}
+		}
+	}
+
+	return columns
+}
+
+// Whether the fields option keeps the column, the expand. prefix of fields is dropped like in the columns
+func matchesFields(column string, fields string) bool {
+	for _, pattern := range strings.Split(fields, ",") {
+		pattern, _, _ = strings.Cut(strings.TrimSpace(pattern), ":")
+
+		if pattern == "expand" {
+			if strings.Contains(column, ".") {
+				return true
+			}
+
+			continue
+		}
+
+		pattern = strings.TrimPrefix(pattern, "expand.")
+
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if rest, found := strings.CutPrefix(column, prefix); found && !strings.Contains(rest, ".") {
+				return true
+			}
+
+			continue
+		}
+
+		if column == pattern || strings.HasPrefix(column, pattern+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The field of a dotted column looked up in the schema of its relation path
+func (run *exportRun) fieldOf(column string) (services.CollectionField, bool) {
+	path, name := "", column
+
+	if index := strings.LastIndex(column, "."); index >= 0 {
+		path, name = column[:index], column[index+1:]
+	}
+
+	schema, ok := run.schemas[path]
+
+	if !ok {
+		return services.CollectionField{}, false
+	}
+
+	return schema.Field(name)
+}
+
+// Fields sent to the API, downloads need the id of every record that holds files
+func (run *exportRun) apiFields() string {
+	if run.options.Fields == "" || run.options.FilesDir == "" {
+		return run.options.Fields
+	}
+
+	fields := []string{run.options.Fields, "id"}
+
+	for _, path := range run.expands {
+		fields = append(fields, "expand."+path+".id")
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// Requests a new file token before every page as file tokens only live for a few minutes
+func (run *exportRun) refreshFileToken() error {
+	if run.options.FilesDir == "" || run.options.Token == "" || !run.hasFiles() {
+		return nil
+	}
+
+	token, err := run.exporter.Files.RequestToken(run.options.Token)
+
+	if err != nil {
+		return err
+	}
+
+	run.fileToken = token
+
+	return nil
+}
+
+func (run *exportRun) hasFiles() bool {
+	for _, field := range run.fields {
+		if field.Type == "file" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Downloads the files of the record and its expanded records, rewriting every filename to the sidecar path
+func (run *exportRun) downloadFiles(record map[string]any, path string) error {
+	schema := run.schemas[path]
+	id, _ := record["id"].(string)
+
+	for _, field := range schema.Fields {
+		if field.Type != "file" || record[field.Name] == nil {
+			continue
+		}
+
+		prefix := field.Name
+
+		if path != "" {
+			prefix = path + "." + field.Name
+		}
+
+		if _, exported := run.fields[prefix]; !exported {
+			continue
+		}
+
+		switch value := record[field.Name].(type) {
+		case string:
+			if value == "" {
+				continue
+			}
+
+			local, err := run.download(schema, id, value)
+
+			if err != nil {
+				return err
+			}
+
+			record[field.Name] = local
+		case []any:
+			locals := make([]any, len(value))
+
+			for i, item := range value {
+				filename, _ := item.(string)
+				local, err := run.download(schema, id, filename)
+
+				if err != nil {
+					return err
+				}
+
+				locals[i] = local
+			}
+
+			record[field.Name] = locals
+		}
+	}
+
+	expand, _ := record["expand"].(map[string]any)
+
+	for name, related := range expand {
+		childPath := name
+
+		if path != "" {
+			childPath = path + "." + name
+		}
+
+		if _, ok := run.schemas[childPath]; !ok {
+			continue
+		}
+
+		children := []any{related}
+
+		if list, ok := related.([]any); ok {
+			children = list
+		}
+
+		for _, child := range children {
+			if child, ok := child.(map[string]any); ok {
+				if err := run.downloadFiles(child, childPath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Downloads a single file unless it already exists, returns its path relative to FilesDir
+func (run *exportRun) download(schema *services.CollectionSchema, recordId string, filename string) (string, error) {
+	if recordId == "" || filename == "" || filename != filepath.Base(filename) {
+		return "", fmt.Errorf("invalid-file|cannot download %q of %s record %q", filename, schema.Name, recordId)
+	}
+
+	relative := schema.Name + "/" + recordId + "/" + filename
+	local := filepath.Join(run.options.FilesDir, filepath.FromSlash(relative))
+
+	if _, err := os.Stat(local); err == nil {
+		return relative, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(local), "."+filename+"-*")
+
+	if err != nil {
+		return "", err
+	}
+
+	_, err = run.exporter.Files.Download(schema.Id, recordId, filename, run.fileToken, file)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), local)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("download-failed|%s of %s record %s: %w", filename, schema.Name, recordId, err)
+	}
+
+	run.result.Files++
+
+	return relative, nil
+}
+
+// Values of the flattened record in column order, encoded for the output format
+func (run *exportRun) values(record map[string]any) []any {
+	flat := map[string]any{}
+	flattenRecord(record, "", flat)
+
+	values := make([]any, len(run.columns))
+
+	for i, column := range run.columns {
+		values[i] = run.encode(column, flat[column])
+	}
+
+	return values
+}
+
+// Copies the record into flat with expanded records under dotted keys, a relation to many
+// records collects the values of every record into one list per key
+func flattenRecord(record map[string]any, prefix string, flat map[string]any) {
+	for key, value := range record {
+		if key != "expand" {
+			flat[prefix+key] = value
+		}
+	}
+
+	expand, _ := record["expand"].(map[string]any)
+
+	for name, related := range expand {
+		switch v := related.(type) {
+		case map[string]any:
+			flattenRecord(v, prefix+name+".", flat)
+		case []any:
+			collected := map[string][]any{}
+
+			for _, item := range v {
+				child, ok := item.(map[string]any)
+
+				if !ok {
+					continue
+				}
+
+				childFlat := map[string]any{}
+				flattenRecord(child, "", childFlat)
+
+				for key, value := range childFlat {
+					if list, ok := value.([]any); ok {
+						collected[key] = append(collected[key], list...)
+					} else if value != nil {
+						collected[key] = append(collected[key], value)
+					}
+				}
+			}
+
+			for key, values := range collected {
+				flat[prefix+name+"."+key] = values
+			}
+		}
+	}
+}
+
+// Applies the list encoding, CSV cells are turned into text and json fields into JSON text
+func (run *exportRun) encode(column string, value any) any {
+	if value == nil {
+		if run.options.Format == CSV {
+			return ""
+		}
+
+		return nil
+	}
+
+	field, known := run.fields[column]
+	isJSON := known && (field.Type == "json" || field.Type == "geoPoint")
+
+	if run.options.Format != CSV {
+		if list, ok := value.([]any); ok && !isJSON {
+			switch run.options.Lists {
+			case JoinedLists:
+				return run.join(list)
+			case JSONLists:
+				return jsonText(list)
+			}
+		}
+
+		return value
+	}
+
+	if isJSON {
+		return jsonText(value)
+	}
+
+	switch v := value.(type) {
+	case []any:
+		if run.options.Lists == JSONLists {
+			return jsonText(v)
+		}
+
+		return run.join(v)
+	case map[string]any:
+		return jsonText(v)
+	}
+
+	return cellText(value)
+}
+
+func (run *exportRun) join(list []any) string {
+	items := make([]string, len(list))
+
+	for i, item := range list {
+		if _, isMap := item.(map[string]any); isMap {
+			items[i] = jsonText(item)
+		} else {
+			items[i] = cellText(item)
+		}
+	}
+
+	return strings.Join(items, run.options.ListSeparator)
+}
+
+func cellText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return jsonText(value)
+}
+
+func jsonText(value any) string {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// Writes records as rows or objects, values come in column order
+type recordWriter interface {
+	write(values []any) error
+	close() error
+}
+
+func newRecordWriter(writer io.Writer, format Format, comma rune, columns []string) (recordWriter, error) {
+	switch format {
+	case CSV:
+		csvWriter := csv.NewWriter(writer)
+
+		if comma != 0 {
+			csvWriter.Comma = comma
+		}
+
+		if err := csvWriter.Write(columns); err != nil {
+			return nil, err
+		}
+
+		return &csvRecordWriter{writer: csvWriter}, nil
+	case JSON, NDJSON:
+		output := &jsonRecordWriter{writer: bufio.NewWriter(writer), columns: columns, array: format == JSON}
+
+		if output.array {
+			output.writer.WriteString("[")
+		}
+
+		return output, nil
+	}
+
+	return nil, errors.New("invalid-format|unknown export format")
+}
+
+type csvRecordWriter struct {
+	writer *csv.Writer
+}
+
+func (output *csvRecordWriter) write(values []any) error {
+	row := make([]string, len(values))
+
+	for i, value := range values {
+		row[i] = cellText(value)
+	}
+
+	return output.writer.Write(row)
+}
+
+func (output *csvRecordWriter) close() error {
+	output.writer.Flush()
+	return output.writer.Error()
+}
+
+type jsonRecordWriter struct {
+	writer  *bufio.Writer
+	columns []string
+	array   bool
+	count   int
+}
+
+// Writes the object by hand so keys keep the column order
+func (output *jsonRecordWriter) write(values []any) error {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString("{")
+
+	for i, column := range output.columns {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+
+		buffer.WriteString(jsonText(column))
+		buffer.WriteString(":")
+		buffer.WriteString(jsonText(values[i]))
+	}
+
+	buffer.WriteString("}")
+
+	switch {
+	case !output.array:
+		buffer.WriteString("\n")
+	case output.count > 0:
+		output.writer.WriteString(",\n")
+	default:
+		output.writer.WriteString("\n")
+	}
+
+	output.count++
+
+	_, err := output.writer.Write(buffer.Bytes())
+	return err
+}
+
+func (output *jsonRecordWriter) close() error {
+	if output.array {
+		if output.count > 0 {
+			output.writer.WriteString("\n")
+		}
+
+		output.writer.WriteString("]\n")
+	}
+
+	return output.writer.Flush()
+}
@@ -0,0 +1,115 @@
+package bulk_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/bulk"
+	"github.com/JGugino/pb-go/services"
+)
+
+func TestExportCSVAcrossPages(t *testing.T) {
+	server, pb, token := blogServer(t)
+
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		server.Seed("posts", map[string]any{"title": title, "views": float64(len(title)), "tags": []any{"go", "sql"}})
+	}
+
+	out := &bytes.Buffer{}
+
+	result, err := bulk.NewExporter(pb).Export(out, bulk.ExportOptions{
+		Collection: "posts",
+		Token:      token,
+		Format:     bulk.CSV,
+		Filter:     `title = "a" || title != "b" && title != "c"`,
+		Sort:       "title",
+		Columns:    []string{"title", "views", "tags"},
+		PerPage:    2,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "title,views,tags\na,1,\"go,sql\"\nd,1,\"go,sql\"\ne,1,\"go,sql\"\n"
+
+	if result.Records != 3 || out.String() != expected {
+		t.Fatalf("exported %d records:\n%s\nexpected:\n%s", result.Records, out.String(), expected)
+	}
+}
+
+func TestExportNDJSONKeepsLists(t *testing.T) {
+	server, pb, token := blogServer(t)
+	server.Seed("posts", map[string]any{"title": "a", "tags": []any{"go", "js"}})
+
+	out := &bytes.Buffer{}
+
+	if _, err := bulk.NewExporter(pb).Export(out, bulk.ExportOptions{Collection: "posts", Token: token, Format: bulk.NDJSON}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	record := map[string]any{}
+
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &record) != nil {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	tags, _ := record["tags"].([]any)
+
+	if record["title"] != "a" || len(tags) != 2 {
+		t.Fatalf("exported %v", record)
+	}
+}
+
+func TestExportJSONListsAsText(t *testing.T) {
+	server, pb, token := blogServer(t)
+	server.Seed("posts", map[string]any{"title": "a", "tags": []any{"go"}})
+
+	out := &bytes.Buffer{}
+
+	if _, err := bulk.NewExporter(pb).Export(out, bulk.ExportOptions{Collection: "posts", Token: token, Format: bulk.JSON, Lists: bulk.JSONLists, Columns: []string{"title", "tags"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	records := []map[string]any{}
+
+	if err := json.Unmarshal(out.Bytes(), &records); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+
+	if len(records) != 1 || records[0]["tags"] != `["go"]` {
+		t.Fatalf("exported %v", records)
+	}
+}
+
+func TestExportReadsPastThePerPageCap(t *testing.T) {
+	server, pb, token := blogServer(t)
+
+	for i := 0; i < services.MAX_LIST_PER_PAGE+5; i++ {
+		server.Seed("posts", map[string]any{"title": fmt.Sprintf("post %04d", i), "views": float64(i)})
+	}
+
+	out := &bytes.Buffer{}
+
+	result, err := bulk.NewExporter(pb).Export(out, bulk.ExportOptions{Collection: "posts", Token: token, Format: bulk.NDJSON, Sort: "views", PerPage: 5000})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	if result.Records != services.MAX_LIST_PER_PAGE+5 || len(lines) != result.Records {
+		t.Fatalf("exported %d records in %d lines", result.Records, len(lines))
+	}
+
+	last := map[string]any{}
+
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil || last["views"] != float64(services.MAX_LIST_PER_PAGE+4) {
+		t.Fatalf("last record %v, %v", last, err)
+	}
+}
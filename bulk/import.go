@@ -0,0 +1,424 @@
+// Package bulk imports records from CSV, JSON and NDJSON files and exports them back to those formats
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/JGugino/pb-go/filter"
+	"github.com/JGugino/pb-go/services"
+)
+
+const (
+	DEFAULT_IMPORT_WORKERS    = 4
+	DEFAULT_IMPORT_CHUNK_SIZE = 50
+	DEFAULT_LIST_SEPARATOR    = ","
+)
+
+// Resolves a relation from a lookup value like an email instead of the record id
+//
+// Collection defaults to the collection the relation field points to.
+type Relation struct {
+	Collection  string
+	LookupField string
+}
+
+// Options for Import
+//
+// Mapping renames source columns or keys to fields, a column mapped to "" or "-" is skipped and columns
+// missing from it keep their name. With BatchSize above zero rows are created in batches when the instance
+// supports them, otherwise Workers rows are created at once. CheckpointPath keeps the number of the last
+// row handled so a new Import with the same path continues after it.
+type ImportOptions struct {
+	Collection     string
+	Token          string
+	Format         Format
+	Comma          rune
+	ListSeparator  string
+	Mapping        map[string]string
+	Relations      map[string]Relation
+	Schema         *services.CollectionSchema
+	BatchSize      int
+	Workers        int
+	CheckpointPath string
+}
+
+// A row that was not created, Row counts from 1 without the CSV header
+type RejectedRow struct {
+	Row  int
+	Data map[string]any
+	Err  error
+}
+
+type ImportResult struct {
+	Created  int
+	Skipped  int
+	Rejected []RejectedRow
+}
+
+type Importer struct {
+	Records     services.RecordService
+	Collections services.CollectionService
+}
+
+func NewImporter(pb *services.Pocketbase) *Importer {
+	return &Importer{Records: pb.Record, Collections: pb.Collection}
+}
+
+type importRow struct {
+	number int
+	source map[string]any
+	data   map[string]any
+}
+
+type importRun struct {
+	importer  *Importer
+	options   ImportOptions
+	schema    *services.CollectionSchema
+	relations map[string]string
+	result    ImportResult
+	mu        sync.Mutex
+}
+
+// Reads every row, converts it with the collection schema and creates the records
+//
+// Rows that cannot be read, converted or created are collected in Rejected and do not stop the import.
+// Only failures to read the input, load the schema or write the checkpoint are returned as error.
+func (importer *Importer) Import(reader io.Reader, options ImportOptions) (ImportResult, error) {
+	if options.ListSeparator == "" {
+		options.ListSeparator = DEFAULT_LIST_SEPARATOR
+	}
+
+	schema := options.Schema
+
+	if schema == nil {
+		loaded, err := services.LoadCollectionSchema(importer.Collections, options.Token, options.Collection)
+
+		if err != nil {
+			return ImportResult{}, err
+		}
+
+		schema = loaded
+	}
+
+	for field, relation := range options.Relations {
+		if definition, ok := schema.Field(field); !ok || definition.Type != "relation" {
+			return ImportResult{}, fmt.Errorf("invalid-relation|%s is not a relation field of %s", field, schema.Name)
+		}
+
+		if relation.LookupField == "" {
+			return ImportResult{}, fmt.Errorf("invalid-relation|missing lookup field for %s", field)
+		}
+	}
+
+	checkpoint, err := readCheckpoint(options.CheckpointPath, options.Collection)
+
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	source, err := newRowSource(reader, options.Format, options.Comma)
+
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	//Every CSV row has the same columns so unknown ones are reported once instead of rejecting every row
+	if csvRows, ok := source.(*csvSource); ok {
+		if unknown := unknownColumns(csvRows.header, options.Mapping, schema); len(unknown) > 0 {
+			return ImportResult{}, fmt.Errorf("unknown-field|%s are not fields of %s, map or skip them", strings.Join(unknown, ", "), schema.Name)
+		}
+	}
+
+	run := &importRun{
+		importer:  importer,
+		options:   options,
+		schema:    schema,
+		relations: map[string]string{},
+		result:    ImportResult{Rejected: []RejectedRow{}},
+	}
+
+	chunkSize := options.BatchSize
+
+	if chunkSize <= 0 {
+		chunkSize = DEFAULT_IMPORT_CHUNK_SIZE
+	}
+
+	chunk := []importRow{}
+	last := checkpoint
+
+	for number := 1; ; number++ {
+		row, err := source.next()
+
+		if err == io.EOF {
+			break
+		}
+
+		last = max(last, number)
+
+		var readErr *rowError
+
+		if errors.As(err, &readErr) {
+			if number > checkpoint {
+				run.reject(number, nil, readErr.err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return run.result, err
+		}
+
+		if number <= checkpoint {
+			run.result.Skipped++
+			continue
+		}
+
+		data, err := run.convert(row)
+
+		if err != nil {
+			run.reject(number, row, err)
+		} else {
+			chunk = append(chunk, importRow{number: number, source: row, data: data})
+		}
+
+		if len(chunk) >= chunkSize {
+			if err := run.flush(chunk, number); err != nil {
+				return run.result, err
+			}
+
+			chunk = []importRow{}
+		}
+	}
+
+	//Rejected rows after the last chunk still move the checkpoint
+	if err := run.flush(chunk, last); err != nil {
+		return run.result, err
+	}
+
+	sort.Slice(run.result.Rejected, func(i, j int) bool {
+		return run.result.Rejected[i].Row < run.result.Rejected[j].Row
+	})
+
+	return run.result, nil
+}
+
+func (run *importRun) reject(number int, row map[string]any, err error) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	run.result.Rejected = append(run.result.Rejected, RejectedRow{Row: number, Data: row, Err: err})
+}
+
+// Maps the source keys to fields, converts the values and resolves relations
+func (run *importRun) convert(row map[string]any) (map[string]any, error) {
+	data := map[string]any{}
+
+	for key, value := range row {
+		name := key
+
+		if mapped, ok := run.options.Mapping[key]; ok {
+			name = mapped
+		}
+
+		if name == "" || name == "-" {
+			continue
+		}
+
+		field, ok := run.schema.Field(name)
+
+		if !ok {
+			return nil, fmt.Errorf("unknown-field|%q is not a field of %s", name, run.schema.Name)
+		}
+
+		//Autodate values are set by the server
+		if field.Type == "autodate" {
+			continue
+		}
+
+		converted, err := coerceValue(field, value, run.options.ListSeparator)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid-value|%w", err)
+		}
+
+		if relation, ok := run.options.Relations[name]; ok && converted != nil {
+			converted, err = run.resolve(field, relation, converted)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if converted != nil {
+			data[name] = converted
+		}
+	}
+
+	return data, nil
+}
+
+// Replaces lookup values with record ids, lookups are cached for the whole import
+func (run *importRun) resolve(field services.CollectionField, relation Relation, value any) (any, error) {
+	collection := relation.Collection
+
+	if collection == "" {
+		collection = field.CollectionId()
+	}
+
+	lookup := func(key string) (string, error) {
+		cacheKey := collection + "\n" + key
+
+		run.mu.Lock()
+		id, ok := run.relations[cacheKey]
+		run.mu.Unlock()
+
+		if ok {
+			return id, nil
+		}
+
+		res, err := run.importer.Records.ListRecords(collection, run.options.Token, services.PocketBaseListOptions{
+			Page:      1,
+			PerPage:   2,
+			Filter:    filter.Eq(relation.LookupField, key).String(),
+			Fields:    "id",
+			SkipTotal: true,
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		if len(res.Items) != 1 {
+			return "", fmt.Errorf("relation-not-found|%s: %d %s records have %s %q", field.Name, len(res.Items), collection, relation.LookupField, key)
+		}
+
+		id, _ = res.Items[0]["id"].(string)
+
+		run.mu.Lock()
+		run.relations[cacheKey] = id
+		run.mu.Unlock()
+
+		return id, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		return lookup(v)
+	case []string:
+		ids := make([]string, len(v))
+
+		for i, key := range v {
+			id, err := lookup(key)
+
+			if err != nil {
+				return nil, err
+			}
+
+			ids[i] = id
+		}
+
+		return ids, nil
+	}
+
+	return value, nil
+}
+
+// Creates the rows of a chunk and moves the checkpoint past its last row
+func (run *importRun) flush(chunk []importRow, last int) error {
+	pending := chunk
+
+	if run.options.BatchSize > 0 && len(chunk) > 0 {
+		pending = run.createBatch(chunk)
+	}
+
+	run.createEach(pending)
+
+	return writeCheckpoint(run.options.CheckpointPath, run.options.Collection, last)
+}
+
+// Creates the chunk in a single batch, returns the rows to create one by one when the batch failed
+func (run *importRun) createBatch(chunk []importRow) []importRow {
+	requests := make([]services.BatchRequest, len(chunk))
+
+	for i, row := range chunk {
+		requests[i] = services.BatchCreate(run.options.Collection, row.data)
+	}
+
+	//A failed batch is rolled back, creating its rows one by one finds the rejected ones
+	if _, err := run.importer.Records.Batch(run.options.Token, requests); err != nil {
+		return chunk
+	}
+
+	run.mu.Lock()
+	run.result.Created += len(chunk)
+	run.mu.Unlock()
+
+	return nil
+}
+
+func (run *importRun) createEach(rows []importRow) {
+	workers := run.options.Workers
+
+	if workers <= 0 {
+		workers = DEFAULT_IMPORT_WORKERS
+	}
+
+	queue := make(chan importRow)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(workers, len(rows)); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for row := range queue {
+				if _, err := run.importer.Records.CreateNewRecord(run.options.Collection, run.options.Token, row.data); err != nil {
+					run.reject(row.number, row.source, err)
+					continue
+				}
+
+				run.mu.Lock()
+				run.result.Created++
+				run.mu.Unlock()
+			}
+		}()
+	}
+
+	for _, row := range rows {
+		queue <- row
+	}
+
+	close(queue)
+	wg.Wait()
+}
+
+// Source columns that are neither fields of the schema nor skipped by the mapping
+func unknownColumns(columns []string, mapping map[string]string, schema *services.CollectionSchema) []string {
+	unknown := []string{}
+
+	for _, column := range columns {
+		name := column
+
+		if mapped, ok := mapping[column]; ok {
+			name = mapped
+		}
+
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if _, ok := schema.Field(name); !ok {
+			unknown = append(unknown, column)
+		}
+	}
+
+	return unknown
+}
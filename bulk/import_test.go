@@ -0,0 +1,163 @@
+package bulk_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/bulk"
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func blogServer(t *testing.T) (*pbtest.Server, *services.Pocketbase, string) {
+	t.Helper()
+
+	server, pb, token := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Id: "pbc_authors", Name: "authors", Fields: []map[string]any{
+		{"name": "email", "type": "email", "required": true},
+	}})
+	server.AddCollection(pbtest.Collection{Id: "pbc_posts", Name: "posts", Fields: []map[string]any{
+		{"name": "title", "type": "text", "required": true},
+		{"name": "views", "type": "number"},
+		{"name": "tags", "type": "select", "maxSelect": 3, "values": []any{"go", "js", "sql"}},
+		{"name": "author", "type": "relation", "collectionId": "pbc_authors", "maxSelect": 1},
+	}, Indexes: []string{"CREATE UNIQUE INDEX idx_title ON posts (title)"}})
+
+	return server, pb, token
+}
+
+func TestImportCSVWithRelationLookups(t *testing.T) {
+	server, pb, token := blogServer(t)
+	author := server.Seed("authors", map[string]any{"email": "ann@example.com"})[0]
+
+	input := strings.Join([]string{
+		"Title,views,tags,author",
+		"First,1,\"go,sql\",ann@example.com",
+		"Second,not a number,go,ann@example.com",
+		"Third,3,,missing@example.com",
+		"Fourth,4,js,",
+	}, "\n")
+
+	result, err := bulk.NewImporter(pb).Import(strings.NewReader(input), bulk.ImportOptions{
+		Collection: "posts",
+		Token:      token,
+		Format:     bulk.CSV,
+		Mapping:    map[string]string{"Title": "title"},
+		Relations:  map[string]bulk.Relation{"author": {LookupField: "email"}},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Created != 2 || len(result.Rejected) != 2 {
+		t.Fatalf("created %d, rejected %v", result.Created, result.Rejected)
+	}
+
+	for i, row := range []int{2, 3} {
+		if result.Rejected[i].Row != row {
+			t.Fatalf("rejected rows %v, expected 2 and 3", result.Rejected)
+		}
+	}
+
+	for _, record := range server.Records("posts") {
+		if record["title"] == "First" {
+			tags, _ := record["tags"].([]any)
+
+			if record["views"] != float64(1) || len(tags) != 2 || record["author"] != author["id"] {
+				t.Fatalf("first row stored as %v", record)
+			}
+		}
+	}
+}
+
+func TestImportNDJSONContinuesFromTheCheckpoint(t *testing.T) {
+	server, pb, token := blogServer(t)
+	checkpoint := filepath.Join(t.TempDir(), "posts.checkpoint")
+
+	options := bulk.ImportOptions{Collection: "posts", Token: token, Format: bulk.NDJSON, CheckpointPath: checkpoint, Workers: 1}
+	first := `{"title":"a"}` + "\n" + `{"title":"b"}` + "\n"
+
+	if result, err := bulk.NewImporter(pb).Import(strings.NewReader(first), options); err != nil || result.Created != 2 {
+		t.Fatalf("first import = %v, %v", result, err)
+	}
+
+	//The same file with a row appended only creates the new row
+	result, err := bulk.NewImporter(pb).Import(strings.NewReader(first+`{"title":"c"}`+"\n"), options)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Created != 1 || result.Skipped != 2 || len(server.Records("posts")) != 3 {
+		t.Fatalf("second import = %v with %d records stored", result, len(server.Records("posts")))
+	}
+}
+
+func TestImportRejectsUnknownCSVColumns(t *testing.T) {
+	_, pb, token := blogServer(t)
+
+	_, err := bulk.NewImporter(pb).Import(strings.NewReader("title,subtitle\na,b\n"), bulk.ImportOptions{Collection: "posts", Token: token, Format: bulk.CSV})
+
+	if err == nil || !strings.HasPrefix(err.Error(), "unknown-field") {
+		t.Fatalf("expected unknown-field, got %v", err)
+	}
+}
+
+func TestImportJSONBatchesFallBackToSingleCreates(t *testing.T) {
+	server, pb, token := blogServer(t)
+	server.EnableBatch(0)
+	server.Seed("posts", map[string]any{"title": "taken"})
+
+	input := `[{"title":"a","views":1},{"title":"taken"},{"title":"c","tags":["go","js"]},{"title":"d"}]`
+
+	result, err := bulk.NewImporter(pb).Import(strings.NewReader(input), bulk.ImportOptions{Collection: "posts", Token: token, Format: bulk.JSON, BatchSize: 2})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Created != 3 || len(result.Rejected) != 1 || result.Rejected[0].Row != 2 {
+		t.Fatalf("created %d, rejected %v", result.Created, result.Rejected)
+	}
+
+	//The rolled back batch of the rejected row must not leave a copy of its other row behind
+	if stored := len(server.Records("posts")); stored != 4 {
+		t.Fatalf("%d records stored, expected 4", stored)
+	}
+
+	batches := 0
+
+	for _, request := range server.Requests() {
+		if request.Path == "/api/batch" {
+			batches++
+		}
+	}
+
+	if batches != 2 {
+		t.Fatalf("sent %d batches for 4 rows in chunks of 2", batches)
+	}
+}
+
+func TestImportMappingSkipsColumns(t *testing.T) {
+	server, pb, token := blogServer(t)
+
+	input := "Heading;internal;views\nFirst;x;2\n"
+
+	result, err := bulk.NewImporter(pb).Import(strings.NewReader(input), bulk.ImportOptions{
+		Collection: "posts",
+		Token:      token,
+		Format:     bulk.CSV,
+		Comma:      ';',
+		Mapping:    map[string]string{"Heading": "title", "internal": "-"},
+	})
+
+	if err != nil || result.Created != 1 {
+		t.Fatalf("import = %v, %v", result, err)
+	}
+
+	if records := server.Records("posts"); len(records) != 1 || records[0]["title"] != "First" || records[0]["views"] != float64(2) {
+		t.Fatalf("stored %v", records)
+	}
+}
@@ -0,0 +1,166 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Input and output formats
+type Format int
+
+const (
+	CSV Format = iota
+	JSON
+	NDJSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case CSV:
+		return "csv"
+	case JSON:
+		return "json"
+	case NDJSON:
+		return "ndjson"
+	default:
+		return "unknown"
+	}
+}
+
+// Reads rows one at a time, next returns io.EOF after the last row and a *rowError for a row that
+// cannot be read while the rows after it still can
+type rowSource interface {
+	next() (map[string]any, error)
+}
+
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rowError) Unwrap() error {
+	return e.err
+}
+
+func newRowSource(reader io.Reader, format Format, comma rune) (rowSource, error) {
+	switch format {
+	case CSV:
+		csvReader := csv.NewReader(reader)
+		csvReader.FieldsPerRecord = -1
+
+		if comma != 0 {
+			csvReader.Comma = comma
+		}
+
+		header, err := csvReader.Read()
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid-csv|missing header: %w", err)
+		}
+
+		return &csvSource{reader: csvReader, header: header}, nil
+	case JSON:
+		decoder := json.NewDecoder(reader)
+		decoder.UseNumber()
+
+		if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+			return nil, errors.New("invalid-json|expected an array of records")
+		}
+
+		return &jsonSource{decoder: decoder}, nil
+	case NDJSON:
+		return &ndjsonSource{reader: bufio.NewReader(reader)}, nil
+	}
+
+	return nil, fmt.Errorf("invalid-format|%d", format)
+}
+
+type csvSource struct {
+	reader *csv.Reader
+	header []string
+}
+
+func (source *csvSource) next() (map[string]any, error) {
+	values, err := source.reader.Read()
+
+	var parseErr *csv.ParseError
+
+	if errors.As(err, &parseErr) {
+		return nil, &rowError{err: fmt.Errorf("invalid-csv|%w", err)}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]any, len(source.header))
+
+	for i, column := range source.header {
+		if i < len(values) {
+			row[column] = values[i]
+		}
+	}
+
+	return row, nil
+}
+
+// Reads a JSON array item by item, a malformed item ends the import as the rest cannot be found reliably
+type jsonSource struct {
+	decoder *json.Decoder
+}
+
+func (source *jsonSource) next() (map[string]any, error) {
+	if !source.decoder.More() {
+		return nil, io.EOF
+	}
+
+	row := map[string]any{}
+
+	if err := source.decoder.Decode(&row); err != nil {
+		return nil, fmt.Errorf("invalid-json|%w", err)
+	}
+
+	return row, nil
+}
+
+// Reads one JSON object per line, blank lines are skipped and malformed lines are rejected
+type ndjsonSource struct {
+	reader *bufio.Reader
+}
+
+func (source *ndjsonSource) next() (map[string]any, error) {
+	for {
+		line, err := source.reader.ReadBytes('\n')
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+
+		row := map[string]any{}
+
+		if decodeErr := decoder.Decode(&row); decodeErr != nil {
+			return nil, &rowError{err: fmt.Errorf("invalid-json|%w", decodeErr)}
+		}
+
+		return row, nil
+	}
+}
@@ -0,0 +1,143 @@
+// Package cassette records HTTP exchanges with a PocketBase instance to a JSON file and replays them offline
+package cassette
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+type Mode int
+
+const (
+	// Sends requests to the real instance and stores every exchange
+	ModeRecord Mode = iota
+	// Answers requests from the stored exchanges without touching the network
+	ModeReplay
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeRecord:
+		return "record"
+	case ModeReplay:
+		return "replay"
+	default:
+		return "record"
+	}
+}
+
+type Request struct {
+	Method  string              `json:"method"`
+	Path    string              `json:"path"`
+	Query   string              `json:"query"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+}
+
+// A stored response, Stream is set for text/event-stream responses whose body holds the raw event stream
+type Response struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers"`
+	Body    string              `json:"body"`
+	Stream  bool                `json:"stream,omitempty"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+func Load(path string) (*Cassette, error) {
+	raw, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{}
+
+	if err := json.Unmarshal(raw, cassette); err != nil {
+		return nil, err
+	}
+
+	return cassette, nil
+}
+
+func (cassette *Cassette) Save(path string) error {
+	raw, err := json.MarshalIndent(cassette, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(raw, '\n'), 0o644)
+}
+
+// Masks credentials in headers before anything is stored
+func scrubHeaders(headers http.Header) map[string][]string {
+	scrubbed := map[string][]string{}
+
+	for k, v := range services.RedactHeaders(headers) {
+		scrubbed[k] = v
+	}
+
+	return scrubbed
+}
+
+// Masks the secrets of JSON objects and arrays and of multipart fields, other bodies are stored as they are
+func scrubBody(body string, contentType string) string {
+	if parts, boundary, ok := readMultipart(body, contentType); ok {
+		scrubbed, err := writeMultipart(scrubParts(parts), boundary)
+
+		if err != nil {
+			return body
+		}
+
+		return scrubbed
+	}
+
+	trimmed := strings.TrimSpace(body)
+
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return body
+	}
+
+	var decoded any
+
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return body
+	}
+
+	scrubbed, err := json.Marshal(scrubValue(decoded))
+
+	if err != nil {
+		return body
+	}
+
+	return string(scrubbed)
+}
+
+func scrubValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return services.RedactBody(v)
+	case []any:
+		items := make([]any, len(v))
+
+		for i, item := range v {
+			items[i] = scrubValue(item)
+		}
+
+		return items
+	default:
+		return v
+	}
+}
@@ -0,0 +1,235 @@
+package cassette_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/cassette"
+	"github.com/JGugino/pb-go/services"
+)
+
+// Answers every request with the response or with its method, path and body size so replays can be checked against the recording
+func echoServer(t *testing.T, response string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if response != "" {
+			w.Write([]byte(response))
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, `{"echo":"%s %s %d bytes"}`, r.Method, r.URL.Path, len(body))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func send(t *testing.T, client *http.Client, method string, url string, contentType string, body string) (string, error) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	raw, err := io.ReadAll(res.Body)
+
+	return string(raw), err
+}
+
+// Records fn against the server, then replays it from the saved cassette with the server closed
+func roundTrip(t *testing.T, server *httptest.Server, record func(client *http.Client)) (*cassette.Recorder, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := cassette.New(path, cassette.ModeRecord, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record(recorder.Client())
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Close()
+
+	replayer, err := cassette.New(path, cassette.ModeReplay, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return replayer, path
+}
+
+func multipartBody(t *testing.T, fields map[string]string, file string) (string, string) {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for _, name := range []string{"title", "password", "@jsonPayload"} {
+		if value, ok := fields[name]; ok {
+			writer.WriteField(name, value)
+		}
+	}
+
+	if file != "" {
+		part, _ := writer.CreateFormFile("document", "notes.txt")
+		part.Write([]byte(file))
+	}
+
+	writer.Close()
+
+	return body.String(), writer.FormDataContentType()
+}
+
+func TestReplayMatchesJSONBodiesByValue(t *testing.T) {
+	server := echoServer(t, "")
+	url := server.URL + "/api/collections/posts/records"
+	recorded := ""
+
+	replayer, _ := roundTrip(t, server, func(client *http.Client) {
+		var err error
+
+		if recorded, err = send(t, client, "POST", url, "application/json", `{"title":"a","views":1}`); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	replayed, err := send(t, replayer.Client(), "POST", url, "application/json", "{\n  \"views\": 1,\n  \"title\": \"a\"\n}")
+
+	if err != nil || replayed != recorded {
+		t.Fatalf("replayed %q, recorded %q, %v", replayed, recorded, err)
+	}
+
+	_, err = send(t, replayer.Client(), "POST", url, "application/json", `{"title":"b","views":1}`)
+
+	unmatched := &cassette.UnmatchedRequestError{}
+
+	if !errors.As(err, &unmatched) || len(unmatched.Differences) != 1 || !strings.HasPrefix(unmatched.Differences[0], "body:") {
+		t.Fatalf("expected a body difference, got %v", err)
+	}
+}
+
+func TestReplayMatchesMultipartBodiesByPart(t *testing.T) {
+	server := echoServer(t, "")
+	url := server.URL + "/api/collections/posts/records"
+	fields := map[string]string{"title": "a", "@jsonPayload": `{"tags":["go"],"views":1}`}
+
+	replayer, _ := roundTrip(t, server, func(client *http.Client) {
+		body, contentType := multipartBody(t, fields, "hello")
+
+		if _, err := send(t, client, "POST", url, contentType, body); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	//A new writer picks a new boundary and the JSON field changes its key order
+	body, contentType := multipartBody(t, map[string]string{"title": "a", "@jsonPayload": `{"views":1,"tags":["go"]}`}, "hello")
+
+	if _, err := send(t, replayer.Client(), "POST", url, contentType, body); err != nil {
+		t.Fatalf("multipart body with another boundary did not match: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		fields map[string]string
+		file   string
+	}{
+		{"other field value", map[string]string{"title": "b", "@jsonPayload": fields["@jsonPayload"]}, "hello"},
+		{"other file", fields, "bye"},
+		{"missing file", fields, ""},
+	}
+
+	for _, c := range cases {
+		body, contentType := multipartBody(t, c.fields, c.file)
+
+		if _, err := send(t, replayer.Client(), "POST", url, contentType, body); err == nil {
+			t.Errorf("%s matched the recording", c.name)
+		}
+	}
+}
+
+func TestRecordScrubsSecrets(t *testing.T) {
+	cases := []struct {
+		name     string
+		response string
+		body     func(t *testing.T) (string, string)
+	}{
+		{"json object", `{"token":"response-secret","record":{"id":"a"}}`, func(t *testing.T) (string, string) {
+			return `{"identity":"a@example.com","password":"request-secret"}`, "application/json"
+		}},
+		{"json array", `[{"id":"a","token":"response-secret"},{"id":"b","meta":[{"secret":"response-secret"}]}]`, func(t *testing.T) (string, string) {
+			return `[{"password":"request-secret"},{"nested":{"token":"request-secret"}}]`, "application/json"
+		}},
+		{"multipart", `{"items":[{"token":"response-secret"}]}`, func(t *testing.T) (string, string) {
+			return multipartBody(t, map[string]string{"title": "a", "password": "request-secret", "@jsonPayload": `{"oldPassword":"request-secret"}`}, "")
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := echoServer(t, c.response)
+			url := server.URL + "/api/collections/users/auth-with-password"
+			body, contentType := c.body(t)
+
+			replayer, path := roundTrip(t, server, func(client *http.Client) {
+				if _, err := send(t, client, "POST", url, contentType, body); err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			stored, err := os.ReadFile(path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(string(stored), "request-secret") || strings.Contains(string(stored), "response-secret") {
+				t.Fatalf("secret stored in the cassette:\n%s", stored)
+			}
+
+			//The request with the real secrets still matches its scrubbed recording
+			replayed, err := send(t, replayer.Client(), "POST", url, contentType, body)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(replayed, "response-secret") || !strings.Contains(replayed, services.REDACTED) {
+				t.Fatalf("replayed %q", replayed)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package cassette
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// Sorts query keys and values and masks file tokens so equal queries compare equal
+func normalizeQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+
+	if err != nil {
+		return rawQuery
+	}
+
+	if values.Has("token") {
+		values.Set("token", services.REDACTED)
+	}
+
+	for k := range values {
+		sort.Strings(values[k])
+	}
+
+	//Encode already sorts by key
+	return values.Encode()
+}
+
+// Re-encodes JSON bodies so key order and whitespace do not matter and multipart bodies as their parts so the
+// boundary does not, other bodies are compared as is
+func normalizeBody(body string, contentType string) string {
+	if parts, _, ok := readMultipart(body, contentType); ok {
+		parts = scrubParts(parts)
+
+		for i := range parts {
+			if parts[i].Filename == "" {
+				parts[i].Body = normalizeBody(parts[i].Body, parts[i].ContentType)
+			}
+		}
+
+		normalized, err := json.Marshal(parts)
+
+		if err == nil {
+			return string(normalized)
+		}
+	}
+
+	scrubbed := scrubBody(body, contentType)
+
+	var decoded any
+
+	if err := json.Unmarshal([]byte(scrubbed), &decoded); err != nil {
+		return scrubbed
+	}
+
+	normalized, err := json.Marshal(decoded)
+
+	if err != nil {
+		return scrubbed
+	}
+
+	if string(normalized) == "{}" || string(normalized) == "null" {
+		return ""
+	}
+
+	return string(normalized)
+}
+
+func newRequestKey(method string, path string, rawQuery string, contentType string, body string) Request {
+	request := Request{
+		Method: strings.ToUpper(method),
+		Path:   path,
+		Query:  normalizeQuery(rawQuery),
+		Body:   normalizeBody(body, contentType),
+	}
+
+	if contentType != "" {
+		request.Headers = map[string][]string{"Content-Type": {contentType}}
+	}
+
+	return request
+}
+
+func (request Request) contentType() string {
+	for k, v := range request.Headers {
+		if strings.EqualFold(k, "Content-Type") && len(v) > 0 {
+			return v[0]
+		}
+	}
+
+	return ""
+}
+
+func (request Request) matches(other Request) bool {
+	return len(request.differences(other)) == 0
+}
+
+// Lists every part of the stored request that differs from the incoming one
+func (request Request) differences(incoming Request) []string {
+	diffs := []string{}
+
+	if request.Method != incoming.Method {
+		diffs = append(diffs, fmt.Sprintf("method: recorded %q, got %q", request.Method, incoming.Method))
+	}
+
+	if request.Path != incoming.Path {
+		diffs = append(diffs, fmt.Sprintf("path: recorded %q, got %q", request.Path, incoming.Path))
+	}
+
+	if normalizeQuery(request.Query) != incoming.Query {
+		diffs = append(diffs, fmt.Sprintf("query: recorded %q, got %q", normalizeQuery(request.Query), incoming.Query))
+	}
+
+	if recorded := normalizeBody(request.Body, request.contentType()); recorded != incoming.Body {
+		diffs = append(diffs, fmt.Sprintf("body: recorded %s, got %s", quoteBody(recorded), quoteBody(incoming.Body)))
+	}
+
+	return diffs
+}
+
+func quoteBody(body string) string {
+	if body == "" {
+		return "<empty>"
+	}
+
+	return body
+}
+
+// Returned in replay mode when no stored interaction matches, lists how the closest one differs
+type UnmatchedRequestError struct {
+	Request     Request
+	Closest     *Request
+	Differences []string
+}
+
+func (e *UnmatchedRequestError) Error() string {
+	var message strings.Builder
+
+	fmt.Fprintf(&message, "cassette: no recorded interaction for %s %s", e.Request.Method, e.Request.Path)
+
+	if e.Request.Query != "" {
+		message.WriteString("?" + e.Request.Query)
+	}
+
+	if e.Closest == nil {
+		message.WriteString(", the cassette is empty")
+		return message.String()
+	}
+
+	fmt.Fprintf(&message, "\nclosest recorded interaction %s %s differs in:", e.Closest.Method, e.Closest.Path)
+
+	for _, diff := range e.Differences {
+		message.WriteString("\n  - " + diff)
+	}
+
+	return message.String()
+}
+
+// Picks the interaction with the fewest differences, method and path mismatches weigh the most
+func closestRequest(interactions []Interaction, incoming Request) (*Request, []string) {
+	var closest *Request
+	closestDiffs := []string{}
+	bestScore := -1
+
+	for i := range interactions {
+		stored := interactions[i].Request
+		diffs := stored.differences(incoming)
+
+		score := len(diffs)
+
+		if stored.Method != incoming.Method {
+			score += 10
+		}
+
+		if stored.Path != incoming.Path {
+			score += 20
+		}
+
+		if bestScore == -1 || score < bestScore {
+			closest = &interactions[i].Request
+			closestDiffs = diffs
+			bestScore = score
+		}
+	}
+
+	return closest, closestDiffs
+}
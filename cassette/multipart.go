@@ -0,0 +1,106 @@
+package cassette
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// A single part of a multipart body, compared without the boundary that is random for every request
+type formPart struct {
+	Name        string `json:"name"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body"`
+	header      textproto.MIMEHeader
+}
+
+// Splits a multipart body into its parts, ok is false when the body is not multipart or can not be parsed
+func readMultipart(body string, contentType string) (parts []formPart, boundary string, ok bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, "", false
+	}
+
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	parts = []formPart{}
+
+	for {
+		part, err := reader.NextRawPart()
+
+		if err == io.EOF {
+			return parts, params["boundary"], true
+		}
+
+		if err != nil {
+			return nil, "", false
+		}
+
+		raw, err := io.ReadAll(part)
+
+		if err != nil {
+			return nil, "", false
+		}
+
+		parts = append(parts, formPart{
+			Name:        part.FormName(),
+			Filename:    part.FileName(),
+			ContentType: part.Header.Get("Content-Type"),
+			Body:        string(raw),
+			header:      part.Header,
+		})
+	}
+}
+
+// Masks sensitive form fields and the secrets in JSON fields like @jsonPayload, files are kept as they are
+func scrubParts(parts []formPart) []formPart {
+	scrubbed := make([]formPart, len(parts))
+
+	for i, part := range parts {
+		if part.Filename == "" {
+			if redacted := services.RedactBody(map[string]any{part.Name: part.Body}); redacted[part.Name] == services.REDACTED {
+				part.Body = services.REDACTED
+			} else {
+				part.Body = scrubBody(part.Body, part.ContentType)
+			}
+		}
+
+		scrubbed[i] = part
+	}
+
+	return scrubbed
+}
+
+// Encodes the parts again with the boundary they were read with so the stored Content-Type stays valid
+func writeMultipart(parts []formPart, boundary string) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	if err := writer.SetBoundary(boundary); err != nil {
+		return "", err
+	}
+
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(part.header)
+
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := partWriter.Write([]byte(part.Body)); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
@@ -0,0 +1,265 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RoundTripper that records exchanges to a cassette file or replays them from it
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// Creates a recorder for the cassette file, replay mode loads the file straight away
+//
+// A nil transport records through http.DefaultTransport.
+func New(path string, mode Mode, transport http.RoundTripper) (*Recorder, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	recorder := &Recorder{
+		mode:      mode,
+		path:      path,
+		transport: transport,
+		cassette:  &Cassette{},
+	}
+
+	if mode == ModeReplay {
+		cassette, err := Load(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		recorder.cassette = cassette
+		recorder.used = make([]bool, len(cassette.Interactions))
+	}
+
+	return recorder, nil
+}
+
+// Picks replay mode when the cassette file exists and record mode otherwise
+func NewAuto(path string, transport http.RoundTripper) (*Recorder, error) {
+	if _, err := os.Stat(path); err == nil {
+		return New(path, ModeReplay, transport)
+	}
+
+	return New(path, ModeRecord, transport)
+}
+
+func (recorder *Recorder) Mode() Mode {
+	return recorder.mode
+}
+
+// Returns an http client that sends every request through the recorder
+func (recorder *Recorder) Client() *http.Client {
+	return &http.Client{Transport: recorder}
+}
+
+// Writes the recorded interactions to the cassette file, does nothing in replay mode
+func (recorder *Recorder) Save() error {
+	if recorder.mode != ModeRecord {
+		return nil
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return recorder.cassette.Save(recorder.path)
+}
+
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if recorder.mode == ModeReplay {
+		return recorder.replay(req, body)
+	}
+
+	return recorder.record(req, body)
+}
+
+func (recorder *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	res, err := recorder.transport.RoundTrip(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Query:   normalizeQuery(req.URL.RawQuery),
+			Headers: scrubHeaders(req.Header),
+			Body:    scrubBody(string(body), req.Header.Get("Content-Type")),
+		},
+		Response: Response{
+			Status:  res.StatusCode,
+			Headers: scrubHeaders(res.Header),
+			Stream:  isEventStream(res.Header),
+		},
+	}
+
+	recorder.mu.Lock()
+	recorder.cassette.Interactions = append(recorder.cassette.Interactions, interaction)
+	index := len(recorder.cassette.Interactions) - 1
+	recorder.mu.Unlock()
+
+	//Streams stay open so the body is captured while the caller reads it
+	if interaction.Response.Stream {
+		res.Body = &streamCapture{body: res.Body, recorder: recorder, index: index}
+		return res, nil
+	}
+
+	raw, err := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	recorder.mu.Lock()
+	recorder.cassette.Interactions[index].Response.Body = scrubBody(string(raw), res.Header.Get("Content-Type"))
+	recorder.mu.Unlock()
+
+	res.Body = io.NopCloser(bytes.NewReader(raw))
+
+	return res, nil
+}
+
+func (recorder *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	incoming := newRequestKey(req.Method, req.URL.Path, req.URL.RawQuery, req.Header.Get("Content-Type"), string(body))
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	matched := -1
+
+	//Identical requests replay their recordings in order, the last one is repeated once all were used
+	for i, interaction := range recorder.cassette.Interactions {
+		if !interaction.Request.matches(incoming) {
+			continue
+		}
+
+		matched = i
+
+		if !recorder.used[i] {
+			break
+		}
+	}
+
+	if matched == -1 {
+		closest, diffs := closestRequest(recorder.cassette.Interactions, incoming)
+
+		return nil, &UnmatchedRequestError{Request: incoming, Closest: closest, Differences: diffs}
+	}
+
+	recorder.used[matched] = true
+	stored := recorder.cassette.Interactions[matched].Response
+
+	header := http.Header{}
+
+	for k, v := range stored.Headers {
+		header[k] = append([]string{}, v...)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", stored.Status, http.StatusText(stored.Status)),
+		StatusCode:    stored.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(stored.Body)),
+		ContentLength: int64(len(stored.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Returns the stored interactions that were never replayed, useful to spot stale cassettes
+func (recorder *Recorder) Unused() []Interaction {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	unused := []Interaction{}
+
+	for i, used := range recorder.used {
+		if !used {
+			unused = append(unused, recorder.cassette.Interactions[i])
+		}
+	}
+
+	return unused
+}
+
+// Copies a stream body into the cassette as it is read
+type streamCapture struct {
+	body     io.ReadCloser
+	recorder *Recorder
+	index    int
+}
+
+func (capture *streamCapture) Read(p []byte) (int, error) {
+	n, err := capture.body.Read(p)
+
+	if n > 0 {
+		capture.recorder.mu.Lock()
+		capture.recorder.cassette.Interactions[capture.index].Response.Body += string(p[:n])
+		capture.recorder.mu.Unlock()
+	}
+
+	return n, err
+}
+
+func (capture *streamCapture) Close() error {
+	return capture.body.Close()
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func isEventStream(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "text/event-stream")
+}
@@ -0,0 +1,91 @@
+// Command pbdocs writes Markdown docs and an ER diagram for the collections of a PocketBase instance
+//
+//	go run github.com/JGugino/pb-go/cmd/pbdocs -url http://localhost:8090 -out SCHEMA.md -diagram schema.mmd
+//	go run github.com/JGugino/pb-go/cmd/pbdocs -schema pb_schema.json -diagram schema.dot
+//
+// With -url the collections are read from a live instance as a superuser, the credentials come from
+// PB_IDENTITY and PB_PASSWORD. With -schema they are read from a collections export of the dashboard.
+//
+// The diagram is written as Mermaid, or as Graphviz when -diagram ends in .dot or .gv. With -embed the
+// Mermaid diagram is also added to the top of the Markdown docs.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JGugino/pb-go/codegen"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "pbdocs:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	url := flag.String("url", os.Getenv("PB_URL"), "url of the instance, defaults to PB_URL")
+	schema := flag.String("schema", "", "collections export to read instead of an instance")
+	authCollection := flag.String("auth-collection", "_superusers", "collection the credentials belong to")
+	out := flag.String("out", "SCHEMA.md", "Markdown file to write, - for stdout, empty to skip")
+	diagram := flag.String("diagram", "", "ER diagram file to write, .dot or .gv for Graphviz and Mermaid otherwise")
+	collections := flag.String("collections", "", "comma separated collections to document, all non system ones by default")
+	system := flag.Bool("system", false, "also document system collections")
+	embed := flag.Bool("embed", false, "embed the Mermaid diagram in the Markdown docs")
+	title := flag.String("title", "", "title of the Markdown docs")
+
+	flag.Parse()
+
+	loaded, source, err := codegen.LoadSource(*url, *schema, *authCollection, os.Getenv("PB_IDENTITY"), os.Getenv("PB_PASSWORD"))
+
+	if err != nil {
+		return err
+	}
+
+	options := codegen.Options{
+		Source:       source,
+		System:       *system,
+		Title:        *title,
+		EmbedDiagram: *embed,
+	}
+
+	if *collections != "" {
+		options.Collections = strings.Split(*collections, ",")
+	}
+
+	if *out != "" {
+		docs, err := codegen.Markdown(loaded, options)
+
+		if err != nil {
+			return err
+		}
+
+		if err := write(*out, docs); err != nil {
+			return err
+		}
+	}
+
+	if *diagram != "" {
+		switch strings.ToLower(filepath.Ext(*diagram)) {
+		case ".dot", ".gv":
+			return write(*diagram, codegen.Graphviz(loaded, options))
+		default:
+			return write(*diagram, codegen.Mermaid(loaded, options))
+		}
+	}
+
+	return nil
+}
+
+func write(path string, output []byte) error {
+	if path == "-" {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+
+	return os.WriteFile(path, output, 0o644)
+}
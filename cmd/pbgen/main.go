@@ -0,0 +1,147 @@
+// Command pbgen writes Go structs, enums and repositories for PocketBase collections
+//
+// Add a directive next to the package that should hold the types and run go generate:
+//
+//	//go:generate go run github.com/JGugino/pb-go/cmd/pbgen -url http://localhost:8090 -out pb_models.go
+//	//go:generate go run github.com/JGugino/pb-go/cmd/pbgen -schema pb_schema.json -out pb_models.go
+//
+// With -url the collections are read from a live instance as a superuser, the credentials come from
+// PB_IDENTITY and PB_PASSWORD. With -schema they are read from a collections export of the dashboard.
+//
+// With -format jsonschema a collection.schema.json document of the records and a collection.create.schema.json
+// document of create bodies are written per collection into the -out directory, with -format openapi a single
+// OpenAPI 3.1 spec is written to -out.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JGugino/pb-go/codegen"
+	"github.com/JGugino/pb-go/services"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "pbgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	url := flag.String("url", os.Getenv("PB_URL"), "url of the instance, defaults to PB_URL")
+	schema := flag.String("schema", "", "collections export to read instead of an instance")
+	authCollection := flag.String("auth-collection", "_superusers", "collection the credentials belong to")
+	format := flag.String("format", "go", "output format: go, jsonschema or openapi")
+	out := flag.String("out", "", "file to write, a directory for jsonschema, defaults to pb_models.go, schemas or openapi.json")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file, defaults to the package running go generate")
+	collections := flag.String("collections", "", "comma separated collections to generate, all non system ones by default")
+	system := flag.Bool("system", false, "also generate system collections")
+	expand := flag.Bool("expand", true, "generate expand structs for relations")
+	repositories := flag.Bool("repositories", true, "generate a repository per collection")
+	typeNames := flag.String("types", "", "comma separated collection=Type overrides like people=Person")
+	title := flag.String("title", "", "title of the OpenAPI spec")
+	server := flag.String("server", "", "server url of the OpenAPI spec")
+
+	flag.Parse()
+
+	loaded, source, err := codegen.LoadSource(*url, *schema, *authCollection, os.Getenv("PB_IDENTITY"), os.Getenv("PB_PASSWORD"))
+
+	if err != nil {
+		return err
+	}
+
+	options := codegen.Options{
+		Package:      *pkg,
+		Source:       source,
+		System:       *system,
+		Expand:       *expand,
+		Repositories: *repositories,
+		TypeNames:    map[string]string{},
+		Title:        *title,
+		ServerURL:    *server,
+	}
+
+	if *collections != "" {
+		options.Collections = strings.Split(*collections, ",")
+	}
+
+	for _, pair := range strings.Split(*typeNames, ",") {
+		if collection, typeName, ok := strings.Cut(pair, "="); ok {
+			options.TypeNames[strings.TrimSpace(collection)] = strings.TrimSpace(typeName)
+		}
+	}
+
+	switch *format {
+	case "go":
+		return writeGo(loaded, options, defaultPath(*out, "pb_models.go"))
+	case "jsonschema":
+		return writeJSONSchemas(loaded, options, defaultPath(*out, "schemas"))
+	case "openapi":
+		return writeOpenAPI(loaded, options, defaultPath(*out, "openapi.json"))
+	}
+
+	return fmt.Errorf("invalid-format|unknown format %q", *format)
+}
+
+func defaultPath(path string, fallback string) string {
+	if path == "" {
+		return fallback
+	}
+
+	return path
+}
+
+func writeGo(collections []services.PocketBaseCollectionResponse, options codegen.Options, path string) error {
+	output, err := codegen.Generate(collections, options)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, output, 0o644)
+}
+
+func writeJSONSchemas(collections []services.PocketBaseCollectionResponse, options codegen.Options, dir string) error {
+	documents, err := codegen.JSONSchemas(collections, options)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for name, document := range documents {
+		if err := writeJSON(filepath.Join(dir, name+".schema.json"), document); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeOpenAPI(collections []services.PocketBaseCollectionResponse, options codegen.Options, path string) error {
+	spec, err := codegen.OpenAPI(collections, options)
+
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(path, spec)
+}
+
+func writeJSON(path string, value any) error {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(encoded, '\n'), 0o644)
+}
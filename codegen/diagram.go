@@ -0,0 +1,196 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// Relation between two selected collections, relations to collections left out of the diagram are skipped
+type diagramEdge struct {
+	from    services.PocketBaseCollectionResponse
+	to      services.PocketBaseCollectionResponse
+	field   services.CollectionField
+	cascade bool
+}
+
+func diagramEdges(collections []services.PocketBaseCollectionResponse, selected []services.PocketBaseCollectionResponse) []diagramEdge {
+	builder := newSchemaBuilder(collections)
+	edges := []diagramEdge{}
+
+	for _, collection := range selected {
+		for _, field := range builder.byId[collection.Id].Fields {
+			if field.Type != "relation" {
+				continue
+			}
+
+			for _, target := range selected {
+				if target.Id == field.CollectionId() || target.Name == field.CollectionId() {
+					edges = append(edges, diagramEdge{from: collection, to: target, field: field, cascade: field.OptionBool("cascadeDelete")})
+				}
+			}
+		}
+	}
+
+	return edges
+}
+
+// Writes a Mermaid erDiagram of the selected collections and their relation fields
+//
+// Relations are drawn from the collection holding the field, multiple relations as many to many. View
+// collections are labelled as views and their relations drawn dotted, cascading relations are commented.
+func Mermaid(collections []services.PocketBaseCollectionResponse, options Options) []byte {
+	gen := &generator{options: options}
+	selected := gen.selectCollections(collections)
+	builder := newSchemaBuilder(collections)
+	out := &bytes.Buffer{}
+
+	out.WriteString("erDiagram\n")
+
+	for _, collection := range selected {
+		name := collection.Name
+
+		if collection.Type == "view" {
+			name += fmt.Sprintf(`["%s (view)"]`, collection.Name)
+		}
+
+		fmt.Fprintf(out, "    %s {\n", name)
+
+		for _, field := range builder.byId[collection.Id].Fields {
+			fieldType := field.Type
+
+			if field.IsMultiple() {
+				fieldType += "[]"
+			}
+
+			fmt.Fprintf(out, "        %s %s", fieldType, field.Name)
+
+			switch {
+			case field.Name == "id":
+				out.WriteString(" PK")
+			case field.Type == "relation":
+				out.WriteString(" FK")
+			}
+
+			if field.OptionBool("cascadeDelete") {
+				out.WriteString(` "cascade delete"`)
+			}
+
+			out.WriteString("\n")
+		}
+
+		out.WriteString("    }\n")
+	}
+
+	for _, edge := range diagramEdges(collections, selected) {
+		//The right side is the cardinality of the related record as seen from the field
+		target := "o|"
+
+		switch {
+		case edge.field.IsMultiple():
+			target = "o{"
+		case edge.field.Required:
+			target = "||"
+		}
+
+		line := "--"
+
+		if edge.from.Type == "view" {
+			line = ".."
+		}
+
+		label := edge.field.Name
+
+		if edge.cascade {
+			label += " (cascade delete)"
+		}
+
+		fmt.Fprintf(out, "    %s }o%s%s %s : \"%s\"\n", edge.from.Name, line, target, edge.to.Name, label)
+	}
+
+	return out.Bytes()
+}
+
+// Writes a Graphviz digraph of the selected collections with a table per collection and an edge per relation
+//
+// View collections are drawn dashed, cascading relations red.
+func Graphviz(collections []services.PocketBaseCollectionResponse, options Options) []byte {
+	gen := &generator{options: options}
+	selected := gen.selectCollections(collections)
+	builder := newSchemaBuilder(collections)
+	out := &bytes.Buffer{}
+
+	out.WriteString("digraph collections {\n")
+	out.WriteString("    rankdir=LR;\n")
+	out.WriteString("    node [shape=plain, fontname=\"Helvetica\"];\n")
+	out.WriteString("    edge [fontname=\"Helvetica\", fontsize=10, dir=both, arrowtail=crow];\n\n")
+
+	for _, collection := range selected {
+		title := html.EscapeString(collection.Name)
+		style := ""
+
+		if collection.Type == "view" {
+			title += " (view)"
+			style = ` style="dashed"`
+		}
+
+		fmt.Fprintf(out, "    %q [label=<<table border=\"1\" cellborder=\"0\" cellspacing=\"0\"%s>\n", collection.Name, style)
+		fmt.Fprintf(out, "        <tr><td colspan=\"2\" bgcolor=\"lightgrey\"><b>%s</b></td></tr>\n", title)
+
+		for _, field := range builder.byId[collection.Id].Fields {
+			fieldType := field.Type
+
+			if field.IsMultiple() {
+				fieldType += "[]"
+			}
+
+			name := html.EscapeString(field.Name)
+
+			if field.Required {
+				name = "<b>" + name + "</b>"
+			}
+
+			fmt.Fprintf(out, "        <tr><td align=\"left\" port=%q>%s</td><td align=\"left\">%s</td></tr>\n", field.Name, name, html.EscapeString(fieldType))
+		}
+
+		out.WriteString("    </table>>];\n")
+	}
+
+	edges := diagramEdges(collections, selected)
+
+	if len(edges) > 0 {
+		out.WriteString("\n")
+	}
+
+	for _, edge := range edges {
+		//Same cardinalities as the Mermaid diagram, the crow sits on the collection holding the field
+		head := "teeodot"
+
+		switch {
+		case edge.field.IsMultiple():
+			head = "crowodot"
+		case edge.field.Required:
+			head = "teetee"
+		}
+
+		attributes := []string{fmt.Sprintf("label=%q", edge.field.Name), "arrowhead=" + head}
+
+		if edge.cascade {
+			attributes[0] = fmt.Sprintf("label=%q", edge.field.Name+" (cascade)")
+			attributes = append(attributes, "color=red", "fontcolor=red")
+		}
+
+		if edge.from.Type == "view" {
+			attributes = append(attributes, "style=dashed")
+		}
+
+		fmt.Fprintf(out, "    %q:%q -> %q:%q [%s];\n", edge.from.Name, edge.field.Name, edge.to.Name, "id", strings.Join(attributes, ", "))
+	}
+
+	out.WriteString("}\n")
+
+	return out.Bytes()
+}
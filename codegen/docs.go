@@ -0,0 +1,265 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// Writes Markdown docs with a section per collection listing its fields, constraints, indexes and API rules
+//
+// With EmbedDiagram the Mermaid ER diagram is added at the top so it renders wherever Markdown renders Mermaid.
+func Markdown(collections []services.PocketBaseCollectionResponse, options Options) ([]byte, error) {
+	gen := &generator{options: options}
+	selected := gen.selectCollections(collections)
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no-collections|nothing matches %v", options.Collections)
+	}
+
+	builder := newSchemaBuilder(collections)
+	out := &bytes.Buffer{}
+
+	title := options.Title
+
+	if title == "" {
+		title = "Collections"
+	}
+
+	fmt.Fprintf(out, "# %s\n\n", title)
+
+	if options.Source != "" {
+		fmt.Fprintf(out, "_Generated by pbdocs from %s, do not edit by hand._\n\n", options.Source)
+	} else {
+		out.WriteString("_Generated by pbdocs, do not edit by hand._\n\n")
+	}
+
+	if options.EmbedDiagram {
+		out.WriteString("```mermaid\n")
+		out.Write(Mermaid(collections, options))
+		out.WriteString("```\n\n")
+	}
+
+	for _, collection := range selected {
+		fmt.Fprintf(out, "- [%s](#%s) - %s\n", collection.Name, anchor(collection.Name), collection.Type)
+	}
+
+	for _, collection := range selected {
+		out.WriteString("\n")
+		builder.writeCollection(out, collection, selected)
+	}
+
+	return out.Bytes(), nil
+}
+
+func (builder *schemaBuilder) writeCollection(out *bytes.Buffer, collection services.PocketBaseCollectionResponse, selected []services.PocketBaseCollectionResponse) {
+	schema := builder.byId[collection.Id]
+
+	fmt.Fprintf(out, "## %s\n\n", collection.Name)
+	fmt.Fprintf(out, "%s collection `%s`", strings.ToUpper(collection.Type[:1])+collection.Type[1:], collection.Id)
+
+	if collection.System {
+		out.WriteString(", system")
+	}
+
+	out.WriteString("\n\n### Fields\n\n")
+	out.WriteString("| Name | Type | Required | Constraints |\n")
+	out.WriteString("| --- | --- | --- | --- |\n")
+
+	for _, field := range schema.Fields {
+		fieldType := field.Type
+
+		if field.IsMultiple() {
+			fieldType += " (multiple)"
+		}
+
+		required := ""
+
+		if field.Required {
+			required = "yes"
+		}
+
+		fmt.Fprintf(out, "| `%s` | %s | %s | %s |\n", field.Name, fieldType, required, cell(strings.Join(builder.constraints(field, selected), ", ")))
+	}
+
+	if len(collection.Indexes) > 0 {
+		out.WriteString("\n### Indexes\n\n")
+
+		for _, index := range collection.Indexes {
+			fmt.Fprintf(out, "- `%s`\n", strings.Join(strings.Fields(index), " "))
+		}
+	}
+
+	out.WriteString("\n### API rules\n\n")
+	out.WriteString("| Action | Rule |\n")
+	out.WriteString("| --- | --- |\n")
+
+	rules := []struct {
+		action string
+		rule   *string
+	}{
+		{"List", collection.ListRule},
+		{"View", collection.ViewRule},
+		{"Create", collection.CreateRule},
+		{"Update", collection.UpdateRule},
+		{"Delete", collection.DeleteRule},
+	}
+
+	for _, rule := range rules {
+		//Views can only be listed and viewed
+		if collection.Type == "view" && rule.action != "List" && rule.action != "View" {
+			continue
+		}
+
+		fmt.Fprintf(out, "| %s | %s |\n", rule.action, cell(ruleText(rule.rule)))
+	}
+
+	if collection.ViewQuery != "" {
+		fmt.Fprintf(out, "\n### View query\n\n```sql\n%s\n```\n", strings.TrimSpace(collection.ViewQuery))
+	}
+}
+
+// Readable constraints of a field, relations link to the section of the related collection
+func (builder *schemaBuilder) constraints(field services.CollectionField, selected []services.PocketBaseCollectionResponse) []string {
+	constraints := []string{}
+
+	add := func(text string, args ...any) {
+		constraints = append(constraints, fmt.Sprintf(text, args...))
+	}
+
+	number := func(option string, text string) {
+		if value, ok := field.OptionNumber(option); ok && (value != 0 || option == "min" && field.Type == "number") {
+			add(text, strconv.FormatFloat(value, 'f', -1, 64))
+		}
+	}
+
+	list := func(option string, text string) {
+		if values := field.OptionStrings(option); len(values) > 0 {
+			add(text, "`"+strings.Join(values, "`, `")+"`")
+		}
+	}
+
+	switch field.Type {
+	case "text", "password", "editor", "email", "url":
+		number("min", "min length %s")
+		number("max", "max length %s")
+
+		if pattern := field.OptionString("pattern"); pattern != "" {
+			add("pattern `%s`", pattern)
+		}
+
+		if pattern := field.OptionString("autogeneratePattern"); pattern != "" {
+			add("generated `%s`", pattern)
+		}
+
+		list("onlyDomains", "only %s")
+		list("exceptDomains", "except %s")
+		number("maxSize", "max size %s bytes")
+	case "number":
+		number("min", "min %s")
+		number("max", "max %s")
+
+		if field.OptionBool("onlyInt") {
+			add("integers only")
+		}
+	case "date":
+		if min := field.OptionString("min"); min != "" {
+			add("after %s", min)
+		}
+
+		if max := field.OptionString("max"); max != "" {
+			add("before %s", max)
+		}
+	case "autodate":
+		switch {
+		case field.OptionBool("onCreate") && field.OptionBool("onUpdate"):
+			add("set on create and update")
+		case field.OptionBool("onCreate"):
+			add("set on create")
+		case field.OptionBool("onUpdate"):
+			add("set on update")
+		}
+	case "select":
+		list("values", "one of %s")
+	case "relation":
+		target := field.CollectionId()
+
+		if related, ok := builder.byId[target]; ok {
+			target = related.Name
+		}
+
+		if isSelected(target, selected) {
+			add("→ [%s](#%s)", target, anchor(target))
+		} else {
+			add("→ %s", target)
+		}
+
+		number("minSelect", "min %s")
+
+		if field.OptionBool("cascadeDelete") {
+			add("cascade delete")
+		}
+	case "file":
+		number("maxSize", "max size %s bytes")
+		list("mimeTypes", "types %s")
+		list("thumbs", "thumbs %s")
+
+		if field.OptionBool("protected") {
+			add("protected")
+		}
+	case "json":
+		number("maxSize", "max size %s bytes")
+	}
+
+	if field.IsMultiple() {
+		add("max %d values", field.MaxSelect())
+	}
+
+	if field.OptionBool("primaryKey") {
+		add("primary key")
+	}
+
+	if field.Hidden {
+		add("hidden")
+	}
+
+	if field.System {
+		add("system")
+	}
+
+	return constraints
+}
+
+func isSelected(name string, selected []services.PocketBaseCollectionResponse) bool {
+	for _, collection := range selected {
+		if collection.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ruleText(rule *string) string {
+	switch {
+	case rule == nil:
+		return "Superusers only"
+	case strings.TrimSpace(*rule) == "":
+		return "Public"
+	}
+
+	return "`" + strings.Join(strings.Fields(*rule), " ") + "`"
+}
+
+// Keeps table cells on one line and escapes the column separator
+func cell(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", `\|`)
+}
+
+// Heading anchor like the one GitHub generates, collection names are already lowercase safe apart from case
+func anchor(name string) string {
+	return strings.ToLower(name)
+}
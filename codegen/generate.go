@@ -0,0 +1,496 @@
+// Package codegen generates Go types for PocketBase collections, see cmd/pbgen for the go generate command
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// Options for Generate, JSONSchemas and OpenAPI
+//
+// Collections limits the output to the named collections, system collections are only included when named
+// there or with System set. TypeNames overrides the type name of a collection, by default it is the
+// singular of its name like User for users. Relations to collections that are not generated stay plain strings.
+// Title, Version and ServerURL only fill the info and servers of the OpenAPI spec.
+type Options struct {
+	Package      string
+	Source       string
+	Collections  []string
+	System       bool
+	Expand       bool
+	Repositories bool
+	TypeNames    map[string]string
+	Title        string
+	Version      string
+	ServerURL    string
+	EmbedDiagram bool
+}
+
+type model struct {
+	collection services.PocketBaseCollectionResponse
+	schema     *services.CollectionSchema
+	typeName   string
+	idType     string
+	fields     []modelField
+	enums      []enum
+	relations  []modelField
+}
+
+type modelField struct {
+	goName    string
+	goType    string
+	field     services.CollectionField
+	omitEmpty bool
+	target    *model
+}
+
+type enum struct {
+	typeName string
+	names    []string
+	values   []string
+}
+
+type generator struct {
+	options Options
+	models  []*model
+	byId    map[string]*model
+	names   map[string]string
+	imports map[string]bool
+	output  *bytes.Buffer
+}
+
+// Renders gofmt'ed Go source with a struct, id type, select enums and field names for every collection,
+// plus expand structs and repositories when enabled
+func Generate(collections []services.PocketBaseCollectionResponse, options Options) ([]byte, error) {
+	if options.Package == "" {
+		options.Package = "models"
+	}
+
+	gen := &generator{
+		options: options,
+		byId:    map[string]*model{},
+		names:   map[string]string{},
+		imports: map[string]bool{},
+		output:  &bytes.Buffer{},
+	}
+
+	selected := gen.selectCollections(collections)
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no-collections|nothing matches %v", options.Collections)
+	}
+
+	for _, collection := range selected {
+		if err := gen.addModel(collection); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range gen.models {
+		if err := gen.addFields(m); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range gen.models {
+		gen.render(m)
+	}
+
+	source := &bytes.Buffer{}
+
+	if options.Source != "" {
+		fmt.Fprintf(source, "// Code generated by pbgen from %s. DO NOT EDIT.\n\n", options.Source)
+	} else {
+		source.WriteString("// Code generated by pbgen. DO NOT EDIT.\n\n")
+	}
+
+	fmt.Fprintf(source, "package %s\n\n", options.Package)
+
+	imports := []string{}
+
+	for path := range gen.imports {
+		imports = append(imports, path)
+	}
+
+	sort.Strings(imports)
+
+	if len(imports) > 0 {
+		source.WriteString("import (\n")
+
+		for i, path := range imports {
+			//Standard library first, separated from the module imports
+			if i > 0 && !strings.Contains(imports[i-1], ".") && strings.Contains(path, ".") {
+				source.WriteString("\n")
+			}
+
+			fmt.Fprintf(source, "\t%q\n", path)
+		}
+
+		source.WriteString(")\n\n")
+	}
+
+	source.Write(gen.output.Bytes())
+
+	formatted, err := format.Source(source.Bytes())
+
+	if err != nil {
+		return source.Bytes(), fmt.Errorf("invalid-output|%w", err)
+	}
+
+	return formatted, nil
+}
+
+// Collections in name order, either the named ones or every non system one
+func (gen *generator) selectCollections(collections []services.PocketBaseCollectionResponse) []services.PocketBaseCollectionResponse {
+	selected := []services.PocketBaseCollectionResponse{}
+
+	for _, collection := range collections {
+		named := slices.Contains(gen.options.Collections, collection.Name) || slices.Contains(gen.options.Collections, collection.Id)
+
+		switch {
+		case len(gen.options.Collections) > 0 && !named:
+			continue
+		case collection.System && !gen.options.System && !named:
+			continue
+		}
+
+		selected = append(selected, collection)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Name < selected[j].Name
+	})
+
+	return selected
+}
+
+// Reserves a top level identifier, two collections or a collection and an enum can end up with the same name
+func (gen *generator) reserve(name string, owner string) error {
+	if name == "" {
+		return fmt.Errorf("invalid-name|%s has no usable Go name, set one in TypeNames", owner)
+	}
+
+	if existing, ok := gen.names[name]; ok {
+		return fmt.Errorf("duplicate-name|%s is generated for both %s and %s, set one in TypeNames", name, existing, owner)
+	}
+
+	gen.names[name] = owner
+
+	return nil
+}
+
+func (gen *generator) addModel(collection services.PocketBaseCollectionResponse) error {
+	typeName, ok := gen.options.TypeNames[collection.Name]
+
+	if !ok {
+		typeName = pascal(singular(collection.Name))
+	}
+
+	m := &model{
+		collection: collection,
+		schema:     services.NewCollectionSchema(collection),
+		typeName:   typeName,
+		idType:     typeName + "Id",
+	}
+
+	names := []string{m.typeName, m.idType, m.typeName + "Collection"}
+
+	if gen.options.Expand {
+		names = append(names, m.typeName+"Expand")
+	}
+
+	if gen.options.Repositories {
+		names = append(names, m.typeName+"Repository", "New"+m.typeName+"Repository")
+	}
+
+	for _, name := range names {
+		if err := gen.reserve(name, collection.Name); err != nil {
+			return err
+		}
+	}
+
+	gen.models = append(gen.models, m)
+	gen.byId[collection.Id] = m
+	gen.byId[collection.Name] = m
+
+	return nil
+}
+
+func (gen *generator) addFields(m *model) error {
+	used := map[string]bool{"Expand": gen.options.Expand, "PasswordConfirm": m.collection.Type == "auth"}
+
+	for _, field := range m.schema.Fields {
+		goName := pascal(field.Name)
+
+		if goName == "" {
+			goName = "Field"
+		}
+
+		for i := 2; used[goName]; i++ {
+			goName = pascal(field.Name) + strconv.Itoa(i)
+		}
+
+		used[goName] = true
+
+		if err := gen.reserve(m.typeName+"Field"+goName, m.collection.Name+"."+field.Name); err != nil {
+			return err
+		}
+
+		f := modelField{
+			goName:    goName,
+			field:     field,
+			omitEmpty: field.Hidden || field.Type == "password",
+		}
+
+		goType, err := gen.goType(m, &f)
+
+		if err != nil {
+			return err
+		}
+
+		if field.IsMultiple() {
+			goType = "[]" + goType
+		}
+
+		f.goType = goType
+		m.fields = append(m.fields, f)
+
+		if f.target != nil {
+			m.relations = append(m.relations, f)
+		}
+	}
+
+	return nil
+}
+
+// Go type of a single value of the field
+func (gen *generator) goType(m *model, f *modelField) (string, error) {
+	field := f.field
+
+	switch field.Type {
+	case "text", "email", "url", "editor", "password":
+		if field.Name == "id" {
+			return m.idType, nil
+		}
+
+		return "string", nil
+	case "number":
+		if field.OptionBool("onlyInt") {
+			return "int", nil
+		}
+
+		return "float64", nil
+	case "bool":
+		return "bool", nil
+	case "date", "autodate":
+		gen.imports["github.com/JGugino/pb-go/services"] = true
+		return "services.DateTime", nil
+	case "geoPoint":
+		gen.imports["github.com/JGugino/pb-go/services"] = true
+		return "services.GeoPoint", nil
+	case "json":
+		gen.imports["encoding/json"] = true
+		return "json.RawMessage", nil
+	case "file":
+		return "string", nil
+	case "relation":
+		target, ok := gen.byId[field.CollectionId()]
+
+		if !ok {
+			return "string", nil
+		}
+
+		f.target = target
+		return target.idType, nil
+	case "select":
+		return gen.addEnum(m, f)
+	}
+
+	//Field types added by newer PocketBase versions or plugins
+	return "any", nil
+}
+
+func (gen *generator) addEnum(m *model, f *modelField) (string, error) {
+	e := enum{typeName: m.typeName + f.goName}
+
+	if err := gen.reserve(e.typeName, m.collection.Name+"."+f.field.Name); err != nil {
+		return "", err
+	}
+
+	if err := gen.reserve(e.typeName+"Values", m.collection.Name+"."+f.field.Name); err != nil {
+		return "", err
+	}
+
+	used := map[string]bool{}
+
+	for i, value := range f.field.OptionStrings("values") {
+		name := pascal(value)
+
+		if name == "" {
+			name = "Value" + strconv.Itoa(i+1)
+		}
+
+		for n := 2; used[name]; n++ {
+			name = pascal(value) + strconv.Itoa(n)
+		}
+
+		used[name] = true
+
+		if err := gen.reserve(e.typeName+name, m.collection.Name+"."+f.field.Name+"="+value); err != nil {
+			return "", err
+		}
+
+		e.names = append(e.names, e.typeName+name)
+		e.values = append(e.values, value)
+	}
+
+	m.enums = append(m.enums, e)
+
+	return e.typeName, nil
+}
+
+func (gen *generator) printf(text string, args ...any) {
+	fmt.Fprintf(gen.output, text, args...)
+}
+
+func (gen *generator) render(m *model) {
+	name := m.typeName
+	collection := m.collection
+
+	gen.printf("// ### %s ###\n\n", strings.ToUpper(collection.Name))
+	gen.printf("// Name of the %s collection\n", collection.Name)
+	gen.printf("const %sCollection = %q\n\n", name, collection.Name)
+
+	gen.printf("// Field names of %s for filters, sorting and updates\n", collection.Name)
+	gen.printf("const (\n")
+
+	for _, f := range m.fields {
+		gen.printf("%sField%s = %q\n", name, f.goName, f.field.Name)
+	}
+
+	gen.printf(")\n\n")
+
+	gen.printf("// Id of a %s record\n", collection.Name)
+	gen.printf("type %s string\n\n", m.idType)
+
+	for _, e := range m.enums {
+		gen.printf("type %s string\n\n", e.typeName)
+		gen.printf("const (\n")
+
+		for i, constName := range e.names {
+			gen.printf("%s %s = %q\n", constName, e.typeName, e.values[i])
+		}
+
+		gen.printf(")\n\n")
+		gen.printf("// Every allowed value of %s\n", e.typeName)
+		gen.printf("var %sValues = []%s{%s}\n\n", e.typeName, e.typeName, strings.Join(e.names, ", "))
+	}
+
+	gen.printf("// Record of the %s %s collection\n", collection.Name, collection.Type)
+	gen.printf("type %s struct {\n", name)
+
+	for _, f := range m.fields {
+		tag := f.field.Name
+
+		if f.omitEmpty {
+			tag += ",omitempty"
+		}
+
+		gen.printf("%s %s `json:%q`\n", f.goName, f.goType, tag)
+	}
+
+	if collection.Type == "auth" {
+		gen.printf("\n//Only sent when creating or changing the password\n")
+		gen.printf("PasswordConfirm string `json:\"passwordConfirm,omitempty\"`\n")
+	}
+
+	hasExpand := gen.options.Expand && len(m.relations) > 0
+
+	if hasExpand {
+		gen.printf("\nExpand *%sExpand `json:\"expand,omitempty\"`\n", name)
+	}
+
+	gen.printf("}\n\n")
+
+	if hasExpand {
+		gen.printf("// Expanded relations of %s, only the relations named in the expand option are set\n", name)
+		gen.printf("type %sExpand struct {\n", name)
+
+		for _, f := range m.relations {
+			goType := "*" + f.target.typeName
+
+			if f.field.IsMultiple() {
+				goType = "[]" + f.target.typeName
+			}
+
+			gen.printf("%s %s `json:\"%s,omitempty\"`\n", f.goName, goType, f.field.Name)
+		}
+
+		gen.printf("}\n\n")
+	}
+
+	if gen.options.Repositories {
+		gen.renderRepository(m)
+	}
+}
+
+func (gen *generator) renderRepository(m *model) {
+	gen.imports["github.com/JGugino/pb-go/services"] = true
+
+	name := m.typeName
+	repo := name + "Repository"
+
+	gen.printf("// Typed access to the %s collection\n", m.collection.Name)
+	gen.printf("type %s struct {\nRecords services.RecordService\nToken string\n}\n\n", repo)
+
+	gen.printf("func New%s(records services.RecordService, token string) *%s {\n", repo, repo)
+	gen.printf("return &%s{Records: records, Token: token}\n}\n\n", repo)
+
+	gen.printf("// Starts a query returning %s records\n", name)
+	gen.printf("func (repo *%s) Query() *services.RecordQuery[%s] {\n", repo, name)
+	gen.printf("return services.NewRecordQuery[%s](repo.Records, %sCollection).Token(repo.Token)\n}\n\n", name, name)
+
+	gen.printf("func (repo *%s) View(id %s) (%s, error) {\n", repo, m.idType, name)
+	gen.printf("record, err := repo.Records.ViewRecord(%sCollection, string(id), repo.Token)\n\n", name)
+	gen.printf("if err != nil {\nreturn %s{}, err\n}\n\n", name)
+	gen.printf("return services.DecodeRecord[%s](record)\n}\n\n", name)
+
+	//View collections are read only
+	if m.collection.Type == "view" {
+		return
+	}
+
+	gen.printf("// Creates the record from every field, an empty Id and the autodate fields are left to PocketBase\n")
+	gen.printf("func (repo *%s) Create(record %s) (%s, error) {\n", repo, name, name)
+	gen.printf("data, err := services.EncodeRecord(record)\n\n")
+	gen.printf("if err != nil {\nreturn %s{}, err\n}\n\n", name)
+	gen.printf("delete(data, \"expand\")\n")
+
+	for _, f := range m.fields {
+		if f.field.Type == "autodate" {
+			gen.printf("delete(data, %q)\n", f.field.Name)
+		}
+	}
+
+	gen.printf("\nif data[\"id\"] == \"\" {\ndelete(data, \"id\")\n}\n\n")
+	gen.printf("created, err := repo.Records.CreateNewRecord(%sCollection, repo.Token, data)\n\n", name)
+	gen.printf("if err != nil {\nreturn %s{}, err\n}\n\n", name)
+	gen.printf("return services.DecodeRecord[%s](created)\n}\n\n", name)
+
+	gen.printf("// Changes only the given fields, use the %sField constants as keys\n", name)
+	gen.printf("func (repo *%s) Update(id %s, data map[string]any) (%s, error) {\n", repo, m.idType, name)
+	gen.printf("updated, err := repo.Records.UpdateRecord(%sCollection, string(id), repo.Token, data)\n\n", name)
+	gen.printf("if err != nil {\nreturn %s{}, err\n}\n\n", name)
+	gen.printf("return services.DecodeRecord[%s](updated)\n}\n\n", name)
+
+	gen.printf("func (repo *%s) Delete(id %s) error {\n", repo, m.idType)
+	gen.printf("_, err := repo.Records.DeleteRecord(%sCollection, string(id), repo.Token)\n", name)
+	gen.printf("return err\n}\n\n")
+}
@@ -0,0 +1,403 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+const JSON_SCHEMA_DRAFT = "https://json-schema.org/draft/2020-12/schema"
+
+// Datetime values as PocketBase writes them, date fields are "" when empty
+const dateTimePattern = `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?Z$`
+
+// Field constraints without a JSON Schema keyword are kept under x- keywords named after the field option
+type schemaBuilder struct {
+	byId map[string]*services.CollectionSchema
+}
+
+func newSchemaBuilder(collections []services.PocketBaseCollectionResponse) *schemaBuilder {
+	builder := &schemaBuilder{byId: map[string]*services.CollectionSchema{}}
+
+	for _, collection := range collections {
+		schema := services.NewCollectionSchema(collection)
+		builder.byId[collection.Id] = schema
+		builder.byId[collection.Name] = schema
+	}
+
+	return builder
+}
+
+// Builds JSON Schema documents for the records of every selected collection, keyed by collection name
+//
+// The document under the collection name describes a record as the API returns it, without write only
+// fields and requiring every field that is not hidden. The one under name.create describes the body of a
+// create, without read only fields and requiring the fields that must be set. Relations are checked
+// against the id pattern of the related collection.
+func JSONSchemas(collections []services.PocketBaseCollectionResponse, options Options) (map[string]map[string]any, error) {
+	gen := &generator{options: options}
+	selected := gen.selectCollections(collections)
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no-collections|nothing matches %v", options.Collections)
+	}
+
+	builder := newSchemaBuilder(collections)
+	documents := map[string]map[string]any{}
+
+	for _, collection := range selected {
+		document := builder.recordSchema(collection)
+		document["$schema"] = JSON_SCHEMA_DRAFT
+		document["$id"] = collection.Name + ".schema.json"
+
+		documents[collection.Name] = document
+
+		if collection.Type == "view" {
+			continue
+		}
+
+		create := builder.createSchema(collection)
+		create["$schema"] = JSON_SCHEMA_DRAFT
+		create["$id"] = collection.Name + ".create.schema.json"
+
+		documents[collection.Name+".create"] = create
+	}
+
+	return documents, nil
+}
+
+// Schema of a record as the API returns it, responses with the fields query parameter can leave out required fields
+// and auth records leave out email when emailVisibility is off
+func (builder *schemaBuilder) recordSchema(collection services.PocketBaseCollectionResponse) map[string]any {
+	properties := builder.properties(collection)
+	required := []string{"collectionId", "collectionName"}
+
+	delete(properties, "passwordConfirm")
+
+	for _, field := range builder.byId[collection.Id].Fields {
+		switch {
+		case field.Type == "password":
+			delete(properties, field.Name)
+		case collection.Type == "auth" && field.Name == "email":
+			//Left out of responses to other users unless emailVisibility is set
+		case !field.Hidden:
+			required = append(required, field.Name)
+		}
+	}
+
+	if !slices.Contains(required, "id") {
+		required = append(required, "id")
+	}
+
+	return map[string]any{
+		"title":                collection.Name,
+		"description":          fmt.Sprintf("Record of the %s %s collection", collection.Name, collection.Type),
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// Schema of the body of a create, required lists the fields that must be set
+func (builder *schemaBuilder) createSchema(collection services.PocketBaseCollectionResponse) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for name, property := range builder.properties(collection) {
+		if readOnly, _ := property.(map[string]any)["readOnly"].(bool); !readOnly {
+			properties[name] = property
+		}
+	}
+
+	for _, field := range builder.byId[collection.Id].Fields {
+		//Ids and autogenerated text are filled in by PocketBase when left out
+		generated := field.Name == "id" || field.OptionString("autogeneratePattern") != ""
+
+		if field.Required && field.Type != "autodate" && !generated {
+			required = append(required, field.Name)
+		}
+	}
+
+	if collection.Type == "auth" {
+		required = append(required, "passwordConfirm")
+	}
+
+	document := map[string]any{
+		"title":                collection.Name + " create",
+		"description":          fmt.Sprintf("Fields of a new record of the %s %s collection", collection.Name, collection.Type),
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if len(required) > 0 {
+		document["required"] = required
+	}
+
+	return document
+}
+
+// Properties of every field annotated with readOnly and writeOnly, shared by the record and create schemas
+func (builder *schemaBuilder) properties(collection services.PocketBaseCollectionResponse) map[string]any {
+	properties := map[string]any{
+		"collectionId":   map[string]any{"type": "string", "const": collection.Id, "readOnly": true},
+		"collectionName": map[string]any{"type": "string", "const": collection.Name, "readOnly": true},
+		"expand":         map[string]any{"type": "object", "readOnly": true, "description": "Expanded relations, only present with the expand query parameter"},
+
+		//Collections exported before id became a field do not list it
+		"id": map[string]any{"type": "string", "readOnly": true},
+	}
+
+	for _, field := range builder.byId[collection.Id].Fields {
+		properties[field.Name] = builder.fieldSchema(field)
+	}
+
+	if collection.Type == "auth" {
+		properties["passwordConfirm"] = map[string]any{"type": "string", "writeOnly": true, "description": "Must match password"}
+	}
+
+	return properties
+}
+
+// Schema of a single field value with its constraints
+func (builder *schemaBuilder) fieldSchema(field services.CollectionField) map[string]any {
+	property := map[string]any{}
+
+	if field.Hidden {
+		property["description"] = "Hidden, only returned to superusers"
+	}
+
+	switch field.Type {
+	case "text", "password", "editor", "email", "url":
+		property["type"] = "string"
+		setLength(property, field, "min", "minLength")
+		setLength(property, field, "max", "maxLength")
+
+		if pattern := field.OptionString("pattern"); pattern != "" {
+			property["pattern"] = pattern
+		}
+
+		if field.Required {
+			property["minLength"] = max(lengthOf(property["minLength"]), 1)
+		}
+
+		switch field.Type {
+		case "password":
+			property["writeOnly"] = true
+		case "editor":
+			property["contentMediaType"] = "text/html"
+			setOption(property, field, "maxSize")
+		case "email", "url":
+			property["format"] = map[string]string{"email": "email", "url": "uri"}[field.Type]
+			setOption(property, field, "onlyDomains")
+			setOption(property, field, "exceptDomains")
+		}
+
+		_, constrained := property["pattern"]
+		_, formatted := property["format"]
+
+		//Optional fields also accept "" even when it does not match the constraints
+		if !field.Required && (constrained || formatted || property["minLength"] != nil) {
+			return map[string]any{"anyOf": []any{property, map[string]any{"const": ""}}}
+		}
+	case "number":
+		property["type"] = "number"
+
+		if field.OptionBool("onlyInt") {
+			property["type"] = "integer"
+		}
+
+		if min, ok := field.OptionNumber("min"); ok {
+			property["minimum"] = min
+		}
+
+		if max, ok := field.OptionNumber("max"); ok {
+			property["maximum"] = max
+		}
+
+		if field.Required {
+			property["not"] = map[string]any{"const": 0}
+		}
+	case "bool":
+		property["type"] = "boolean"
+
+		if field.Required {
+			property["const"] = true
+		}
+	case "date", "autodate":
+		property["type"] = "string"
+		property["pattern"] = dateTimePattern
+
+		if !field.Required && field.Type == "date" {
+			property["pattern"] = `^$|` + dateTimePattern
+		}
+
+		if field.Type == "autodate" {
+			property["readOnly"] = true
+		}
+
+		setOption(property, field, "min")
+		setOption(property, field, "max")
+	case "select":
+		values := []any{}
+
+		for _, value := range field.OptionStrings("values") {
+			values = append(values, value)
+		}
+
+		item := map[string]any{"type": "string", "enum": values}
+
+		if field.IsMultiple() {
+			return builder.list(field, item)
+		}
+
+		if !field.Required {
+			item["enum"] = append(values, "")
+		}
+
+		property = item
+	case "relation":
+		item := builder.relationId(field)
+
+		if field.IsMultiple() {
+			return builder.list(field, item)
+		}
+
+		property = item
+
+		if !field.Required {
+			property = map[string]any{"anyOf": []any{item, map[string]any{"const": ""}}}
+		}
+	case "file":
+		item := map[string]any{"type": "string", "description": "Stored filename"}
+		setOption(item, field, "maxSize")
+		setOption(item, field, "mimeTypes")
+		setOption(item, field, "protected")
+
+		if field.IsMultiple() {
+			return builder.list(field, item)
+		}
+
+		property = item
+	case "json":
+		setOption(property, field, "maxSize")
+	case "geoPoint":
+		property["type"] = "object"
+		property["properties"] = map[string]any{
+			"lon": map[string]any{"type": "number", "minimum": -180, "maximum": 180},
+			"lat": map[string]any{"type": "number", "minimum": -90, "maximum": 90},
+		}
+		property["required"] = []string{"lon", "lat"}
+	}
+
+	return property
+}
+
+// Array schema for multiple select, relation and file fields
+func (builder *schemaBuilder) list(field services.CollectionField, item map[string]any) map[string]any {
+	property := map[string]any{
+		"type":        "array",
+		"items":       item,
+		"maxItems":    field.MaxSelect(),
+		"uniqueItems": true,
+	}
+
+	if min, ok := field.OptionNumber("minSelect"); ok && min > 0 {
+		property["minItems"] = int(min)
+	}
+
+	if field.Required {
+		property["minItems"] = max(lengthOf(property["minItems"]), 1)
+	}
+
+	return property
+}
+
+// Shape of the ids of the related collection, taken from its id field when the collection is known
+func (builder *schemaBuilder) relationId(field services.CollectionField) map[string]any {
+	property := map[string]any{"type": "string"}
+	target, ok := builder.byId[field.CollectionId()]
+
+	if !ok {
+		return property
+	}
+
+	property["description"] = "Id of a " + target.Name + " record"
+	property["x-collection"] = target.Name
+
+	if id, ok := target.Field("id"); ok {
+		setLength(property, id, "min", "minLength")
+		setLength(property, id, "max", "maxLength")
+
+		if pattern := id.OptionString("pattern"); pattern != "" {
+			property["pattern"] = pattern
+		}
+	}
+
+	return property
+}
+
+// Copies a length option, PocketBase uses 0 for no limit
+func setLength(property map[string]any, field services.CollectionField, option string, keyword string) {
+	if value, ok := field.OptionNumber(option); ok && value > 0 {
+		property[keyword] = int(value)
+	}
+}
+
+// Copies an option without a JSON Schema keyword as x-option, empty values are left out
+func setOption(property map[string]any, field services.CollectionField, option string) {
+	value := field.Options[option]
+
+	switch v := value.(type) {
+	case nil:
+		return
+	case string:
+		if v == "" {
+			return
+		}
+	case bool:
+		if !v {
+			return
+		}
+	case []any:
+		if len(v) == 0 {
+			return
+		}
+	default:
+		number, ok := field.OptionNumber(option)
+
+		if !ok || number == 0 {
+			return
+		}
+
+		value = number
+	}
+
+	property["x-"+option] = value
+}
+
+func lengthOf(value any) int {
+	length, _ := value.(int)
+	return length
+}
+
+var ruleAuthPattern = regexp.MustCompile(`@request\.auth\.[A-Za-z_]+\s*!=\s*(""|'')`)
+
+// Describes who can use an action with the rule and whether it needs an auth token, nil rules are locked to superusers
+func ruleAccess(rule *string) (string, bool) {
+	switch {
+	case rule == nil:
+		return "Superusers only", true
+	case strings.TrimSpace(*rule) == "":
+		return "Public", false
+	//A rule with || can still let guests through another branch
+	case ruleAuthPattern.MatchString(*rule) && !strings.Contains(*rule, "||"):
+		return "Requires an authenticated record matching: " + *rule, true
+	}
+
+	return "Guests and authenticated records matching: " + *rule, false
+}
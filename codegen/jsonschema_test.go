@@ -0,0 +1,148 @@
+package codegen_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/JGugino/pb-go/codegen"
+	"github.com/JGugino/pb-go/services"
+)
+
+func usersCollection() services.PocketBaseCollectionResponse {
+	return services.PocketBaseCollectionResponse{
+		Id:   "pbc_users",
+		Name: "users",
+		Type: "auth",
+		Fields: []map[string]any{
+			{"name": "id", "type": "text", "required": true, "primaryKey": true, "autogeneratePattern": "[a-z0-9]{15}"},
+			{"name": "password", "type": "password", "required": true, "hidden": true},
+			{"name": "tokenKey", "type": "text", "required": true, "hidden": true, "autogeneratePattern": "[a-zA-Z0-9]{50}"},
+			{"name": "email", "type": "email", "required": true},
+			{"name": "name", "type": "text"},
+			{"name": "created", "type": "autodate", "onCreate": true},
+		},
+	}
+}
+
+func requiredOf(t *testing.T, schema any) []string {
+	t.Helper()
+
+	required, _ := schema.(map[string]any)["required"].([]string)
+
+	return required
+}
+
+func propertiesOf(schema any) map[string]any {
+	return schema.(map[string]any)["properties"].(map[string]any)
+}
+
+func TestRecordSchemaDescribesResponses(t *testing.T) {
+	documents, err := codegen.JSONSchemas([]services.PocketBaseCollectionResponse{usersCollection()}, codegen.Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := documents["users"]
+	required := requiredOf(t, record)
+
+	for _, name := range []string{"id", "collectionId", "collectionName", "name", "created"} {
+		if !slices.Contains(required, name) {
+			t.Errorf("response required %v misses %s", required, name)
+		}
+	}
+
+	//Email is only returned to other users with emailVisibility
+	for _, name := range []string{"password", "tokenKey", "passwordConfirm", "email"} {
+		if slices.Contains(required, name) {
+			t.Errorf("response required %v holds %s", required, name)
+		}
+	}
+
+	properties := propertiesOf(record)
+
+	if _, ok := properties["password"]; ok {
+		t.Error("response schema describes the write only password")
+	}
+
+	if _, ok := properties["tokenKey"]; !ok {
+		t.Error("hidden fields returned to superusers must stay allowed")
+	}
+
+	if _, ok := properties["email"]; !ok {
+		t.Error("response schema does not describe email")
+	}
+}
+
+func TestRecordSchemaRequiresEmailOfBaseCollections(t *testing.T) {
+	contacts := services.PocketBaseCollectionResponse{
+		Id:   "pbc_contacts",
+		Name: "contacts",
+		Type: "base",
+		Fields: []map[string]any{
+			{"name": "id", "type": "text", "required": true, "primaryKey": true},
+			{"name": "email", "type": "email", "required": true},
+		},
+	}
+
+	documents, err := codegen.JSONSchemas([]services.PocketBaseCollectionResponse{contacts}, codegen.Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if required := requiredOf(t, documents["contacts"]); !slices.Contains(required, "email") {
+		t.Fatalf("response required %v misses email", required)
+	}
+}
+
+func TestCreateSchemaDescribesBodies(t *testing.T) {
+	documents, err := codegen.JSONSchemas([]services.PocketBaseCollectionResponse{usersCollection()}, codegen.Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create, ok := documents["users.create"]
+
+	if !ok || create["$id"] != "users.create.schema.json" {
+		t.Fatalf("missing create schema in %v", create)
+	}
+
+	required := requiredOf(t, create)
+	expected := []string{"password", "email", "passwordConfirm"}
+
+	if !slices.Equal(required, expected) {
+		t.Fatalf("create required %v, expected %v", required, expected)
+	}
+
+	properties := propertiesOf(create)
+
+	for _, name := range []string{"created", "collectionId", "expand"} {
+		if _, ok := properties[name]; ok {
+			t.Errorf("create schema accepts read only %s", name)
+		}
+	}
+}
+
+func TestOpenAPIUsesTheResponseSchemaForRecords(t *testing.T) {
+	spec, err := codegen.OpenAPI([]services.PocketBaseCollectionResponse{usersCollection()}, codegen.Options{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+
+	if required := requiredOf(t, schemas["User"]); slices.Contains(required, "password") || !slices.Contains(required, "id") {
+		t.Fatalf("User required %v", required)
+	}
+
+	if required := requiredOf(t, schemas["UserCreate"]); !slices.Contains(required, "password") {
+		t.Fatalf("UserCreate required %v", required)
+	}
+
+	if _, ok := propertiesOf(schemas["UserUpdate"])["password"]; !ok {
+		t.Fatal("updates can not change the password")
+	}
+}
@@ -0,0 +1,82 @@
+package codegen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+const loadPerPage = 200
+
+// Lists every collection of the instance, requires a superuser token
+func LoadCollections(collections services.CollectionService, token string) ([]services.PocketBaseCollectionResponse, error) {
+	loaded := []services.PocketBaseCollectionResponse{}
+
+	for page := 1; ; page++ {
+		res, err := collections.ListCollections(token, services.PocketBaseListOptions{Page: page, PerPage: loadPerPage, Sort: "name"})
+
+		if err != nil {
+			return nil, err
+		}
+
+		loaded = append(loaded, res.Items...)
+
+		if len(res.Items) < loadPerPage {
+			return loaded, nil
+		}
+	}
+}
+
+// Reads the JSON array written by the dashboard export of collections
+func ReadCollections(reader io.Reader) ([]services.PocketBaseCollectionResponse, error) {
+	collections := []services.PocketBaseCollectionResponse{}
+
+	if err := json.NewDecoder(reader).Decode(&collections); err != nil {
+		return nil, fmt.Errorf("invalid-schema|%w", err)
+	}
+
+	return collections, nil
+}
+
+// Reads the collections from a collections export when schemaPath is set and from the instance otherwise,
+// also returns a description of the source for generated headers
+func LoadSource(url string, schemaPath string, authCollection string, identity string, password string) ([]services.PocketBaseCollectionResponse, string, error) {
+	if schemaPath != "" {
+		file, err := os.Open(schemaPath)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		defer file.Close()
+
+		collections, err := ReadCollections(file)
+
+		return collections, schemaPath, err
+	}
+
+	if url == "" {
+		return nil, "", errors.New("missing-source|set -url, PB_URL or -schema")
+	}
+
+	pb, err := services.New(url)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, err = pb.Auth.AuthWithPasswordForCollection(authCollection, "", "", identity, password)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	collections, err := LoadCollections(pb.Collection, pb.Auth.Token())
+
+	//The url is left out as it can point at a private instance
+	return collections, "a live instance", err
+}
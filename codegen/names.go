@@ -0,0 +1,45 @@
+package codegen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Turns a collection, field or select value into an exported identifier, created_at and createdAt give CreatedAt
+func pascal(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	builder := strings.Builder{}
+
+	for _, part := range parts {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		builder.WriteString(string(runes))
+	}
+
+	identifier := builder.String()
+
+	if identifier != "" && unicode.IsDigit([]rune(identifier)[0]) {
+		identifier = "N" + identifier
+	}
+
+	return identifier
+}
+
+// Naive English singular of the last word, irregular names are set with Options.TypeNames
+func singular(name string) string {
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "shes"), strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "zes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(lower, "s") && !strings.HasSuffix(lower, "ss") && !strings.HasSuffix(lower, "us") && !strings.HasSuffix(lower, "is"):
+		return name[:len(name)-1]
+	}
+
+	return name
+}
@@ -0,0 +1,392 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+const OPENAPI_VERSION = "3.1.0"
+
+// Builds an OpenAPI 3.1 spec with the list, view, create, update and delete endpoints of every selected collection
+//
+// Record schemas are the JSON Schemas of JSONSchemas and describe the responses, create and update bodies
+// leave out the read only fields and updates also describe the field+ and field- modifiers. Collections with file fields accept multipart bodies.
+// The security of every operation is derived from its rule: locked rules need a superuser token, rules that
+// require @request.auth need a record token, public rules and other rules accept guests.
+func OpenAPI(collections []services.PocketBaseCollectionResponse, options Options) (map[string]any, error) {
+	gen := &generator{options: options, names: map[string]string{}}
+	selected := gen.selectCollections(collections)
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no-collections|nothing matches %v", options.Collections)
+	}
+
+	builder := newSchemaBuilder(collections)
+
+	schemas := map[string]any{
+		"Error": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"status":  map[string]any{"type": "integer"},
+				"message": map[string]any{"type": "string"},
+				"data":    map[string]any{"type": "object", "description": "Field errors keyed by field name, each with a code and message"},
+			},
+		},
+	}
+
+	paths := map[string]any{}
+	tags := []any{}
+
+	for _, collection := range selected {
+		name, ok := options.TypeNames[collection.Name]
+
+		if !ok {
+			name = pascal(singular(collection.Name))
+		}
+
+		for _, suffix := range []string{"", "List", "Create", "Update", "CreateForm", "UpdateForm"} {
+			if err := gen.reserve(name+suffix, collection.Name); err != nil {
+				return nil, err
+			}
+		}
+
+		op := &operationBuilder{collection: collection, name: name, cascades: builder.cascades(collection, collections)}
+
+		schemas[name] = builder.recordSchema(collection)
+		schemas[name+"List"] = listSchema(name)
+
+		records := map[string]any{"get": op.list()}
+		record := map[string]any{"get": op.view()}
+
+		if collection.Type != "view" {
+			schemas[name+"Create"] = builder.inputSchema(collection, true, false)
+			schemas[name+"Update"] = builder.inputSchema(collection, false, false)
+
+			op.multipart = builder.hasFiles(collection)
+
+			if op.multipart {
+				schemas[name+"CreateForm"] = builder.inputSchema(collection, true, true)
+				schemas[name+"UpdateForm"] = builder.inputSchema(collection, false, true)
+			}
+
+			records["post"] = op.create()
+			record["patch"] = op.update()
+			record["delete"] = op.delete()
+		}
+
+		paths["/api/collections/"+collection.Name+"/records"] = records
+		paths["/api/collections/"+collection.Name+"/records/{id}"] = record
+		tags = append(tags, map[string]any{"name": collection.Name, "description": fmt.Sprintf("Records of the %s %s collection", collection.Name, collection.Type)})
+	}
+
+	title := options.Title
+
+	if title == "" {
+		title = "PocketBase API"
+	}
+
+	version := options.Version
+
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	spec := map[string]any{
+		"openapi": OPENAPI_VERSION,
+		"info":    map[string]any{"title": title, "version": version},
+		"tags":    tags,
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas":    schemas,
+			"parameters": parameters(),
+			"responses": map[string]any{
+				"BadRequest": errorResponse("The request is invalid or failed validation"),
+				"Forbidden":  errorResponse("The rule does not allow the action for this caller"),
+				"NotFound":   errorResponse("The record does not exist or the rule hides it"),
+			},
+			"securitySchemes": map[string]any{
+				"recordAuth":    map[string]any{"type": "apiKey", "in": "header", "name": "Authorization", "description": "Auth token of a record"},
+				"superuserAuth": map[string]any{"type": "apiKey", "in": "header", "name": "Authorization", "description": "Auth token of a superuser"},
+			},
+		},
+	}
+
+	if options.ServerURL != "" {
+		spec["servers"] = []any{map[string]any{"url": options.ServerURL}}
+	}
+
+	return spec, nil
+}
+
+// Relation fields like comments.post that delete their records when a record of the collection is deleted
+func (builder *schemaBuilder) cascades(collection services.PocketBaseCollectionResponse, collections []services.PocketBaseCollectionResponse) []string {
+	cascades := []string{}
+
+	for _, other := range collections {
+		for _, field := range builder.byId[other.Id].Fields {
+			if field.Type == "relation" && field.CollectionId() == collection.Id && field.OptionBool("cascadeDelete") {
+				cascades = append(cascades, other.Name+"."+field.Name)
+			}
+		}
+	}
+
+	return cascades
+}
+
+func (builder *schemaBuilder) hasFiles(collection services.PocketBaseCollectionResponse) bool {
+	for _, field := range builder.byId[collection.Id].Fields {
+		if field.Type == "file" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Body of a create or update, multipart bodies take file uploads and every other field as text
+func (builder *schemaBuilder) inputSchema(collection services.PocketBaseCollectionResponse, creating bool, multipart bool) map[string]any {
+	create := builder.createSchema(collection)
+	properties := create["properties"].(map[string]any)
+
+	for _, field := range builder.byId[collection.Id].Fields {
+		if field.Type == "autodate" {
+			continue
+		}
+
+		list, _ := properties[field.Name].(map[string]any)
+		stored := list["items"]
+
+		if multipart && field.Type == "file" {
+			item := map[string]any{"type": "string", "format": "binary"}
+
+			if field.IsMultiple() {
+				properties[field.Name] = map[string]any{"type": "array", "items": item, "maxItems": field.MaxSelect()}
+			} else {
+				properties[field.Name] = item
+			}
+		}
+
+		if creating {
+			continue
+		}
+
+		//Modifiers of updates, field+ adds and field- subtracts or removes
+		switch {
+		case field.Type == "number":
+			properties[field.Name+"+"] = map[string]any{"type": "number", "description": "Adds to the current value"}
+			properties[field.Name+"-"] = map[string]any{"type": "number", "description": "Subtracts from the current value"}
+		case field.IsMultiple():
+			list, _ := properties[field.Name].(map[string]any)
+			added := list["items"]
+
+			properties[field.Name+"+"] = oneOrMany(added)
+			properties["+"+field.Name] = oneOrMany(added)
+
+			//Files are removed by their stored filename
+			properties[field.Name+"-"] = oneOrMany(stored)
+		}
+	}
+
+	description := "Fields of a new record"
+
+	if !creating {
+		description = "Fields to change, every field is optional"
+	}
+
+	if multipart {
+		description += ", other fields can also be sent as JSON in the @jsonPayload part"
+		properties["@jsonPayload"] = map[string]any{"type": "string", "contentMediaType": "application/json"}
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"description":          description,
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	//Multipart bodies can send required fields in @jsonPayload instead of their own part
+	if creating && create["required"] != nil && !multipart {
+		schema["required"] = create["required"]
+	}
+
+	return schema
+}
+
+func oneOrMany(item any) map[string]any {
+	return map[string]any{"anyOf": []any{item, map[string]any{"type": "array", "items": item}}}
+}
+
+func listSchema(name string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"page":       map[string]any{"type": "integer"},
+			"perPage":    map[string]any{"type": "integer"},
+			"totalItems": map[string]any{"type": "integer", "description": "-1 with skipTotal"},
+			"totalPages": map[string]any{"type": "integer", "description": "-1 with skipTotal"},
+			"items":      map[string]any{"type": "array", "items": schemaRef(name)},
+		},
+		"required": []string{"page", "perPage", "totalItems", "totalPages", "items"},
+	}
+}
+
+func parameters() map[string]any {
+	query := func(name string, description string, schema map[string]any) map[string]any {
+		return map[string]any{"name": name, "in": "query", "description": description, "schema": schema}
+	}
+
+	text := map[string]any{"type": "string"}
+
+	return map[string]any{
+		"id":        map[string]any{"name": "id", "in": "path", "required": true, "schema": text},
+		"page":      query("page", "Page to return, starts at 1", map[string]any{"type": "integer", "minimum": 1, "default": 1}),
+		"perPage":   query("perPage", "Records per page", map[string]any{"type": "integer", "minimum": 1, "maximum": 1000, "default": 30}),
+		"sort":      query("sort", "Comma separated fields, - sorts descending like -created,id", text),
+		"filter":    query("filter", "Filter expression like (title ~ 'abc' && created > '2022-01-01')", text),
+		"expand":    query("expand", "Comma separated relations to expand like author,tags.owner", text),
+		"fields":    query("fields", "Comma separated fields to return like id,title,expand.author.name", text),
+		"skipTotal": query("skipTotal", "Skips counting the total, totalItems and totalPages are -1", map[string]any{"type": "boolean"}),
+	}
+}
+
+func errorResponse(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": schemaRef("Error")}},
+	}
+}
+
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func parameterRefs(names ...string) []any {
+	refs := make([]any, len(names))
+
+	for i, name := range names {
+		refs[i] = map[string]any{"$ref": "#/components/parameters/" + name}
+	}
+
+	return refs
+}
+
+type operationBuilder struct {
+	collection services.PocketBaseCollectionResponse
+	name       string
+	multipart  bool
+	cascades   []string
+}
+
+// Operation with the security, description and error responses derived from the rule
+func (op *operationBuilder) operation(action string, summary string, rule *string, responses map[string]any) map[string]any {
+	access, requiresAuth := ruleAccess(rule)
+
+	security := []any{map[string]any{}, map[string]any{"recordAuth": []any{}}}
+
+	switch {
+	case rule == nil:
+		security = []any{map[string]any{"superuserAuth": []any{}}}
+	case requiresAuth:
+		security = []any{map[string]any{"recordAuth": []any{}}, map[string]any{"superuserAuth": []any{}}}
+	}
+
+	responses["400"] = map[string]any{"$ref": "#/components/responses/BadRequest"}
+	responses["403"] = map[string]any{"$ref": "#/components/responses/Forbidden"}
+
+	var ruleValue any
+
+	if rule != nil {
+		ruleValue = *rule
+	}
+
+	return map[string]any{
+		"operationId":       op.collection.Name + "." + action,
+		"tags":              []any{op.collection.Name},
+		"summary":           summary,
+		"description":       "Access: " + access,
+		"security":          security,
+		"responses":         responses,
+		"x-pocketbase-rule": ruleValue,
+	}
+}
+
+func (op *operationBuilder) record() map[string]any {
+	return map[string]any{
+		"description": "The record",
+		"content":     map[string]any{"application/json": map[string]any{"schema": schemaRef(op.name)}},
+	}
+}
+
+func (op *operationBuilder) body(schema string) map[string]any {
+	content := map[string]any{"application/json": map[string]any{"schema": schemaRef(schema)}}
+
+	if op.multipart {
+		content["multipart/form-data"] = map[string]any{"schema": schemaRef(schema + "Form")}
+	}
+
+	return map[string]any{"required": true, "content": content}
+}
+
+func (op *operationBuilder) list() map[string]any {
+	operation := op.operation("list", "List "+op.collection.Name+" records", op.collection.ListRule, map[string]any{
+		"200": map[string]any{
+			"description": "A page of records, records hidden by the rule are left out",
+			"content":     map[string]any{"application/json": map[string]any{"schema": schemaRef(op.name + "List")}},
+		},
+	})
+
+	operation["parameters"] = parameterRefs("page", "perPage", "sort", "filter", "expand", "fields", "skipTotal")
+
+	return operation
+}
+
+func (op *operationBuilder) view() map[string]any {
+	operation := op.operation("view", "View a "+op.collection.Name+" record", op.collection.ViewRule, map[string]any{
+		"200": op.record(),
+		"404": map[string]any{"$ref": "#/components/responses/NotFound"},
+	})
+
+	operation["parameters"] = parameterRefs("id", "expand", "fields")
+
+	return operation
+}
+
+func (op *operationBuilder) create() map[string]any {
+	operation := op.operation("create", "Create a "+op.collection.Name+" record", op.collection.CreateRule, map[string]any{
+		"200": op.record(),
+	})
+
+	operation["parameters"] = parameterRefs("expand", "fields")
+	operation["requestBody"] = op.body(op.name + "Create")
+
+	return operation
+}
+
+func (op *operationBuilder) update() map[string]any {
+	operation := op.operation("update", "Update a "+op.collection.Name+" record", op.collection.UpdateRule, map[string]any{
+		"200": op.record(),
+		"404": map[string]any{"$ref": "#/components/responses/NotFound"},
+	})
+
+	operation["parameters"] = parameterRefs("id", "expand", "fields")
+	operation["requestBody"] = op.body(op.name + "Update")
+
+	return operation
+}
+
+func (op *operationBuilder) delete() map[string]any {
+	operation := op.operation("delete", "Delete a "+op.collection.Name+" record", op.collection.DeleteRule, map[string]any{
+		"204": map[string]any{"description": "The record was deleted"},
+		"404": map[string]any{"$ref": "#/components/responses/NotFound"},
+	})
+
+	operation["parameters"] = parameterRefs("id")
+	if len(op.cascades) > 0 {
+		operation["description"] = fmt.Sprintf("%s. Also deletes the records that reference it with cascade delete: %s", operation["description"], strings.Join(op.cascades, ", "))
+	}
+
+	return operation
+}
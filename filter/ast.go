@@ -0,0 +1,154 @@
+// Package filter parses PocketBase filter expressions into an AST and prints them back in a canonical form
+package filter
+
+// Position of a node in the source filter, Offset is in bytes while Line and Column start at 1
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+type Node interface {
+	Pos() Position
+	String() string
+}
+
+// A boolean expression, either a comparison or two expressions joined with && or ||
+type Expr interface {
+	Node
+	expr()
+}
+
+// A value compared by a comparison, either an identifier, a literal or a function call
+type Operand interface {
+	Node
+	operand()
+}
+
+type LogicalOp string
+
+const (
+	And LogicalOp = "&&"
+	Or  LogicalOp = "||"
+)
+
+type Operator string
+
+const (
+	Equal             Operator = "="
+	NotEqual          Operator = "!="
+	Greater           Operator = ">"
+	GreaterOrEqual    Operator = ">="
+	Less              Operator = "<"
+	LessOrEqual       Operator = "<="
+	Like              Operator = "~"
+	NotLike           Operator = "!~"
+	AnyEqual          Operator = "?="
+	AnyNotEqual       Operator = "?!="
+	AnyGreater        Operator = "?>"
+	AnyGreaterOrEqual Operator = "?>="
+	AnyLess           Operator = "?<"
+	AnyLessOrEqual    Operator = "?<="
+	AnyLike           Operator = "?~"
+	AnyNotLike        Operator = "?!~"
+)
+
+// Reports if the operator is one of the ?-prefixed any-of variants
+func (op Operator) IsAnyOf() bool {
+	return len(op) > 0 && op[0] == '?'
+}
+
+var operators = map[string]Operator{
+	"=": Equal, "!=": NotEqual, ">": Greater, ">=": GreaterOrEqual, "<": Less, "<=": LessOrEqual,
+	"~": Like, "!~": NotLike, "?=": AnyEqual, "?!=": AnyNotEqual, "?>": AnyGreater, "?>=": AnyGreaterOrEqual,
+	"?<": AnyLess, "?<=": AnyLessOrEqual, "?~": AnyLike, "?!~": AnyNotLike,
+}
+
+// Two expressions joined by a logical operator
+//
+// && binds tighter than || like in SQL, so a || b && c is a || (b && c). Same operators join from left to right.
+type BinaryExpr struct {
+	Position Position
+	Op       LogicalOp
+	Left     Expr
+	Right    Expr
+}
+
+type Comparison struct {
+	Position Position
+	Left     Operand
+	Op       Operator
+	Right    Operand
+}
+
+// A field, a dotted path or an @request, @collection or macro identifier, with an optional modifier like :lower
+type Identifier struct {
+	Position Position
+	Name     string
+	Modifier string
+}
+
+type StringLiteral struct {
+	Position Position
+	Value    string
+}
+
+// Kept as the source text so big integers and decimals print back unchanged
+type NumberLiteral struct {
+	Position Position
+	Value    string
+}
+
+type BoolLiteral struct {
+	Position Position
+	Value    bool
+}
+
+type NullLiteral struct {
+	Position Position
+}
+
+type FuncCall struct {
+	Position Position
+	Name     string
+	Args     []Operand
+}
+
+func (n *BinaryExpr) Pos() Position    { return n.Position }
+func (n *Comparison) Pos() Position    { return n.Position }
+func (n *Identifier) Pos() Position    { return n.Position }
+func (n *StringLiteral) Pos() Position { return n.Position }
+func (n *NumberLiteral) Pos() Position { return n.Position }
+func (n *BoolLiteral) Pos() Position   { return n.Position }
+func (n *NullLiteral) Pos() Position   { return n.Position }
+func (n *FuncCall) Pos() Position      { return n.Position }
+
+func (*BinaryExpr) expr() {}
+func (*Comparison) expr() {}
+
+func (*Identifier) operand()    {}
+func (*StringLiteral) operand() {}
+func (*NumberLiteral) operand() {}
+func (*BoolLiteral) operand()   {}
+func (*NullLiteral) operand()   {}
+func (*FuncCall) operand()      {}
+
+// Calls fn for the node and all of its children depth first, returning false skips the children
+func Walk(node Node, fn func(node Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *BinaryExpr:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *Comparison:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *FuncCall:
+		for _, arg := range n.Args {
+			Walk(arg, fn)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Layout PocketBase uses for datetime values, time.Time values are bound with it in UTC
+const DateTimeLayout = "2006-01-02 15:04:05.000Z"
+
+// Builders for expressions with safely bound values, the value is always turned into a literal
+// so it can never change the structure of the filter. Field names are checked by Validate.
+
+func Eq(field string, value any) Expr             { return compare(field, Equal, value) }
+func Neq(field string, value any) Expr            { return compare(field, NotEqual, value) }
+func Gt(field string, value any) Expr             { return compare(field, Greater, value) }
+func Gte(field string, value any) Expr            { return compare(field, GreaterOrEqual, value) }
+func Lt(field string, value any) Expr             { return compare(field, Less, value) }
+func Lte(field string, value any) Expr            { return compare(field, LessOrEqual, value) }
+func Contains(field string, value any) Expr       { return compare(field, Like, value) }
+func NotContains(field string, value any) Expr    { return compare(field, NotLike, value) }
+func AnyEq(field string, value any) Expr          { return compare(field, AnyEqual, value) }
+func AnyNeq(field string, value any) Expr         { return compare(field, AnyNotEqual, value) }
+func AnyGt(field string, value any) Expr          { return compare(field, AnyGreater, value) }
+func AnyGte(field string, value any) Expr         { return compare(field, AnyGreaterOrEqual, value) }
+func AnyLt(field string, value any) Expr          { return compare(field, AnyLess, value) }
+func AnyLte(field string, value any) Expr         { return compare(field, AnyLessOrEqual, value) }
+func AnyContains(field string, value any) Expr    { return compare(field, AnyLike, value) }
+func AnyNotContains(field string, value any) Expr { return compare(field, AnyNotLike, value) }
+
+// Matches when the field equals any of the values, an empty list never matches
+func In(field string, values ...any) Expr {
+	if len(values) == 0 {
+		return Eq("id", "")
+	}
+
+	exprs := make([]Expr, len(values))
+
+	for i, value := range values {
+		exprs[i] = Eq(field, value)
+	}
+
+	return OrAll(exprs...)
+}
+
+// Joins the expressions with &&, nil expressions are skipped
+func AndAll(exprs ...Expr) Expr {
+	return join(And, exprs)
+}
+
+// Joins the expressions with ||, nil expressions are skipped
+func OrAll(exprs ...Expr) Expr {
+	return join(Or, exprs)
+}
+
+func join(op LogicalOp, exprs []Expr) Expr {
+	var joined Expr
+
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+
+		if joined == nil {
+			joined = expr
+			continue
+		}
+
+		joined = &BinaryExpr{Op: op, Left: joined, Right: expr}
+	}
+
+	return joined
+}
+
+func compare(field string, op Operator, value any) Expr {
+	ident := &Identifier{Name: field}
+
+	if parsed, err := newIdentifier(token{value: field}); err == nil {
+		ident = parsed
+	}
+
+	return &Comparison{Left: ident, Op: op, Right: Value(value)}
+}
+
+// Turns a Go value into a literal, values without a literal form are bound as strings
+func Value(value any) Operand {
+	switch v := value.(type) {
+	case nil:
+		return &NullLiteral{}
+	case Operand:
+		return v
+	case string:
+		return &StringLiteral{Value: v}
+	case bool:
+		return &BoolLiteral{Value: v}
+	case int:
+		return &NumberLiteral{Value: strconv.Itoa(v)}
+	case int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return &NumberLiteral{Value: fmt.Sprint(v)}
+	case float32:
+		return &NumberLiteral{Value: strconv.FormatFloat(float64(v), 'f', -1, 32)}
+	case float64:
+		return &NumberLiteral{Value: strconv.FormatFloat(v, 'f', -1, 64)}
+	case time.Time:
+		return &StringLiteral{Value: v.UTC().Format(DateTimeLayout)}
+	case fmt.Stringer:
+		return &StringLiteral{Value: v.String()}
+	default:
+		return &StringLiteral{Value: fmt.Sprint(v)}
+	}
+}
+
+// An identifier operand, used to compare two fields or a field with an @request value
+func Field(name string) Operand {
+	if parsed, err := newIdentifier(token{value: name}); err == nil {
+		return parsed
+	}
+
+	return &Identifier{Name: name}
+}
+
+// Returns the names of every plain field the expression refers to, @ identifiers are skipped
+func Fields(expr Expr) []string {
+	fields := []string{}
+
+	Walk(expr, func(node Node) bool {
+		if ident, ok := node.(*Identifier); ok && len(ident.Name) > 0 && ident.Name[0] != '@' {
+			fields = append(fields, ident.Name)
+		}
+
+		return true
+	})
+
+	return fields
+}
+
+// Checks every identifier in an expression built in code is a valid PocketBase identifier
+func Validate(expr Expr) error {
+	var err error
+
+	Walk(expr, func(node Node) bool {
+		ident, ok := node.(*Identifier)
+
+		if !ok || err != nil {
+			return err == nil
+		}
+
+		if _, identErr := newIdentifier(token{value: ident.String(), position: ident.Position}); identErr != nil || !validIdentifier(ident.Name) {
+			err = &SyntaxError{Position: ident.Position, Message: fmt.Sprintf("invalid identifier %q", ident.String())}
+		}
+
+		return false
+	})
+
+	return err
+}
+
+func validIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		if (i == 0 && !isIdentStart(r)) || !isIdentPart(r) {
+			return false
+		}
+	}
+
+	return true
+}
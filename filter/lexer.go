@@ -0,0 +1,284 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenAnd
+	tokenOr
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+func (kind tokenKind) String() string {
+	switch kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenIdent:
+		return "identifier"
+	case tokenString:
+		return "string"
+	case tokenNumber:
+		return "number"
+	case tokenOperator:
+		return "operator"
+	case tokenAnd:
+		return "&&"
+	case tokenOr:
+		return "||"
+	case tokenLParen:
+		return "("
+	case tokenRParen:
+		return ")"
+	case tokenComma:
+		return ","
+	default:
+		return "token"
+	}
+}
+
+type token struct {
+	kind     tokenKind
+	text     string
+	value    string
+	position Position
+}
+
+// Reported for every lexing and parsing problem with the position it was found at
+type SyntaxError struct {
+	Position Position
+	Message  string
+}
+
+func (e *SyntaxError) Error() string {
+	//Expressions built in code have no position
+	if e.Position.Line == 0 {
+		return "filter: " + e.Message
+	}
+
+	return fmt.Sprintf("filter: line %d, column %d: %s", e.Position.Line, e.Position.Column, e.Message)
+}
+
+type lexer struct {
+	source string
+	offset int
+	line   int
+	column int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{source: source, line: 1, column: 1}
+}
+
+func (l *lexer) position() Position {
+	return Position{Offset: l.offset, Line: l.line, Column: l.column}
+}
+
+func (l *lexer) errorf(position Position, format string, args ...any) error {
+	return &SyntaxError{Position: position, Message: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) peekRune(ahead int) rune {
+	offset := l.offset
+
+	for i := 0; i < ahead && offset < len(l.source); i++ {
+		_, size := utf8.DecodeRuneInString(l.source[offset:])
+		offset += size
+	}
+
+	if offset >= len(l.source) {
+		return 0
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.source[offset:])
+
+	return r
+}
+
+func (l *lexer) advance() rune {
+	r, size := utf8.DecodeRuneInString(l.source[l.offset:])
+	l.offset += size
+
+	if r == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+
+	return r
+}
+
+// Skips whitespace and // line comments
+func (l *lexer) skipIgnored() {
+	for l.offset < len(l.source) {
+		r := l.peekRune(0)
+
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			l.advance()
+		case r == '/' && l.peekRune(1) == '/':
+			for l.offset < len(l.source) && l.peekRune(0) != '\n' {
+				l.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+
+	start := l.position()
+
+	if l.offset >= len(l.source) {
+		return token{kind: tokenEOF, position: start}, nil
+	}
+
+	r := l.peekRune(0)
+
+	switch {
+	case r == '(':
+		l.advance()
+		return token{kind: tokenLParen, text: "(", position: start}, nil
+	case r == ')':
+		l.advance()
+		return token{kind: tokenRParen, text: ")", position: start}, nil
+	case r == ',':
+		l.advance()
+		return token{kind: tokenComma, text: ",", position: start}, nil
+	case r == '&':
+		if l.peekRune(1) != '&' {
+			return token{}, l.errorf(start, "unexpected character '&', did you mean '&&'")
+		}
+
+		l.advance()
+		l.advance()
+		return token{kind: tokenAnd, text: "&&", position: start}, nil
+	case r == '|':
+		if l.peekRune(1) != '|' {
+			return token{}, l.errorf(start, "unexpected character '|', did you mean '||'")
+		}
+
+		l.advance()
+		l.advance()
+		return token{kind: tokenOr, text: "||", position: start}, nil
+	case r == '\'' || r == '"':
+		return l.lexString(start)
+	case isDigit(r) || (r == '-' && isDigit(l.peekRune(1))):
+		return l.lexNumber(start), nil
+	case strings.ContainsRune("=!<>~?", r):
+		return l.lexOperator(start)
+	case isIdentStart(r):
+		return l.lexIdent(start), nil
+	}
+
+	return token{}, l.errorf(start, "unexpected character %q", r)
+}
+
+func (l *lexer) lexString(start Position) (token, error) {
+	quote := l.advance()
+
+	var value strings.Builder
+
+	for {
+		if l.offset >= len(l.source) {
+			return token{}, l.errorf(start, "unterminated string")
+		}
+
+		r := l.advance()
+
+		//Only escaped quotes are unescaped, other backslashes are kept as they are like PocketBase does
+		if r == '\\' && l.peekRune(0) == quote {
+			value.WriteRune(l.advance())
+			continue
+		}
+
+		if r == quote {
+			break
+		}
+
+		value.WriteRune(r)
+	}
+
+	return token{kind: tokenString, text: l.source[start.Offset:l.offset], value: value.String(), position: start}, nil
+}
+
+func (l *lexer) lexNumber(start Position) token {
+	if l.peekRune(0) == '-' {
+		l.advance()
+	}
+
+	for isDigit(l.peekRune(0)) {
+		l.advance()
+	}
+
+	if l.peekRune(0) == '.' && isDigit(l.peekRune(1)) {
+		l.advance()
+
+		for isDigit(l.peekRune(0)) {
+			l.advance()
+		}
+	}
+
+	text := l.source[start.Offset:l.offset]
+
+	return token{kind: tokenNumber, text: text, value: text, position: start}
+}
+
+func (l *lexer) lexOperator(start Position) (token, error) {
+	//Longest operators first so ?!= is not read as ?!
+	for _, length := range []int{3, 2, 1} {
+		if start.Offset+length > len(l.source) {
+			continue
+		}
+
+		text := l.source[start.Offset : start.Offset+length]
+
+		if _, ok := operators[text]; ok {
+			for i := 0; i < length; i++ {
+				l.advance()
+			}
+
+			return token{kind: tokenOperator, text: text, value: text, position: start}, nil
+		}
+	}
+
+	return token{}, l.errorf(start, "unknown operator starting with %q", l.peekRune(0))
+}
+
+func (l *lexer) lexIdent(start Position) token {
+	l.advance()
+
+	for isIdentPart(l.peekRune(0)) {
+		l.advance()
+	}
+
+	text := l.source[start.Offset:l.offset]
+
+	return token{kind: tokenIdent, text: text, value: text, position: start}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isIdentStart(r rune) bool {
+	return r == '@' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || isDigit(r) || r == '.' || r == ':'
+}
@@ -0,0 +1,294 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modifiers that can follow an identifier
+var Modifiers = map[string]bool{
+	"lower":   true,
+	"length":  true,
+	"each":    true,
+	"isset":   true,
+	"changed": true,
+}
+
+// Functions with their minimum and maximum argument count, -1 means no maximum
+var Functions = map[string][2]int{
+	"geoDistance": {4, 4},
+	"strftime":    {2, -1},
+}
+
+// Datetime macros usable as identifiers
+var Macros = map[string]bool{
+	"@now": true, "@second": true, "@minute": true, "@hour": true, "@weekday": true,
+	"@day": true, "@month": true, "@year": true, "@yesterday": true, "@tomorrow": true,
+	"@todayStart": true, "@todayEnd": true, "@monthStart": true, "@monthEnd": true,
+	"@yearStart": true, "@yearEnd": true,
+}
+
+type parser struct {
+	lexer   *lexer
+	current token
+}
+
+// Parses a filter expression, syntax errors are returned as *SyntaxError
+func Parse(source string) (Expr, error) {
+	p := &parser{lexer: newLexer(source)}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.current.kind == tokenEOF {
+		return nil, &SyntaxError{Position: p.current.position, Message: "empty filter"}
+	}
+
+	expr, err := p.parseExpr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.current.kind != tokenEOF {
+		return nil, p.unexpected("&& or ||")
+	}
+
+	return expr, nil
+}
+
+// Parses the filter and prints it back in canonical form
+func Canonical(source string) (string, error) {
+	expr, err := Parse(source)
+
+	if err != nil {
+		return "", err
+	}
+
+	return expr.String(), nil
+}
+
+func (p *parser) advance() error {
+	next, err := p.lexer.next()
+
+	if err != nil {
+		return err
+	}
+
+	p.current = next
+
+	return nil
+}
+
+func (p *parser) unexpected(expected string) error {
+	found := p.current.kind.String()
+
+	if p.current.text != "" {
+		found = fmt.Sprintf("%s %q", found, p.current.text)
+	}
+
+	return &SyntaxError{Position: p.current.position, Message: fmt.Sprintf("expected %s, found %s", expected, found)}
+}
+
+// Parses || with the lowest precedence, PocketBase builds a single SQL expression where AND binds tighter than OR
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseLogical(Or, tokenOr, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	return p.parseLogical(And, tokenAnd, p.parseGroup)
+}
+
+// Joins operands of the same logical operator from left to right
+func (p *parser) parseLogical(op LogicalOp, kind tokenKind, operand func() (Expr, error)) (Expr, error) {
+	left, err := operand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.current.kind == kind {
+		position := p.current.position
+
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		right, err := operand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &BinaryExpr{Position: position, Op: op, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseGroup() (Expr, error) {
+	if p.current.kind != tokenLParen {
+		return p.parseComparison()
+	}
+
+	open := p.current.position
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parseExpr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.current.kind != tokenRParen {
+		if p.current.kind == tokenEOF {
+			return nil, &SyntaxError{Position: open, Message: "missing closing parenthesis for group"}
+		}
+
+		return nil, p.unexpected("')'")
+	}
+
+	return expr, p.advance()
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	position := p.current.position
+
+	left, err := p.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.current.kind != tokenOperator {
+		return nil, p.unexpected("comparison operator")
+	}
+
+	op := operators[p.current.value]
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	right, err := p.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comparison{Position: position, Left: left, Op: op, Right: right}, nil
+}
+
+func (p *parser) parseOperand() (Operand, error) {
+	current := p.current
+
+	switch current.kind {
+	case tokenString:
+		return &StringLiteral{Position: current.position, Value: current.value}, p.advance()
+	case tokenNumber:
+		return &NumberLiteral{Position: current.position, Value: current.value}, p.advance()
+	case tokenIdent:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		if p.current.kind == tokenLParen {
+			return p.parseCall(current)
+		}
+
+		switch current.value {
+		case "true", "false":
+			return &BoolLiteral{Position: current.position, Value: current.value == "true"}, nil
+		case "null":
+			return &NullLiteral{Position: current.position}, nil
+		}
+
+		return newIdentifier(current)
+	}
+
+	return nil, p.unexpected("identifier, literal or function")
+}
+
+func (p *parser) parseCall(name token) (Operand, error) {
+	limits, ok := Functions[name.value]
+
+	if !ok {
+		return nil, &SyntaxError{Position: name.position, Message: fmt.Sprintf("unknown function %q", name.value)}
+	}
+
+	call := &FuncCall{Position: name.position, Name: name.value}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	for p.current.kind != tokenRParen {
+		if len(call.Args) > 0 {
+			if p.current.kind != tokenComma {
+				return nil, p.unexpected("',' or ')'")
+			}
+
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		arg, err := p.parseOperand()
+
+		if err != nil {
+			return nil, err
+		}
+
+		call.Args = append(call.Args, arg)
+	}
+
+	if len(call.Args) < limits[0] || (limits[1] >= 0 && len(call.Args) > limits[1]) {
+		return nil, &SyntaxError{Position: name.position, Message: fmt.Sprintf("%s expects %s, got %d", call.Name, argumentCount(limits), len(call.Args))}
+	}
+
+	return call, p.advance()
+}
+
+func argumentCount(limits [2]int) string {
+	switch {
+	case limits[0] == limits[1]:
+		return fmt.Sprintf("%d arguments", limits[0])
+	case limits[1] < 0:
+		return fmt.Sprintf("at least %d arguments", limits[0])
+	default:
+		return fmt.Sprintf("%d to %d arguments", limits[0], limits[1])
+	}
+}
+
+// Splits the modifier off the identifier and checks @ identifiers are known
+//
+// A colon followed by a dotted path is an @collection alias like @collection.users:u.id and not a modifier.
+func newIdentifier(tok token) (*Identifier, error) {
+	ident := &Identifier{Position: tok.position, Name: tok.value}
+
+	if index := strings.LastIndex(tok.value, ":"); index >= 0 && !strings.Contains(tok.value[index:], ".") {
+		modifier := tok.value[index+1:]
+
+		if !Modifiers[modifier] {
+			return nil, &SyntaxError{Position: tok.position, Message: fmt.Sprintf("unknown modifier %q", modifier)}
+		}
+
+		ident.Name = tok.value[:index]
+		ident.Modifier = modifier
+	}
+
+	if strings.HasSuffix(ident.Name, ".") || strings.Contains(ident.Name, "..") || strings.HasSuffix(ident.Name, ":") {
+		return nil, &SyntaxError{Position: tok.position, Message: fmt.Sprintf("invalid identifier %q", tok.value)}
+	}
+
+	if strings.HasPrefix(ident.Name, "@") && !Macros[ident.Name] &&
+		!strings.HasPrefix(ident.Name, "@request.") && !strings.HasPrefix(ident.Name, "@collection.") {
+		return nil, &SyntaxError{Position: tok.position, Message: fmt.Sprintf("unknown identifier %q", ident.Name)}
+	}
+
+	return ident, nil
+}
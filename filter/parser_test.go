@@ -0,0 +1,81 @@
+package filter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JGugino/pb-go/filter"
+)
+
+func TestCanonical(t *testing.T) {
+	cases := map[string]string{
+		`a = 1 || b = 2 && c = 3`:               `a = 1 || b = 2 && c = 3`,
+		`a = 1 && b = 2 || c = 3`:               `a = 1 && b = 2 || c = 3`,
+		`(a = 1 || b = 2) && c = 3`:             `(a = 1 || b = 2) && c = 3`,
+		`a = 1 && (b = 2 || c = 3)`:             `a = 1 && (b = 2 || c = 3)`,
+		`((a = 1)) && b = 2 && c = 3`:           `a = 1 && b = 2 && c = 3`,
+		`a = 1 || (b = 2 || c = 3)`:             `a = 1 || (b = 2 || c = 3)`,
+		`title ~ "go"`:                          `title ~ 'go'`,
+		`tags ?= 'a' && @request.auth.id != ""`: `tags ?= 'a' && @request.auth.id != ''`,
+		`name:lower = 'x' && items:length > 2`:  `name:lower = 'x' && items:length > 2`,
+		`created >= @todayStart`:                `created >= @todayStart`,
+		`geoDistance(lon, lat, 1.5, 2) < 25`:    `geoDistance(lon, lat, 1.5, 2) < 25`,
+	}
+
+	for source, expected := range cases {
+		printed, err := filter.Canonical(source)
+
+		if err != nil {
+			t.Errorf("%s: %v", source, err)
+			continue
+		}
+
+		if printed != expected {
+			t.Errorf("%s printed as %s, expected %s", source, printed, expected)
+		}
+	}
+}
+
+func TestAndBindsTighterThanOr(t *testing.T) {
+	expr, err := filter.Parse(`a = 1 || b = 2 && c = 3`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, ok := expr.(*filter.BinaryExpr)
+
+	if !ok || root.Op != filter.Or {
+		t.Fatalf("root is %#v, expected ||", expr)
+	}
+
+	if right, ok := root.Right.(*filter.BinaryExpr); !ok || right.Op != filter.And {
+		t.Fatalf("right operand is %#v, expected &&", root.Right)
+	}
+}
+
+func TestBuilderGroupsByPrecedence(t *testing.T) {
+	expr := filter.AndAll(filter.OrAll(filter.Eq("a", 1), filter.Eq("b", 2)), filter.Eq("c", 3))
+
+	if printed := expr.String(); printed != `(a = 1 || b = 2) && c = 3` {
+		t.Fatalf("printed %s", printed)
+	}
+
+	expr = filter.OrAll(filter.AndAll(filter.Eq("a", 1), filter.Eq("b", 2)), filter.Eq("c", "x'y"))
+
+	if printed := expr.String(); printed != `a = 1 && b = 2 || c = 'x\'y'` {
+		t.Fatalf("printed %s", printed)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	for _, source := range []string{``, `a =`, `(a = 1`, `a = 1 &&`, `a == 1`, `a = 1 b = 2`} {
+		_, err := filter.Parse(source)
+
+		var syntaxErr *filter.SyntaxError
+
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected a *SyntaxError, got %v", source, err)
+		}
+	}
+}
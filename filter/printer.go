@@ -0,0 +1,77 @@
+package filter
+
+import "strings"
+
+// Canonical form: single spaces around operators, single quoted strings and parentheses
+// only where the precedence and left to right joining would otherwise change the tree
+
+func (n *BinaryExpr) String() string {
+	left := n.Left.String()
+	right := n.Right.String()
+
+	if inner, ok := n.Left.(*BinaryExpr); ok && precedence(inner.Op) < precedence(n.Op) {
+		left = "(" + left + ")"
+	}
+
+	//A right operand with the same operator is grouped as well so the tree prints back unchanged
+	if inner, ok := n.Right.(*BinaryExpr); ok && precedence(inner.Op) <= precedence(n.Op) {
+		right = "(" + right + ")"
+	}
+
+	return left + " " + string(n.Op) + " " + right
+}
+
+func precedence(op LogicalOp) int {
+	if op == And {
+		return 2
+	}
+
+	return 1
+}
+
+func (n *Comparison) String() string {
+	return n.Left.String() + " " + string(n.Op) + " " + n.Right.String()
+}
+
+func (n *Identifier) String() string {
+	if n.Modifier == "" {
+		return n.Name
+	}
+
+	return n.Name + ":" + n.Modifier
+}
+
+func (n *StringLiteral) String() string {
+	return Quote(n.Value)
+}
+
+func (n *NumberLiteral) String() string {
+	return n.Value
+}
+
+func (n *BoolLiteral) String() string {
+	if n.Value {
+		return "true"
+	}
+
+	return "false"
+}
+
+func (n *NullLiteral) String() string {
+	return "null"
+}
+
+func (n *FuncCall) String() string {
+	args := make([]string, len(n.Args))
+
+	for i, arg := range n.Args {
+		args[i] = arg.String()
+	}
+
+	return n.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Quotes a string value for a filter, single quotes inside it are escaped
+func Quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
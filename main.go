@@ -18,18 +18,20 @@ func main() {
 		log.Fatal("Failed to load .env")
 	}
 
-	pb := services.Pocketbase{}
+	pb, err := services.New(BASE_URL, services.WithRetryPolicy(services.DefaultRetryPolicy()))
 
-	pb.Init(BASE_URL)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	_, err := pb.Auth.AuthWithPasswordForCollection("_superusers", "", "", os.Getenv("PB_IDENTITY"), os.Getenv("PB_PASSWORD"))
+	_, err = pb.Auth.AuthWithPasswordForCollection("_superusers", "", "", os.Getenv("PB_IDENTITY"), os.Getenv("PB_PASSWORD"))
 
 	if err != nil {
 		fmt.Println(err.Error())
 		return
 	}
 
-	records, err := pb.Record.ListRecords("testing_collection", pb.Auth.AuthToken, services.PocketBaseListOptions{
+	records, err := pb.Record.ListRecords("testing_collection", pb.Auth.Token(), services.PocketBaseListOptions{
 		Page:    1,
 		PerPage: 30,
 		Filter:  fmt.Sprintf("id='%s'", "ibopxmpxt3dap2o"),
@@ -40,7 +42,7 @@ func main() {
 		return
 	}
 
-	updatedRecord, err := pb.Record.UpdateRecord("testing_collection", records.Items[0]["id"].(string), pb.Auth.AuthToken, map[string]any{
+	updatedRecord, err := pb.Record.UpdateRecord("testing_collection", records.Items[0]["id"].(string), pb.Auth.Token(), map[string]any{
 		"text": "This is some updated example text",
 	})
 
@@ -49,7 +51,7 @@ func main() {
 		return
 	}
 
-	pb.Collection.ImportCollections(pb.Auth.AuthToken, []map[string]any{
+	pb.Collection.ImportCollections(pb.Auth.Token(), []map[string]any{
 		{
 			"name": "example_collection",
 			"type": services.BaseCollection,
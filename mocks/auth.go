@@ -0,0 +1,62 @@
+package mocks
+
+import "github.com/JGugino/pb-go/services"
+
+type AuthService struct {
+	Recorder
+
+	AuthToken string
+
+	GetPBCollectionsAuthMethodsFunc   func(collection string, fields string) (services.AuthMethodResponse, error)
+	AuthWithPasswordForCollectionFunc func(collection string, expand string, fields string, identity string, password string) (services.AuthSuccessResponse, error)
+	RefreshAuthFunc                   func(collection string, token string) (services.AuthSuccessResponse, error)
+}
+
+var _ services.AuthService = (*AuthService)(nil)
+
+// Middleware is recorded but never run, the mock itself is returned
+func (m *AuthService) WithMiddleware(middleware ...services.Middleware) services.AuthService {
+	m.record("WithMiddleware", middleware)
+	return m
+}
+
+func (m *AuthService) Token() string {
+	return m.AuthToken
+}
+
+func (m *AuthService) GetPBCollectionsAuthMethods(collection string, fields string) (services.AuthMethodResponse, error) {
+	m.record("GetPBCollectionsAuthMethods", collection, fields)
+
+	if m.GetPBCollectionsAuthMethodsFunc != nil {
+		return m.GetPBCollectionsAuthMethodsFunc(collection, fields)
+	}
+
+	return services.AuthMethodResponse{}, nil
+}
+
+// A successful scripted result also updates AuthToken like the real service does
+func (m *AuthService) AuthWithPasswordForCollection(collection string, expand string, fields string, identity string, password string) (services.AuthSuccessResponse, error) {
+	m.record("AuthWithPasswordForCollection", collection, expand, fields, identity, password)
+
+	if m.AuthWithPasswordForCollectionFunc != nil {
+		res, err := m.AuthWithPasswordForCollectionFunc(collection, expand, fields, identity, password)
+
+		if err == nil {
+			m.AuthToken = res.Token
+		}
+
+		return res, err
+	}
+
+	return services.AuthSuccessResponse{}, nil
+}
+
+func (m *AuthService) RefreshAuth(collection string, token string) (services.AuthSuccessResponse, error) {
+	m.record("RefreshAuth", collection, token)
+
+	if m.RefreshAuthFunc != nil {
+		return m.RefreshAuthFunc(collection, token)
+	}
+
+	return services.AuthSuccessResponse{}, nil
+}
@@ -0,0 +1,54 @@
+// Package mocks provides configurable test doubles for the services interfaces
+//
+// Every mock records its calls and returns the result of the matching ...Func field,
+// methods without a Func return zero values and a nil error.
+package mocks
+
+import "sync"
+
+// A single call made to a mock
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Call log shared by the mocks
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (recorder *Recorder) record(method string, args ...any) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.calls = append(recorder.calls, Call{Method: method, Args: args})
+}
+
+// Returns every call made so far in order
+func (recorder *Recorder) Calls() []Call {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	return append([]Call{}, recorder.calls...)
+}
+
+// Returns the calls made to a single method
+func (recorder *Recorder) CallsTo(method string) []Call {
+	calls := []Call{}
+
+	for _, call := range recorder.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+func (recorder *Recorder) Reset() {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.calls = nil
+}
@@ -0,0 +1,116 @@
+package mocks
+
+import "github.com/JGugino/pb-go/services"
+
+type CollectionService struct {
+	Recorder
+
+	ImportCollectionsFunc   func(token string, collections []map[string]any, deleteMissing bool) error
+	CreateNewCollectionFunc func(token string, options services.CollectionOptions) (services.PocketBaseCollectionResponse, error)
+	UpdateCollectionFunc    func(token string, desiredCollection string, data map[string]any) (services.PocketBaseCollectionResponse, error)
+	ScaffoldCollectionsFunc func(token string) (map[string]any, error)
+	ViewCollectionFunc      func(token string, desiredCollection string) (services.PocketBaseCollectionResponse, error)
+	ListCollectionsFunc     func(token string, queryOptions services.PocketBaseListOptions) (services.PocketBaseCollectionListResponse, error)
+	DeleteCollectionFunc    func(token string, desiredCollection string) (bool, error)
+	TruncateCollectionFunc  func(token string, desiredCollection string) error
+
+	EnableConditionalUpdatesFunc func(token string, desiredCollection string) (services.PocketBaseCollectionResponse, error)
+}
+
+var _ services.CollectionService = (*CollectionService)(nil)
+
+// Middleware is recorded but never run, the mock itself is returned
+func (m *CollectionService) WithMiddleware(middleware ...services.Middleware) services.CollectionService {
+	m.record("WithMiddleware", middleware)
+	return m
+}
+
+func (m *CollectionService) ImportCollections(token string, collections []map[string]any, deleteMissing bool) error {
+	m.record("ImportCollections", token, collections, deleteMissing)
+
+	if m.ImportCollectionsFunc != nil {
+		return m.ImportCollectionsFunc(token, collections, deleteMissing)
+	}
+
+	return nil
+}
+
+func (m *CollectionService) CreateNewCollection(token string, options services.CollectionOptions) (services.PocketBaseCollectionResponse, error) {
+	m.record("CreateNewCollection", token, options)
+
+	if m.CreateNewCollectionFunc != nil {
+		return m.CreateNewCollectionFunc(token, options)
+	}
+
+	return services.PocketBaseCollectionResponse{}, nil
+}
+
+func (m *CollectionService) UpdateCollection(token string, desiredCollection string, data map[string]any) (services.PocketBaseCollectionResponse, error) {
+	m.record("UpdateCollection", token, desiredCollection, data)
+
+	if m.UpdateCollectionFunc != nil {
+		return m.UpdateCollectionFunc(token, desiredCollection, data)
+	}
+
+	return services.PocketBaseCollectionResponse{}, nil
+}
+
+func (m *CollectionService) ScaffoldCollections(token string) (map[string]any, error) {
+	m.record("ScaffoldCollections", token)
+
+	if m.ScaffoldCollectionsFunc != nil {
+		return m.ScaffoldCollectionsFunc(token)
+	}
+
+	return map[string]any{}, nil
+}
+
+func (m *CollectionService) ViewCollection(token string, desiredCollection string) (services.PocketBaseCollectionResponse, error) {
+	m.record("ViewCollection", token, desiredCollection)
+
+	if m.ViewCollectionFunc != nil {
+		return m.ViewCollectionFunc(token, desiredCollection)
+	}
+
+	return services.PocketBaseCollectionResponse{}, nil
+}
+
+func (m *CollectionService) ListCollections(token string, queryOptions services.PocketBaseListOptions) (services.PocketBaseCollectionListResponse, error) {
+	m.record("ListCollections", token, queryOptions)
+
+	if m.ListCollectionsFunc != nil {
+		return m.ListCollectionsFunc(token, queryOptions)
+	}
+
+	return services.PocketBaseCollectionListResponse{}, nil
+}
+
+func (m *CollectionService) DeleteCollection(token string, desiredCollection string) (bool, error) {
+	m.record("DeleteCollection", token, desiredCollection)
+
+	if m.DeleteCollectionFunc != nil {
+		return m.DeleteCollectionFunc(token, desiredCollection)
+	}
+
+	return false, nil
+}
+
+func (m *CollectionService) TruncateCollection(token string, desiredCollection string) error {
+	m.record("TruncateCollection", token, desiredCollection)
+
+	if m.TruncateCollectionFunc != nil {
+		return m.TruncateCollectionFunc(token, desiredCollection)
+	}
+
+	return nil
+}
+
+func (m *CollectionService) EnableConditionalUpdates(token string, desiredCollection string) (services.PocketBaseCollectionResponse, error) {
+	m.record("EnableConditionalUpdates", token, desiredCollection)
+
+	if m.EnableConditionalUpdatesFunc != nil {
+		return m.EnableConditionalUpdatesFunc(token, desiredCollection)
+	}
+
+	return services.PocketBaseCollectionResponse{}, nil
+}
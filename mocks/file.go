@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"io"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+type FileService struct {
+	Recorder
+
+	URLFunc          func(collection string, recordId string, filename string, fileToken string) string
+	RequestTokenFunc func(token string) (string, error)
+	DownloadFunc     func(collection string, recordId string, filename string, fileToken string, writer io.Writer) (int64, error)
+}
+
+var _ services.FileService = (*FileService)(nil)
+
+// Middleware is recorded but never run, the mock itself is returned
+func (m *FileService) WithMiddleware(middleware ...services.Middleware) services.FileService {
+	m.record("WithMiddleware", middleware)
+	return m
+}
+
+func (m *FileService) URL(collection string, recordId string, filename string, fileToken string) string {
+	m.record("URL", collection, recordId, filename, fileToken)
+
+	if m.URLFunc != nil {
+		return m.URLFunc(collection, recordId, filename, fileToken)
+	}
+
+	return ""
+}
+
+func (m *FileService) RequestToken(token string) (string, error) {
+	m.record("RequestToken", token)
+
+	if m.RequestTokenFunc != nil {
+		return m.RequestTokenFunc(token)
+	}
+
+	return "", nil
+}
+
+func (m *FileService) Download(collection string, recordId string, filename string, fileToken string, writer io.Writer) (int64, error) {
+	m.record("Download", collection, recordId, filename, fileToken, writer)
+
+	if m.DownloadFunc != nil {
+		return m.DownloadFunc(collection, recordId, filename, fileToken, writer)
+	}
+
+	return 0, nil
+}
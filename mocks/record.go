@@ -0,0 +1,215 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+type RecordService struct {
+	Recorder
+
+	CreateAuthRecordFunc  func(collection string, email string, password string, passwordConfirm string, token string) (map[string]any, error)
+	CreateNewRecordFunc   func(collection string, token string, data map[string]any) (map[string]any, error)
+	ListRecordsFunc       func(collection string, token string, queryOptions services.PocketBaseListOptions) (services.PocketBaseListResponse, error)
+	StreamRecordsFunc     func(collection string, token string, queryOptions services.PocketBaseListOptions, useNumber bool, fn func(record map[string]any) error) (services.PocketBaseListResponse, error)
+	PrefetchRecordsFunc   func(ctx context.Context, collection string, token string, queryOptions services.PocketBaseListOptions, options services.PrefetchOptions, fn func(page int, items []map[string]any) error) error
+	FetchAllRecordsFunc   func(ctx context.Context, collection string, token string, queryOptions services.PocketBaseListOptions, options services.PrefetchOptions) ([]map[string]any, error)
+	ViewRecordFunc        func(collection string, recordId string, token string) (map[string]any, error)
+	DeleteRecordFunc      func(collection string, recordId string, token string) (bool, error)
+	UpdateRecordFunc      func(collection string, recordId string, token string, updatedData map[string]any) (map[string]any, error)
+	UpdateRecordWithFunc  func(collection string, recordId string, token string, update *services.RecordUpdate) (map[string]any, error)
+	UpdateIfUnchangedFunc func(collection string, recordId string, token string, expectedUpdated string, data map[string]any) (map[string]any, error)
+	BatchFunc             func(token string, requests []services.BatchRequest) ([]services.BatchResult, error)
+	UpsertFunc            func(collection string, token string, matchFilter string, data map[string]any) (services.UpsertResult, error)
+	FindOrCreateFunc      func(collection string, token string, matchFilter string, defaults map[string]any) (services.UpsertResult, error)
+	UpdateWhereFunc       func(collection string, token string, matchFilter string, data map[string]any, options services.BulkOptions) (services.BulkResult, error)
+	DeleteWhereFunc       func(collection string, token string, matchFilter string, options services.BulkOptions) (services.BulkResult, error)
+}
+
+var _ services.RecordService = (*RecordService)(nil)
+
+// Middleware is recorded but never run, the mock itself is returned
+func (m *RecordService) WithMiddleware(middleware ...services.Middleware) services.RecordService {
+	m.record("WithMiddleware", middleware)
+	return m
+}
+
+func (m *RecordService) CreateAuthRecord(collection string, email string, password string, passwordConfirm string, token string) (map[string]any, error) {
+	m.record("CreateAuthRecord", collection, email, password, passwordConfirm, token)
+
+	if m.CreateAuthRecordFunc != nil {
+		return m.CreateAuthRecordFunc(collection, email, password, passwordConfirm, token)
+	}
+
+	return map[string]any{}, nil
+}
+
+func (m *RecordService) CreateNewRecord(collection string, token string, data map[string]any) (map[string]any, error) {
+	m.record("CreateNewRecord", collection, token, data)
+
+	if m.CreateNewRecordFunc != nil {
+		return m.CreateNewRecordFunc(collection, token, data)
+	}
+
+	return map[string]any{}, nil
+}
+
+func (m *RecordService) ListRecords(collection string, token string, queryOptions services.PocketBaseListOptions) (services.PocketBaseListResponse, error) {
+	m.record("ListRecords", collection, token, queryOptions)
+
+	if m.ListRecordsFunc != nil {
+		return m.ListRecordsFunc(collection, token, queryOptions)
+	}
+
+	return services.PocketBaseListResponse{}, nil
+}
+
+// Without a StreamRecordsFunc the items of ListRecordsFunc are passed to fn
+func (m *RecordService) StreamRecords(collection string, token string, queryOptions services.PocketBaseListOptions, useNumber bool, fn func(record map[string]any) error) (services.PocketBaseListResponse, error) {
+	m.record("StreamRecords", collection, token, queryOptions, useNumber)
+
+	if m.StreamRecordsFunc != nil {
+		return m.StreamRecordsFunc(collection, token, queryOptions, useNumber, fn)
+	}
+
+	if m.ListRecordsFunc == nil {
+		return services.PocketBaseListResponse{}, nil
+	}
+
+	list, err := m.ListRecordsFunc(collection, token, queryOptions)
+
+	if err != nil {
+		return services.PocketBaseListResponse{}, err
+	}
+
+	for _, item := range list.Items {
+		if err := fn(item); err != nil {
+			return list, err
+		}
+	}
+
+	list.Items = nil
+
+	return list, nil
+}
+
+func (m *RecordService) PrefetchRecords(ctx context.Context, collection string, token string, queryOptions services.PocketBaseListOptions, options services.PrefetchOptions, fn func(page int, items []map[string]any) error) error {
+	m.record("PrefetchRecords", collection, token, queryOptions, options)
+
+	if m.PrefetchRecordsFunc != nil {
+		return m.PrefetchRecordsFunc(ctx, collection, token, queryOptions, options, fn)
+	}
+
+	return nil
+}
+
+func (m *RecordService) FetchAllRecords(ctx context.Context, collection string, token string, queryOptions services.PocketBaseListOptions, options services.PrefetchOptions) ([]map[string]any, error) {
+	m.record("FetchAllRecords", collection, token, queryOptions, options)
+
+	if m.FetchAllRecordsFunc != nil {
+		return m.FetchAllRecordsFunc(ctx, collection, token, queryOptions, options)
+	}
+
+	return []map[string]any{}, nil
+}
+
+func (m *RecordService) ViewRecord(collection string, recordId string, token string) (map[string]any, error) {
+	m.record("ViewRecord", collection, recordId, token)
+
+	if m.ViewRecordFunc != nil {
+		return m.ViewRecordFunc(collection, recordId, token)
+	}
+
+	return map[string]any{}, nil
+}
+
+func (m *RecordService) DeleteRecord(collection string, recordId string, token string) (bool, error) {
+	m.record("DeleteRecord", collection, recordId, token)
+
+	if m.DeleteRecordFunc != nil {
+		return m.DeleteRecordFunc(collection, recordId, token)
+	}
+
+	return false, nil
+}
+
+func (m *RecordService) UpdateRecord(collection string, recordId string, token string, updatedData map[string]any) (map[string]any, error) {
+	m.record("UpdateRecord", collection, recordId, token, updatedData)
+
+	if m.UpdateRecordFunc != nil {
+		return m.UpdateRecordFunc(collection, recordId, token, updatedData)
+	}
+
+	return map[string]any{}, nil
+}
+
+func (m *RecordService) UpdateRecordWith(collection string, recordId string, token string, update *services.RecordUpdate) (map[string]any, error) {
+	m.record("UpdateRecordWith", collection, recordId, token, update)
+
+	if m.UpdateRecordWithFunc != nil {
+		return m.UpdateRecordWithFunc(collection, recordId, token, update)
+	}
+
+	return map[string]any{}, nil
+}
+
+func (m *RecordService) UpdateIfUnchanged(collection string, recordId string, token string, expectedUpdated string, data map[string]any) (map[string]any, error) {
+	m.record("UpdateIfUnchanged", collection, recordId, token, expectedUpdated, data)
+
+	if m.UpdateIfUnchangedFunc != nil {
+		return m.UpdateIfUnchangedFunc(collection, recordId, token, expectedUpdated, data)
+	}
+
+	return map[string]any{}, nil
+}
+
+func (m *RecordService) Batch(token string, requests []services.BatchRequest) ([]services.BatchResult, error) {
+	m.record("Batch", token, requests)
+
+	if m.BatchFunc != nil {
+		return m.BatchFunc(token, requests)
+	}
+
+	return []services.BatchResult{}, nil
+}
+
+func (m *RecordService) Upsert(collection string, token string, matchFilter string, data map[string]any) (services.UpsertResult, error) {
+	m.record("Upsert", collection, token, matchFilter, data)
+
+	if m.UpsertFunc != nil {
+		return m.UpsertFunc(collection, token, matchFilter, data)
+	}
+
+	return services.UpsertResult{}, nil
+}
+
+func (m *RecordService) FindOrCreate(collection string, token string, matchFilter string, defaults map[string]any) (services.UpsertResult, error) {
+	m.record("FindOrCreate", collection, token, matchFilter, defaults)
+
+	if m.FindOrCreateFunc != nil {
+		return m.FindOrCreateFunc(collection, token, matchFilter, defaults)
+	}
+
+	return services.UpsertResult{}, nil
+}
+
+func (m *RecordService) UpdateWhere(collection string, token string, matchFilter string, data map[string]any, options services.BulkOptions) (services.BulkResult, error) {
+	m.record("UpdateWhere", collection, token, matchFilter, data, options)
+
+	if m.UpdateWhereFunc != nil {
+		return m.UpdateWhereFunc(collection, token, matchFilter, data, options)
+	}
+
+	return services.BulkResult{}, nil
+}
+
+func (m *RecordService) DeleteWhere(collection string, token string, matchFilter string, options services.BulkOptions) (services.BulkResult, error) {
+	m.record("DeleteWhere", collection, token, matchFilter, options)
+
+	if m.DeleteWhereFunc != nil {
+		return m.DeleteWhereFunc(collection, token, matchFilter, options)
+	}
+
+	return services.BulkResult{}, nil
+}
@@ -0,0 +1,126 @@
+package pbtest
+
+import (
+	"net/http"
+	"strings"
+)
+
+func (server *Server) newSession(collection string, recordId string) string {
+	token := "pbtest." + newId() + newId()
+	server.sessions[token] = authSession{collection: collection, recordId: recordId}
+
+	return token
+}
+
+func (server *Server) session(r *http.Request) (authSession, bool) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	session, ok := server.sessions[token]
+
+	return session, ok
+}
+
+func (server *Server) isSuperuser(r *http.Request) bool {
+	session, ok := server.session(r)
+
+	return ok && session.collection == SuperusersCollection
+}
+
+// Checks a collection rule, rule expressions are not evaluated so any non empty rule only requires an auth token
+func (server *Server) allowed(r *http.Request, rule *string) bool {
+	if server.isSuperuser(r) {
+		return true
+	}
+
+	if rule == nil {
+		return false
+	}
+
+	if *rule == "" {
+		return true
+	}
+
+	_, ok := server.session(r)
+
+	return ok
+}
+
+func (server *Server) handleAuthMethods(w http.ResponseWriter, r *http.Request, collection string) {
+	col := server.findCollection(collection)
+
+	if col == nil || col.Type != "auth" {
+		writeNotFound(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"password": map[string]any{"enabled": true, "identityFields": []string{"email"}},
+		"oauth2":   map[string]any{"enabled": false, "providers": []any{}},
+		"mfa":      map[string]any{"enabled": false, "duration": 0},
+		"otp":      map[string]any{"enabled": false, "duration": 0},
+	})
+}
+
+func (server *Server) handleAuthWithPassword(w http.ResponseWriter, r *http.Request, collection string) {
+	col := server.findCollection(collection)
+
+	if col == nil || col.Type != "auth" {
+		writeNotFound(w)
+		return
+	}
+
+	body, err := server.decodeBody(r)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data due to invalid formatting.", nil)
+		return
+	}
+
+	identity, _ := body["identity"].(string)
+	password, _ := body["password"].(string)
+
+	for _, record := range server.records[col.Id] {
+		if record["email"] != identity && record["username"] != identity {
+			continue
+		}
+
+		if server.passwords[col.Id+"/"+record["id"].(string)] != password {
+			break
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"token":  server.newSession(col.Name, record["id"].(string)),
+			"record": server.outputRecord(col, record),
+		})
+		return
+	}
+
+	writeError(w, http.StatusBadRequest, "Failed to authenticate.", nil)
+}
+
+func (server *Server) handleAuthRefresh(w http.ResponseWriter, r *http.Request, collection string) {
+	col := server.findCollection(collection)
+
+	if col == nil || col.Type != "auth" {
+		writeNotFound(w)
+		return
+	}
+
+	session, ok := server.session(r)
+
+	if !ok || session.collection != col.Name {
+		writeError(w, http.StatusUnauthorized, "The request requires valid record authorization token.", nil)
+		return
+	}
+
+	_, record := server.findRecord(col, session.recordId)
+
+	if record == nil {
+		writeError(w, http.StatusNotFound, "Missing auth record context.", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"token":  server.newSession(col.Name, session.recordId),
+		"record": server.outputRecord(col, record),
+	})
+}
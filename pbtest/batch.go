@@ -0,0 +1,172 @@
+package pbtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+)
+
+const defaultBatchMaxRequests = 50
+
+type batchRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Body    map[string]any    `json:"body"`
+	Headers map[string]string `json:"headers"`
+}
+
+// Enables /api/batch like the batch settings of a real instance, it is disabled by default
+func (server *Server) EnableBatch(maxRequests int) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if maxRequests <= 0 {
+		maxRequests = defaultBatchMaxRequests
+	}
+
+	server.settings["batch"] = map[string]any{"enabled": true, "maxRequests": float64(maxRequests)}
+}
+
+func (server *Server) batchSettings() (bool, int) {
+	batch, _ := server.settings["batch"].(map[string]any)
+	enabled, _ := batch["enabled"].(bool)
+	maxRequests, _ := batch["maxRequests"].(float64)
+
+	if maxRequests <= 0 {
+		maxRequests = defaultBatchMaxRequests
+	}
+
+	return enabled, int(maxRequests)
+}
+
+// Runs every request in a single transaction, the first failing request rolls back the others
+func (server *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	enabled, maxRequests := server.batchSettings()
+
+	if !enabled {
+		writeError(w, http.StatusForbidden, "Batch requests are not allowed.", nil)
+		return
+	}
+
+	payload := struct {
+		Requests []batchRequest `json:"requests"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data due to invalid formatting.", nil)
+		return
+	}
+
+	if len(payload.Requests) > maxRequests {
+		writeError(w, http.StatusBadRequest, "Something went wrong while processing your request.", map[string]any{
+			"requests": map[string]any{"code": "validation_length_too_long", "message": "The maximum allowed items is " + strconv.Itoa(maxRequests) + "."},
+		})
+		return
+	}
+
+	records, passwords := server.snapshot()
+	results := make([]map[string]any, 0, len(payload.Requests))
+
+	for i, request := range payload.Requests {
+		status, body := server.runBatchRequest(r, request)
+
+		if status >= http.StatusBadRequest {
+			server.records = records
+			server.passwords = passwords
+
+			writeError(w, http.StatusBadRequest, "Batch transaction failed.", map[string]any{
+				"requests": map[string]any{
+					strconv.Itoa(i): map[string]any{"code": "batch_request_failed", "message": "Batch request failed.", "response": body},
+				},
+			})
+			return
+		}
+
+		results = append(results, map[string]any{"status": status, "body": body})
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (server *Server) runBatchRequest(outer *http.Request, request batchRequest) (int, any) {
+	encoded, _ := json.Marshal(request.Body)
+
+	inner := httptest.NewRequest(strings.ToUpper(request.Method), request.URL, bytes.NewReader(encoded))
+	inner.Header.Set("Content-Type", "application/json")
+	inner.Header.Set("Authorization", outer.Header.Get("Authorization"))
+
+	for k, v := range request.Headers {
+		inner.Header.Set(k, v)
+	}
+
+	recorder := httptest.NewRecorder()
+	parts := strings.Split(strings.Trim(inner.URL.Path, "/"), "/")
+
+	//Only record actions can be batched
+	switch {
+	case len(parts) < 4 || parts[0] != "api" || parts[1] != "collections" || parts[3] != "records":
+		writeNotFound(recorder)
+	case len(parts) == 4 && inner.Method == http.MethodPut:
+		server.handleUpsertRecord(recorder, inner, parts[2])
+	default:
+		server.routeCollections(recorder, inner, parts[2:])
+	}
+
+	var body any
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+
+	return recorder.Code, body
+}
+
+// Creates the record when the id is unknown and updates it otherwise, only available in batches
+func (server *Server) handleUpsertRecord(w http.ResponseWriter, r *http.Request, collection string) {
+	col := server.findCollection(collection)
+
+	if col == nil {
+		writeNotFound(w)
+		return
+	}
+
+	raw := &bytes.Buffer{}
+	raw.ReadFrom(r.Body)
+
+	body := map[string]any{}
+	json.Unmarshal(raw.Bytes(), &body)
+
+	r.Body = io.NopCloser(bytes.NewReader(raw.Bytes()))
+
+	if id, _ := body["id"].(string); id != "" {
+		if _, existing := server.findRecord(col, id); existing != nil {
+			server.handleUpdateRecord(w, r, collection, id)
+			return
+		}
+	}
+
+	server.handleCreateRecord(w, r, collection)
+}
+
+func (server *Server) snapshot() (map[string][]map[string]any, map[string]string) {
+	records := make(map[string][]map[string]any, len(server.records))
+
+	for id, list := range server.records {
+		copied := make([]map[string]any, len(list))
+
+		for i, record := range list {
+			copied[i] = copyRecord(record)
+		}
+
+		records[id] = copied
+	}
+
+	passwords := make(map[string]string, len(server.passwords))
+
+	for k, v := range server.passwords {
+		passwords[k] = v
+	}
+
+	return records, passwords
+}
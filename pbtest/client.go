@@ -0,0 +1,31 @@
+package pbtest
+
+import (
+	"testing"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// Credentials of the superuser every NewClient server starts with
+const (
+	SuperuserEmail    = "admin@example.com"
+	SuperuserPassword = "secret123"
+)
+
+// Starts a server that is closed with the test and holds a superuser, returns it with a client for
+// it and the token of the superuser so tests only have to add their collections
+func NewClient(t testing.TB, options ...services.Option) (*Server, *services.Pocketbase, string) {
+	t.Helper()
+
+	server := NewServer()
+	t.Cleanup(server.Close)
+
+	token := server.AddSuperuser(SuperuserEmail, SuperuserPassword)
+	pb, err := services.New(server.URL, options...)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return server, pb, token
+}
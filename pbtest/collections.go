@@ -0,0 +1,214 @@
+package pbtest
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+func (server *Server) requireSuperuser(w http.ResponseWriter, r *http.Request) bool {
+	if server.isSuperuser(r) {
+		return true
+	}
+
+	if _, ok := server.session(r); ok {
+		writeError(w, http.StatusForbidden, "Only superusers can perform this action.", nil)
+	} else {
+		writeError(w, http.StatusUnauthorized, "The request requires valid record authorization token.", nil)
+	}
+
+	return false
+}
+
+func (server *Server) handleListCollections(w http.ResponseWriter, r *http.Request) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	page, perPage := pagination(query.Get("page"), query.Get("perPage"))
+
+	start := min((page-1)*perPage, len(server.collections))
+	end := min(start+perPage, len(server.collections))
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"page":       page,
+		"perPage":    perPage,
+		"totalItems": len(server.collections),
+		"totalPages": (len(server.collections) + perPage - 1) / perPage,
+		"items":      server.collections[start:end],
+	})
+}
+
+func (server *Server) handleViewCollection(w http.ResponseWriter, r *http.Request, idOrName string) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	col := server.findCollection(idOrName)
+
+	if col == nil {
+		writeNotFound(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, col)
+}
+
+func (server *Server) handleCreateCollection(w http.ResponseWriter, r *http.Request) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	collection := Collection{}
+
+	if err := json.NewDecoder(r.Body).Decode(&collection); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data due to invalid formatting.", nil)
+		return
+	}
+
+	if collection.Name == "" {
+		writeError(w, http.StatusBadRequest, "Failed to create collection.", map[string]any{
+			"name": map[string]any{"code": "validation_required", "message": "Cannot be blank."},
+		})
+		return
+	}
+
+	if server.findCollection(collection.Name) != nil {
+		writeError(w, http.StatusBadRequest, "Failed to create collection.", notUniqueError("name"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, server.addCollection(collection))
+}
+
+func (server *Server) handleUpdateCollection(w http.ResponseWriter, r *http.Request, idOrName string) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	col := server.findCollection(idOrName)
+
+	if col == nil {
+		writeNotFound(w)
+		return
+	}
+
+	//Decoding over the stored collection only replaces the submitted keys
+	updated := *col
+
+	if err := json.NewDecoder(r.Body).Decode(&updated); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data due to invalid formatting.", nil)
+		return
+	}
+
+	updated.Id = col.Id
+	*col = updated
+
+	writeJSON(w, http.StatusOK, col)
+}
+
+func (server *Server) handleDeleteCollection(w http.ResponseWriter, r *http.Request, idOrName string) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	for i, col := range server.collections {
+		if col != server.findCollection(idOrName) {
+			continue
+		}
+
+		if col.System {
+			writeError(w, http.StatusBadRequest, "Failed to delete collection due to existing dependency.", nil)
+			return
+		}
+
+		server.collections = append(server.collections[:i], server.collections[i+1:]...)
+		delete(server.records, col.Id)
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	writeNotFound(w)
+}
+
+func (server *Server) handleTruncateCollection(w http.ResponseWriter, r *http.Request, idOrName string) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	col := server.findCollection(idOrName)
+
+	if col == nil {
+		writeNotFound(w)
+		return
+	}
+
+	delete(server.records, col.Id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (server *Server) handleImportCollections(w http.ResponseWriter, r *http.Request) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	body := struct {
+		Collections   []Collection `json:"collections"`
+		DeleteMissing bool         `json:"deleteMissing"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data due to invalid formatting.", nil)
+		return
+	}
+
+	imported := map[*Collection]bool{}
+
+	for _, collection := range body.Collections {
+		existing := server.findCollection(collection.Id)
+
+		if existing == nil {
+			existing = server.findCollection(collection.Name)
+		}
+
+		if existing == nil {
+			imported[server.addCollection(collection)] = true
+			continue
+		}
+
+		collection.Id = existing.Id
+		*existing = collection
+		imported[existing] = true
+	}
+
+	if body.DeleteMissing {
+		kept := []*Collection{}
+
+		for _, col := range server.collections {
+			if imported[col] || col.System {
+				kept = append(kept, col)
+				continue
+			}
+
+			delete(server.records, col.Id)
+		}
+
+		server.collections = kept
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (server *Server) handleScaffolds(w http.ResponseWriter, r *http.Request) {
+	if !server.requireSuperuser(w, r) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"auth": Collection{Type: "auth", Fields: authFields()},
+		"base": Collection{Type: "base", Fields: []map[string]any{{"name": "id", "type": "text", "system": true, "primaryKey": true}}},
+		"view": Collection{Type: "view", Fields: []map[string]any{}},
+	})
+}
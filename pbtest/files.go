@@ -0,0 +1,93 @@
+package pbtest
+
+import (
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// Stores the content of a file so records seeded with the filename can serve it
+func (server *Server) AddFile(filename string, content []byte) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.files[filename] = append([]byte{}, content...)
+}
+
+// Returns the content of an uploaded or added file
+func (server *Server) File(filename string) ([]byte, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	content, ok := server.files[filename]
+
+	return append([]byte{}, content...), ok
+}
+
+func (server *Server) storeUpload(filename string, header *multipart.FileHeader) error {
+	file, err := header.Open()
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+
+	if err != nil {
+		return err
+	}
+
+	server.files[filename] = content
+
+	return nil
+}
+
+// Issues a file token for any authenticated caller, protected files are not modelled so tokens are never checked
+func (server *Server) handleFileToken(w http.ResponseWriter, r *http.Request) {
+	session, ok := server.session(r)
+
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "The request requires valid record authorization token.", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"token": "pbtest-file." + session.recordId + newId()})
+}
+
+// Serves a file when the record references it in one of its fields
+func (server *Server) handleFile(w http.ResponseWriter, r *http.Request, collection string, id string, filename string) {
+	col := server.findCollection(collection)
+
+	if col == nil {
+		writeNotFound(w)
+		return
+	}
+
+	_, record := server.findRecord(col, id)
+	content, stored := server.files[filename]
+
+	if record == nil || !stored || !referencesFile(record, filename) {
+		writeNotFound(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(content))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+func referencesFile(record map[string]any, filename string) bool {
+	for _, value := range record {
+		for _, item := range toList(value) {
+			if item == filename {
+				return true
+			}
+		}
+	}
+
+	return false
+}
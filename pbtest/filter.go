@@ -0,0 +1,430 @@
+package pbtest
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Basic filter support for the fake: comparisons joined with && and ||, grouping and the ?-prefixed any-of operators.
+// Modifiers, functions and @request/@collection identifiers are not supported.
+
+type filterNode interface {
+	match(record map[string]any) bool
+}
+
+type orNode []filterNode
+type andNode []filterNode
+
+type comparisonNode struct {
+	left     filterOperand
+	operator string
+	right    filterOperand
+}
+
+type filterOperand struct {
+	field   string
+	literal any
+}
+
+func (node orNode) match(record map[string]any) bool {
+	for _, child := range node {
+		if child.match(record) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (node andNode) match(record map[string]any) bool {
+	for _, child := range node {
+		if !child.match(record) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (node comparisonNode) match(record map[string]any) bool {
+	left := node.left.resolve(record)
+	right := node.right.resolve(record)
+	operator := node.operator
+	anyOf := strings.HasPrefix(operator, "?")
+
+	if anyOf {
+		operator = operator[1:]
+	}
+
+	items, isList := left.([]any)
+
+	if !isList {
+		return compareValues(left, operator, right)
+	}
+
+	if len(items) == 0 {
+		return compareValues("", operator, right)
+	}
+
+	for _, item := range items {
+		matched := compareValues(item, operator, right)
+
+		if anyOf && matched {
+			return true
+		}
+
+		if !anyOf && !matched {
+			return false
+		}
+	}
+
+	return !anyOf
+}
+
+func (operand filterOperand) resolve(record map[string]any) any {
+	if operand.field == "" {
+		return operand.literal
+	}
+
+	var current any = record
+
+	for _, part := range strings.Split(operand.field, ".") {
+		values, ok := current.(map[string]any)
+
+		if !ok {
+			return nil
+		}
+
+		current = values[part]
+	}
+
+	return current
+}
+
+func compareValues(left any, operator string, right any) bool {
+	leftNumber, leftIsNumber := toNumber(left)
+	rightNumber, rightIsNumber := toNumber(right)
+
+	if leftIsNumber && rightIsNumber && operator != "~" && operator != "!~" {
+		switch operator {
+		case "=":
+			return leftNumber == rightNumber
+		case "!=":
+			return leftNumber != rightNumber
+		case ">":
+			return leftNumber > rightNumber
+		case ">=":
+			return leftNumber >= rightNumber
+		case "<":
+			return leftNumber < rightNumber
+		case "<=":
+			return leftNumber <= rightNumber
+		}
+	}
+
+	leftString := toString(left)
+	rightString := toString(right)
+
+	switch operator {
+	case "=":
+		return leftString == rightString
+	case "!=":
+		return leftString != rightString
+	case ">":
+		return leftString > rightString
+	case ">=":
+		return leftString >= rightString
+	case "<":
+		return leftString < rightString
+	case "<=":
+		return leftString <= rightString
+	case "~":
+		return likeMatch(leftString, rightString)
+	case "!~":
+		return !likeMatch(leftString, rightString)
+	}
+
+	return false
+}
+
+// Matches like PocketBase, without % wildcards the value is wrapped in them
+func likeMatch(value string, pattern string) bool {
+	if !strings.Contains(pattern, "%") {
+		pattern = "%" + pattern + "%"
+	}
+
+	parts := strings.Split(pattern, "%")
+
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	matcher, err := regexp.Compile("(?is)^" + strings.Join(parts, ".*") + "$")
+
+	return err == nil && matcher.MatchString(value)
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ### PARSING ###
+
+var filterTokenPattern = regexp.MustCompile(`^(\s+|&&|\|\||\?!~|\?!=|\?>=|\?<=|\?=|\?>|\?<|\?~|!~|!=|>=|<=|=|>|<|~|\(|\)|'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|[A-Za-z0-9_@.\-:]+)`)
+
+type filterParser struct {
+	tokens   []string
+	position int
+}
+
+func parseFilter(filter string) (filterNode, error) {
+	tokens := []string{}
+
+	for rest := filter; len(rest) > 0; {
+		token := filterTokenPattern.FindString(rest)
+
+		if token == "" {
+			return nil, fmt.Errorf("unexpected character at %d", len(filter)-len(rest))
+		}
+
+		rest = rest[len(token):]
+
+		if strings.TrimSpace(token) != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	parser := &filterParser{tokens: tokens}
+	node, err := parser.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected token %q", parser.tokens[parser.position])
+	}
+
+	return node, nil
+}
+
+func (parser *filterParser) peek() string {
+	if parser.position >= len(parser.tokens) {
+		return ""
+	}
+
+	return parser.tokens[parser.position]
+}
+
+func (parser *filterParser) next() string {
+	token := parser.peek()
+	parser.position++
+
+	return token
+}
+
+func (parser *filterParser) parseOr() (filterNode, error) {
+	nodes := orNode{}
+
+	for {
+		node, err := parser.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, node)
+
+		if parser.peek() != "||" {
+			break
+		}
+
+		parser.next()
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+
+	return nodes, nil
+}
+
+func (parser *filterParser) parseAnd() (filterNode, error) {
+	nodes := andNode{}
+
+	for {
+		node, err := parser.parseUnary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, node)
+
+		if parser.peek() != "&&" {
+			break
+		}
+
+		parser.next()
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+
+	return nodes, nil
+}
+
+func (parser *filterParser) parseUnary() (filterNode, error) {
+	if parser.peek() == "(" {
+		parser.next()
+
+		node, err := parser.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if parser.next() != ")" {
+			return nil, errors.New("missing closing parenthesis")
+		}
+
+		return node, nil
+	}
+
+	left, err := parser.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	operator := parser.next()
+
+	if !isFilterOperator(operator) {
+		return nil, fmt.Errorf("expected operator, got %q", operator)
+	}
+
+	right, err := parser.parseOperand()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return comparisonNode{left: left, operator: operator, right: right}, nil
+}
+
+func (parser *filterParser) parseOperand() (filterOperand, error) {
+	token := parser.next()
+
+	switch {
+	case token == "":
+		return filterOperand{}, errors.New("unexpected end of filter")
+	case strings.HasPrefix(token, "'") || strings.HasPrefix(token, `"`):
+		value := token[1 : len(token)-1]
+		value = strings.NewReplacer(`\'`, `'`, `\"`, `"`, `\\`, `\`).Replace(value)
+
+		return filterOperand{literal: value}, nil
+	case token == "true" || token == "false":
+		return filterOperand{literal: token == "true"}, nil
+	case token == "null":
+		return filterOperand{literal: nil}, nil
+	}
+
+	if number, err := strconv.ParseFloat(token, 64); err == nil {
+		return filterOperand{literal: number}, nil
+	}
+
+	if isFilterOperator(token) || token == "(" || token == ")" || token == "&&" || token == "||" {
+		return filterOperand{}, fmt.Errorf("expected operand, got %q", token)
+	}
+
+	return filterOperand{field: token}, nil
+}
+
+func isFilterOperator(token string) bool {
+	switch strings.TrimPrefix(token, "?") {
+	case "=", "!=", ">", ">=", "<", "<=", "~", "!~":
+		return true
+	}
+
+	return false
+}
+
+// Returns the records matching the filter, an empty filter matches everything
+func filterRecords(records []map[string]any, filter string) ([]map[string]any, error) {
+	matched := []map[string]any{}
+
+	if strings.TrimSpace(filter) == "" {
+		return append(matched, records...), nil
+	}
+
+	node, err := parseFilter(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		if node.match(record) {
+			matched = append(matched, record)
+		}
+	}
+
+	return matched, nil
+}
+
+// Sorts by a comma separated list of fields, a - prefix sorts descending
+func sortRecords(records []map[string]any, sortBy string) {
+	if strings.TrimSpace(sortBy) == "" {
+		return
+	}
+
+	fields := strings.Split(sortBy, ",")
+
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, field := range fields {
+			field = strings.TrimSpace(field)
+			descending := strings.HasPrefix(field, "-")
+			field = strings.TrimLeft(field, "+-")
+
+			left := filterOperand{field: field}.resolve(records[i])
+			right := filterOperand{field: field}.resolve(records[j])
+
+			if compareValues(left, "=", right) {
+				continue
+			}
+
+			return compareValues(left, "<", right) != descending
+		}
+
+		return false
+	})
+}
@@ -0,0 +1,494 @@
+package pbtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 1000
+)
+
+var uniqueIndexPattern = regexp.MustCompile(`(?i)create\s+unique\s+index\s+.*?\((.+)\)`)
+
+// Stores a new record, passwords of auth records are kept apart so they never show up in responses
+func (server *Server) insertRecord(col *Collection, data map[string]any) map[string]any {
+	record := map[string]any{}
+
+	for k, v := range data {
+		if k == "password" || k == "passwordConfirm" {
+			continue
+		}
+
+		record[k] = normalizeValue(v)
+	}
+
+	if id, _ := record["id"].(string); id == "" {
+		record["id"] = newId()
+	}
+
+	now := newTimestamp()
+
+	if _, ok := record["created"]; !ok {
+		record["created"] = now
+	}
+
+	if _, ok := record["updated"]; !ok {
+		record["updated"] = now
+	}
+
+	if password, ok := data["password"].(string); ok {
+		server.passwords[col.Id+"/"+record["id"].(string)] = password
+	}
+
+	server.records[col.Id] = append(server.records[col.Id], record)
+
+	return record
+}
+
+func (server *Server) handleListRecords(w http.ResponseWriter, r *http.Request, collection string) {
+	col := server.findCollection(collection)
+
+	if col == nil {
+		writeNotFound(w)
+		return
+	}
+
+	if !server.allowed(r, col.ListRule) {
+		writeError(w, http.StatusForbidden, "Only superusers can perform this action.", nil)
+		return
+	}
+
+	query := r.URL.Query()
+
+	matched, err := filterRecords(server.records[col.Id], query.Get("filter"))
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Something went wrong while processing your request. Invalid filter parameters.", nil)
+		return
+	}
+
+	sortRecords(matched, query.Get("sort"))
+
+	page, perPage := pagination(query.Get("page"), query.Get("perPage"))
+
+	start := min((page-1)*perPage, len(matched))
+	end := min(start+perPage, len(matched))
+
+	items := make([]map[string]any, 0, end-start)
+
+	for _, record := range matched[start:end] {
+		items = append(items, selectFields(server.outputRecord(col, record), query.Get("fields")))
+	}
+
+	totalItems := len(matched)
+	totalPages := (totalItems + perPage - 1) / perPage
+
+	if skip, _ := strconv.ParseBool(query.Get("skipTotal")); skip {
+		totalItems = -1
+		totalPages = -1
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"page":       page,
+		"perPage":    perPage,
+		"totalItems": totalItems,
+		"totalPages": totalPages,
+		"items":      items,
+	})
+}
+
+func (server *Server) handleViewRecord(w http.ResponseWriter, r *http.Request, collection string, id string) {
+	col := server.findCollection(collection)
+
+	if col == nil || !server.allowed(r, col.ViewRule) {
+		writeNotFound(w)
+		return
+	}
+
+	_, record := server.findRecord(col, id)
+
+	if record == nil {
+		writeNotFound(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, selectFields(server.outputRecord(col, record), r.URL.Query().Get("fields")))
+}
+
+func (server *Server) handleCreateRecord(w http.ResponseWriter, r *http.Request, collection string) {
+	col := server.findCollection(collection)
+
+	if col == nil {
+		writeNotFound(w)
+		return
+	}
+
+	if !server.allowed(r, col.CreateRule) {
+		writeError(w, http.StatusForbidden, "Only superusers can perform this action.", nil)
+		return
+	}
+
+	body, err := server.decodeBody(r)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data due to invalid formatting.", nil)
+		return
+	}
+
+	if col.Type == "auth" {
+		if errs := validateAuthRecord(body, true); len(errs) > 0 {
+			writeError(w, http.StatusBadRequest, "Failed to create record.", errs)
+			return
+		}
+	}
+
+	if id, _ := body["id"].(string); id != "" {
+		if _, existing := server.findRecord(col, id); existing != nil {
+			writeError(w, http.StatusBadRequest, "Failed to create record.", notUniqueError("id"))
+			return
+		}
+	}
+
+	if errs := server.checkUnique(col, body, ""); len(errs) > 0 {
+		writeError(w, http.StatusBadRequest, "Failed to create record.", errs)
+		return
+	}
+
+	record := server.insertRecord(col, body)
+
+	writeJSON(w, http.StatusOK, server.outputRecord(col, record))
+}
+
+func (server *Server) handleUpdateRecord(w http.ResponseWriter, r *http.Request, collection string, id string) {
+	col := server.findCollection(collection)
+
+	if col == nil || !server.allowed(r, col.UpdateRule) {
+		writeNotFound(w)
+		return
+	}
+
+	index, record := server.findRecord(col, id)
+
+	if record == nil {
+		writeNotFound(w)
+		return
+	}
+
+	body, err := server.decodeBody(r)
+
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to load the submitted data due to invalid formatting.", nil)
+		return
+	}
+
+	updated := applyModifiers(record, body)
+
+	if errs := server.checkUnique(col, updated, id); len(errs) > 0 {
+		writeError(w, http.StatusBadRequest, "Failed to update record.", errs)
+		return
+	}
+
+	if password, ok := body["password"].(string); ok && col.Type == "auth" {
+		server.passwords[col.Id+"/"+id] = password
+	}
+
+	delete(updated, "password")
+	delete(updated, "passwordConfirm")
+	delete(updated, "oldPassword")
+
+	updated["id"] = id
+	updated["created"] = record["created"]
+	updated["updated"] = newTimestamp()
+
+	server.records[col.Id][index] = updated
+
+	writeJSON(w, http.StatusOK, server.outputRecord(col, updated))
+}
+
+func (server *Server) handleDeleteRecord(w http.ResponseWriter, r *http.Request, collection string, id string) {
+	col := server.findCollection(collection)
+
+	if col == nil || !server.allowed(r, col.DeleteRule) {
+		writeNotFound(w)
+		return
+	}
+
+	index, record := server.findRecord(col, id)
+
+	if record == nil {
+		writeNotFound(w)
+		return
+	}
+
+	server.records[col.Id] = append(server.records[col.Id][:index], server.records[col.Id][index+1:]...)
+	delete(server.passwords, col.Id+"/"+id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ### HELPERS ###
+
+// Record as the API returns it, hidden fields like tokenKey are never part of a response
+func (server *Server) outputRecord(col *Collection, record map[string]any) map[string]any {
+	output := publicRecord(record)
+
+	for _, field := range col.Fields {
+		if hidden, _ := field["hidden"].(bool); hidden {
+			name, _ := field["name"].(string)
+			delete(output, name)
+		}
+	}
+
+	output["collectionId"] = col.Id
+	output["collectionName"] = col.Name
+
+	return output
+}
+
+func publicRecord(record map[string]any) map[string]any {
+	output := copyRecord(record)
+	delete(output, "tokenKey")
+
+	return output
+}
+
+// Applies the PocketBase update modifiers: "field+" and "+field" add or prepend, "field-" subtracts or removes
+func applyModifiers(record map[string]any, body map[string]any) map[string]any {
+	updated := copyRecord(record)
+
+	for key, value := range body {
+		value = normalizeValue(value)
+
+		switch {
+		case strings.HasPrefix(key, "+"):
+			field := key[1:]
+			updated[field] = append(toList(value), toList(updated[field])...)
+		case strings.HasSuffix(key, "+"):
+			field := key[:len(key)-1]
+
+			if number, ok := value.(float64); ok {
+				current, _ := updated[field].(float64)
+				updated[field] = current + number
+				continue
+			}
+
+			updated[field] = append(toList(updated[field]), toList(value)...)
+		case strings.HasSuffix(key, "-"):
+			field := key[:len(key)-1]
+
+			if number, ok := value.(float64); ok {
+				current, _ := updated[field].(float64)
+				updated[field] = current - number
+				continue
+			}
+
+			updated[field] = removeValues(toList(updated[field]), toList(value))
+		default:
+			updated[key] = value
+		}
+	}
+
+	return updated
+}
+
+func (server *Server) checkUnique(col *Collection, data map[string]any, selfId string) map[string]any {
+	groups := [][]string{}
+
+	if col.Type == "auth" {
+		groups = append(groups, []string{"email"})
+	}
+
+	for _, index := range col.Indexes {
+		match := uniqueIndexPattern.FindStringSubmatch(index)
+
+		if match == nil {
+			continue
+		}
+
+		columns := []string{}
+
+		for _, column := range strings.Split(match[1], ",") {
+			//Skips empty columns of a malformed index like "(a, )"
+			parts := strings.Fields(column)
+
+			if len(parts) == 0 {
+				continue
+			}
+
+			columns = append(columns, strings.Trim(parts[0], "`\"'[]"))
+		}
+
+		if len(columns) == 0 {
+			continue
+		}
+
+		groups = append(groups, columns)
+	}
+
+	for _, columns := range groups {
+		for _, record := range server.records[col.Id] {
+			if record["id"] == selfId {
+				continue
+			}
+
+			same := true
+
+			for _, column := range columns {
+				if fmt.Sprint(normalizeValue(data[column])) != fmt.Sprint(record[column]) {
+					same = false
+					break
+				}
+			}
+
+			if same {
+				errs := map[string]any{}
+
+				for _, column := range columns {
+					errs[column] = notUniqueError(column)[column]
+				}
+
+				return errs
+			}
+		}
+	}
+
+	return nil
+}
+
+func notUniqueError(field string) map[string]any {
+	return map[string]any{
+		field: map[string]any{"code": "validation_not_unique", "message": "Value must be unique."},
+	}
+}
+
+func validateAuthRecord(body map[string]any, creating bool) map[string]any {
+	errs := map[string]any{}
+
+	if email, _ := body["email"].(string); creating && email == "" {
+		errs["email"] = map[string]any{"code": "validation_required", "message": "Cannot be blank."}
+	}
+
+	password, _ := body["password"].(string)
+
+	if creating && password == "" {
+		errs["password"] = map[string]any{"code": "validation_required", "message": "Cannot be blank."}
+	}
+
+	if confirm, ok := body["passwordConfirm"].(string); ok && confirm != password {
+		errs["passwordConfirm"] = map[string]any{"code": "validation_values_mismatch", "message": "Values don't match."}
+	}
+
+	return errs
+}
+
+func pagination(rawPage string, rawPerPage string) (int, int) {
+	page, _ := strconv.Atoi(rawPage)
+	perPage, _ := strconv.Atoi(rawPerPage)
+
+	if page < 1 {
+		page = 1
+	}
+
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+
+	return page, min(perPage, maxPerPage)
+}
+
+func selectFields(record map[string]any, fields string) map[string]any {
+	if fields == "" {
+		return record
+	}
+
+	selected := map[string]any{}
+
+	for _, field := range strings.Split(fields, ",") {
+		field, _, _ = strings.Cut(strings.TrimSpace(field), ":")
+
+		if field == "*" {
+			return record
+		}
+
+		if value, ok := record[field]; ok {
+			selected[field] = value
+		}
+	}
+
+	return selected
+}
+
+// Converts decoded JSON numbers into float64 so stored values compare the same way as in responses
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		number, _ := v.Float64()
+		return number
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case []string:
+		items := make([]any, len(v))
+
+		for i, item := range v {
+			items[i] = item
+		}
+
+		return items
+	case []any:
+		items := make([]any, len(v))
+
+		for i, item := range v {
+			items[i] = normalizeValue(item)
+		}
+
+		return items
+	default:
+		return v
+	}
+}
+
+func toList(value any) []any {
+	switch v := value.(type) {
+	case nil:
+		return []any{}
+	case []any:
+		return v
+	case string:
+		if v == "" {
+			return []any{}
+		}
+
+		return []any{v}
+	default:
+		return []any{v}
+	}
+}
+
+func removeValues(items []any, remove []any) []any {
+	kept := []any{}
+
+	for _, item := range items {
+		found := false
+
+		for _, r := range remove {
+			if fmt.Sprint(item) == fmt.Sprint(r) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			kept = append(kept, item)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,584 @@
+// Package pbtest provides an in-memory fake PocketBase server for tests that use the services package
+package pbtest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SuperusersCollection = "_superusers"
+
+	idAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	timeLayout = "2006-01-02 15:04:05.000Z"
+)
+
+// A collection stored by the fake, nil rules are superuser only like on a real instance
+type Collection struct {
+	Id         string           `json:"id"`
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	Fields     []map[string]any `json:"fields"`
+	System     bool             `json:"system"`
+	ListRule   *string          `json:"listRule"`
+	ViewRule   *string          `json:"viewRule"`
+	CreateRule *string          `json:"createRule"`
+	UpdateRule *string          `json:"updateRule"`
+	DeleteRule *string          `json:"deleteRule"`
+	ViewQuery  string           `json:"viewQuery"`
+	Indexes    []string         `json:"indexes"`
+}
+
+// A request received by the fake, kept so tests can inspect what the client sent
+type RecordedRequest struct {
+	Method        string
+	Path          string
+	Query         string
+	Authorization string
+	Body          []byte
+}
+
+// A failure injected into matching requests
+//
+// Empty Method and Path match every request, Path matches by prefix.
+// Times is the number of requests that fail, zero or less fails every matching request.
+type Failure struct {
+	Method  string
+	Path    string
+	Status  int
+	Message string
+	Latency time.Duration
+	Times   int
+}
+
+type authSession struct {
+	collection string
+	recordId   string
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	collections []*Collection
+	records     map[string][]map[string]any
+	passwords   map[string]string
+	sessions    map[string]authSession
+	failures    []*Failure
+	requests    []RecordedRequest
+	latency     time.Duration
+	settings    map[string]any
+	files       map[string][]byte
+}
+
+// Starts a new fake server with an empty _superusers collection
+func NewServer() *Server {
+	server := &Server{
+		records:   map[string][]map[string]any{},
+		passwords: map[string]string{},
+		sessions:  map[string]authSession{},
+		settings:  map[string]any{},
+		files:     map[string][]byte{},
+	}
+
+	server.collections = append(server.collections, &Collection{
+		Id:     "pbc_" + newId(),
+		Name:   SuperusersCollection,
+		Type:   "auth",
+		System: true,
+		Fields: authFields(),
+	})
+
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+
+	return server
+}
+
+// ### SEEDING ###
+
+// Adds a collection, missing ids are generated and auth collections get the default auth fields
+func (server *Server) AddCollection(collection Collection) *Collection {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.addCollection(collection)
+}
+
+func (server *Server) addCollection(collection Collection) *Collection {
+	if collection.Id == "" {
+		collection.Id = "pbc_" + newId()
+	}
+
+	if collection.Type == "" {
+		collection.Type = "base"
+	}
+
+	if collection.Type == "auth" && len(collection.Fields) == 0 {
+		collection.Fields = authFields()
+	}
+
+	stored := collection
+	server.collections = append(server.collections, &stored)
+
+	return &stored
+}
+
+// Inserts records without any validation, returns the stored copies including generated ids
+func (server *Server) Seed(collection string, records ...map[string]any) []map[string]any {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	col := server.findCollection(collection)
+
+	if col == nil {
+		col = server.addCollection(Collection{Name: collection})
+	}
+
+	seeded := make([]map[string]any, 0, len(records))
+
+	for _, record := range records {
+		stored := server.insertRecord(col, record)
+		seeded = append(seeded, copyRecord(stored))
+	}
+
+	return seeded
+}
+
+// Adds a superuser and returns a valid token for it
+func (server *Server) AddSuperuser(email string, password string) string {
+	records := server.Seed(SuperusersCollection, map[string]any{"email": email, "password": password})
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return server.newSession(SuperusersCollection, records[0]["id"].(string))
+}
+
+// Sets the settings returned by GET /api/settings
+func (server *Server) SetSettings(settings map[string]any) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.settings = settings
+}
+
+// ### INSPECTING ###
+
+// Returns copies of every record in the collection in insertion order
+func (server *Server) Records(collection string) []map[string]any {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	col := server.findCollection(collection)
+
+	if col == nil {
+		return nil
+	}
+
+	records := make([]map[string]any, 0, len(server.records[col.Id]))
+
+	for _, record := range server.records[col.Id] {
+		records = append(records, copyRecord(record))
+	}
+
+	return records
+}
+
+func (server *Server) Record(collection string, id string) (map[string]any, bool) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	col := server.findCollection(collection)
+
+	if col == nil {
+		return nil, false
+	}
+
+	_, record := server.findRecord(col, id)
+
+	if record == nil {
+		return nil, false
+	}
+
+	return copyRecord(record), true
+}
+
+func (server *Server) Collections() []Collection {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	collections := make([]Collection, 0, len(server.collections))
+
+	for _, col := range server.collections {
+		collections = append(collections, *col)
+	}
+
+	return collections
+}
+
+// Returns every request received so far
+func (server *Server) Requests() []RecordedRequest {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return append([]RecordedRequest{}, server.requests...)
+}
+
+func (server *Server) ResetRequests() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.requests = nil
+}
+
+// ### FAILURES ###
+
+// Injects a failure, failures are checked in the order they were added
+func (server *Server) Fail(failure Failure) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.failures = append(server.failures, &failure)
+}
+
+// Makes the next n requests fail with the status
+func (server *Server) FailNext(n int, status int) {
+	server.Fail(Failure{Status: status, Times: n})
+}
+
+func (server *Server) ClearFailures() {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.failures = nil
+}
+
+// Delays every response by the duration
+func (server *Server) SetLatency(latency time.Duration) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.latency = latency
+}
+
+// Returns the failure for the request and removes it once it ran out
+func (server *Server) takeFailure(method string, path string) *Failure {
+	for i, failure := range server.failures {
+		if failure.Method != "" && !strings.EqualFold(failure.Method, method) {
+			continue
+		}
+
+		if !strings.HasPrefix(path, failure.Path) {
+			continue
+		}
+
+		if failure.Times > 0 {
+			failure.Times--
+
+			if failure.Times == 0 {
+				server.failures = append(server.failures[:i], server.failures[i+1:]...)
+			}
+		}
+
+		return failure
+	}
+
+	return nil
+}
+
+// ### ROUTING ###
+
+func (server *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	server.mu.Lock()
+
+	server.requests = append(server.requests, RecordedRequest{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Query:         r.URL.RawQuery,
+		Authorization: r.Header.Get("Authorization"),
+		Body:          body,
+	})
+
+	latency := server.latency
+	failure := server.takeFailure(r.Method, r.URL.Path)
+
+	if failure != nil {
+		latency += failure.Latency
+	}
+
+	server.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if failure != nil && failure.Status > 0 {
+		message := failure.Message
+
+		if message == "" {
+			message = http.StatusText(failure.Status)
+		}
+
+		writeError(w, failure.Status, message, nil)
+		return
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 2 && parts[0] == "api" && parts[1] == "settings" && r.Method == http.MethodGet:
+		server.handleSettings(w, r)
+	case len(parts) == 2 && parts[0] == "api" && parts[1] == "batch" && r.Method == http.MethodPost:
+		server.handleBatch(w, r)
+	case len(parts) == 3 && parts[0] == "api" && parts[1] == "files" && parts[2] == "token" && r.Method == http.MethodPost:
+		server.handleFileToken(w, r)
+	case len(parts) == 5 && parts[0] == "api" && parts[1] == "files" && r.Method == http.MethodGet:
+		server.handleFile(w, r, parts[2], parts[3], parts[4])
+	case len(parts) >= 2 && parts[0] == "api" && parts[1] == "collections":
+		server.routeCollections(w, r, parts[2:])
+	default:
+		writeNotFound(w)
+	}
+}
+
+func (server *Server) routeCollections(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0:
+		switch r.Method {
+		case http.MethodGet:
+			server.handleListCollections(w, r)
+		case http.MethodPost:
+			server.handleCreateCollection(w, r)
+		default:
+			writeNotFound(w)
+		}
+	case len(parts) == 1 && parts[0] == "import" && r.Method == http.MethodPut:
+		server.handleImportCollections(w, r)
+	case len(parts) == 2 && parts[0] == "meta" && parts[1] == "scaffolds" && r.Method == http.MethodGet:
+		server.handleScaffolds(w, r)
+	case len(parts) == 1:
+		switch r.Method {
+		case http.MethodGet:
+			server.handleViewCollection(w, r, parts[0])
+		case http.MethodPatch:
+			server.handleUpdateCollection(w, r, parts[0])
+		case http.MethodDelete:
+			server.handleDeleteCollection(w, r, parts[0])
+		default:
+			writeNotFound(w)
+		}
+	case len(parts) == 2 && parts[1] == "truncate" && r.Method == http.MethodDelete:
+		server.handleTruncateCollection(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "records":
+		switch r.Method {
+		case http.MethodGet:
+			server.handleListRecords(w, r, parts[0])
+		case http.MethodPost:
+			server.handleCreateRecord(w, r, parts[0])
+		default:
+			writeNotFound(w)
+		}
+	case len(parts) == 3 && parts[1] == "records":
+		switch r.Method {
+		case http.MethodGet:
+			server.handleViewRecord(w, r, parts[0], parts[2])
+		case http.MethodPatch:
+			server.handleUpdateRecord(w, r, parts[0], parts[2])
+		case http.MethodDelete:
+			server.handleDeleteRecord(w, r, parts[0], parts[2])
+		default:
+			writeNotFound(w)
+		}
+	case len(parts) == 2 && parts[1] == "auth-methods" && r.Method == http.MethodGet:
+		server.handleAuthMethods(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "auth-with-password" && r.Method == http.MethodPost:
+		server.handleAuthWithPassword(w, r, parts[0])
+	case len(parts) == 2 && parts[1] == "auth-refresh" && r.Method == http.MethodPost:
+		server.handleAuthRefresh(w, r, parts[0])
+	default:
+		writeNotFound(w)
+	}
+}
+
+func (server *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
+	if !server.isSuperuser(r) {
+		writeError(w, http.StatusForbidden, "Only superusers can perform this action.", nil)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, server.settings)
+}
+
+// ### HELPERS ###
+
+func (server *Server) findCollection(idOrName string) *Collection {
+	for _, col := range server.collections {
+		if col.Id == idOrName || strings.EqualFold(col.Name, idOrName) {
+			return col
+		}
+	}
+
+	return nil
+}
+
+func (server *Server) findRecord(col *Collection, id string) (int, map[string]any) {
+	for i, record := range server.records[col.Id] {
+		if record["id"] == id {
+			return i, record
+		}
+	}
+
+	return -1, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, message string, data map[string]any) {
+	if data == nil {
+		data = map[string]any{}
+	}
+
+	writeJSON(w, status, map[string]any{
+		"status":  status,
+		"message": message,
+		"data":    data,
+	})
+}
+
+func writeNotFound(w http.ResponseWriter) {
+	writeError(w, http.StatusNotFound, "The requested resource wasn't found.", nil)
+}
+
+func (server *Server) decodeBody(r *http.Request) (map[string]any, error) {
+	body := map[string]any{}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return server.decodeMultipartBody(r)
+	}
+
+	raw, err := io.ReadAll(r.Body)
+
+	if err != nil || len(bytes.TrimSpace(raw)) == 0 {
+		return body, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	err = decoder.Decode(&body)
+
+	return body, err
+}
+
+// Merges @jsonPayload with the plain values, uploaded files are stored under generated filenames
+func (server *Server) decodeMultipartBody(r *http.Request) (map[string]any, error) {
+	body := map[string]any{}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return body, err
+	}
+
+	for key, values := range r.MultipartForm.Value {
+		if key == "@jsonPayload" {
+			continue
+		}
+
+		if len(values) == 1 {
+			body[key] = values[0]
+			continue
+		}
+
+		body[key] = normalizeValue(values)
+	}
+
+	for key, headers := range r.MultipartForm.File {
+		filenames := make([]any, len(headers))
+
+		for i, header := range headers {
+			extension := path.Ext(header.Filename)
+			filename := strings.TrimSuffix(header.Filename, extension) + "_" + newId()[:10] + extension
+
+			if err := server.storeUpload(filename, header); err != nil {
+				return body, err
+			}
+
+			filenames[i] = filename
+		}
+
+		body[key] = filenames
+	}
+
+	for _, payload := range r.MultipartForm.Value["@jsonPayload"] {
+		decoder := json.NewDecoder(strings.NewReader(payload))
+		decoder.UseNumber()
+
+		if err := decoder.Decode(&body); err != nil {
+			return body, err
+		}
+	}
+
+	return body, nil
+}
+
+func newId() string {
+	buf := make([]byte, 15)
+	rand.Read(buf)
+
+	for i, b := range buf {
+		buf[i] = idAlphabet[int(b)%len(idAlphabet)]
+	}
+
+	return string(buf)
+}
+
+func newTimestamp() string {
+	return time.Now().UTC().Format(timeLayout)
+}
+
+func copyRecord(record map[string]any) map[string]any {
+	copied := make(map[string]any, len(record))
+
+	for k, v := range record {
+		if items, ok := v.([]any); ok {
+			v = append([]any{}, items...)
+		}
+
+		copied[k] = v
+	}
+
+	return copied
+}
+
+func authFields() []map[string]any {
+	return []map[string]any{
+		{"name": "id", "type": "text", "system": true, "primaryKey": true},
+		{"name": "password", "type": "password", "system": true, "hidden": true, "required": true},
+		{"name": "tokenKey", "type": "text", "system": true, "hidden": true},
+		{"name": "email", "type": "email", "system": true, "required": true},
+		{"name": "emailVisibility", "type": "bool", "system": true},
+		{"name": "verified", "type": "bool", "system": true},
+	}
+}
+
+// Returns a pointer to the rule string, used to build collections with non superuser rules
+func Rule(rule string) *string {
+	return &rule
+}
@@ -0,0 +1,247 @@
+package pbtest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func public() *string {
+	return pbtest.Rule("")
+}
+
+func addPosts(server *pbtest.Server) {
+	server.AddCollection(pbtest.Collection{
+		Name:       "posts",
+		Type:       "base",
+		ListRule:   public(),
+		ViewRule:   public(),
+		CreateRule: public(),
+		UpdateRule: public(),
+		DeleteRule: public(),
+		Fields: []map[string]any{
+			{"name": "title", "type": "text"},
+			{"name": "views", "type": "number"},
+		},
+	})
+}
+
+func TestRecordCRUD(t *testing.T) {
+	server, pb, _ := pbtest.NewClient(t)
+	addPosts(server)
+
+	created, err := pb.Record.CreateNewRecord("posts", "", map[string]any{"title": "hello", "views": 1})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := created["id"].(string)
+
+	if id == "" || created["collectionName"] != "posts" {
+		t.Fatalf("unexpected created record %v", created)
+	}
+
+	viewed, err := pb.Record.ViewRecord("posts", id, "")
+
+	if err != nil || viewed["title"] != "hello" {
+		t.Fatalf("view = %v, %v", viewed, err)
+	}
+
+	updated, err := pb.Record.UpdateRecord("posts", id, "", map[string]any{"title": "changed", "views+": 2})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated["title"] != "changed" || updated["views"] != float64(3) {
+		t.Fatalf("modifiers not applied: %v", updated)
+	}
+
+	if deleted, err := pb.Record.DeleteRecord("posts", id, ""); err != nil || !deleted {
+		t.Fatalf("delete = %t, %v", deleted, err)
+	}
+
+	if _, ok := server.Record("posts", id); ok {
+		t.Fatal("record still stored after delete")
+	}
+
+	if _, err := pb.Record.ViewRecord("posts", id, ""); err == nil {
+		t.Fatal("expected an error viewing a deleted record")
+	}
+}
+
+func TestListPaginationFilterAndSort(t *testing.T) {
+	server, pb, _ := pbtest.NewClient(t)
+	addPosts(server)
+
+	for i := 0; i < 45; i++ {
+		server.Seed("posts", map[string]any{"title": "post", "views": float64(i)})
+	}
+
+	page, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{Page: 3, PerPage: 20})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.TotalItems != 45 || page.TotalPages != 3 || len(page.Items) != 5 {
+		t.Fatalf("page 3 = total %d, pages %d, items %d", page.TotalItems, page.TotalPages, len(page.Items))
+	}
+
+	filtered, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{
+		Filter:  "views >= 40 || views < 2 && views != 0",
+		Sort:    "-views",
+		PerPage: 50,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	views := []float64{}
+
+	for _, item := range filtered.Items {
+		views = append(views, item["views"].(float64))
+	}
+
+	expected := []float64{44, 43, 42, 41, 40, 1}
+
+	if len(views) != len(expected) {
+		t.Fatalf("filtered views = %v, expected %v", views, expected)
+	}
+
+	for i := range expected {
+		if views[i] != expected[i] {
+			t.Fatalf("filtered views = %v, expected %v", views, expected)
+		}
+	}
+}
+
+func TestAuthWithPasswordAndRefresh(t *testing.T) {
+	server, pb, _ := pbtest.NewClient(t)
+
+	if _, err := pb.Auth.AuthWithPasswordForCollection(pbtest.SuperusersCollection, "", "", pbtest.SuperuserEmail, "wrong"); err == nil {
+		t.Fatal("expected a wrong password to fail")
+	}
+
+	auth, err := pb.Auth.AuthWithPasswordForCollection(pbtest.SuperusersCollection, "", "", pbtest.SuperuserEmail, pbtest.SuperuserPassword)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if auth.Token == "" {
+		t.Fatal("missing token")
+	}
+
+	refreshed, err := pb.Auth.RefreshAuth(pbtest.SuperusersCollection, auth.Token)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refreshed.Token == "" || refreshed.Token == auth.Token {
+		t.Fatalf("refresh returned %q", refreshed.Token)
+	}
+
+	if _, err := pb.Collection.ListCollections(refreshed.Token, services.PocketBaseListOptions{}); err != nil {
+		t.Fatalf("refreshed token can not list collections: %v", err)
+	}
+
+	//The first client keeps the token in its auth store
+	guest, err := services.New(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := guest.Collection.ListCollections("", services.PocketBaseListOptions{}); err == nil {
+		t.Fatal("expected listing collections without a token to fail")
+	}
+}
+
+func TestHiddenFieldsAreNotReturned(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "users", Type: "auth", ListRule: public(), ViewRule: public()})
+	server.Seed("users", map[string]any{"email": "a@example.com", "password": "secret123", "tokenKey": "key"})
+
+	list, err := pb.Record.ListRecords("users", token, services.PocketBaseListOptions{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range list.Items {
+		if _, ok := item["tokenKey"]; ok {
+			t.Fatalf("tokenKey returned in %v", item)
+		}
+
+		if _, ok := item["password"]; ok {
+			t.Fatalf("password returned in %v", item)
+		}
+	}
+}
+
+func TestMalformedUniqueIndexIsIgnored(t *testing.T) {
+	server, pb, _ := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{
+		Name:       "tags",
+		CreateRule: public(),
+		Fields:     []map[string]any{{"name": "name", "type": "text"}},
+		Indexes:    []string{"CREATE UNIQUE INDEX idx_name ON tags (name, )"},
+	})
+
+	if _, err := pb.Record.CreateNewRecord("tags", "", map[string]any{"name": "go"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pb.Record.CreateNewRecord("tags", "", map[string]any{"name": "go"}); err == nil {
+		t.Fatal("expected the unique index on name to reject a duplicate")
+	}
+}
+
+func TestInjectedFailures(t *testing.T) {
+	server, pb, _ := pbtest.NewClient(t)
+	addPosts(server)
+
+	server.FailNext(1, http.StatusInternalServerError)
+
+	if _, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{}); err == nil {
+		t.Fatal("expected the injected 500")
+	}
+
+	if _, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{}); err != nil {
+		t.Fatalf("failure outlived its count: %v", err)
+	}
+
+	server.Fail(pbtest.Failure{Method: "POST", Path: "/api/collections/posts", Status: http.StatusTooManyRequests, Times: 1})
+
+	if _, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{}); err != nil {
+		t.Fatalf("GET matched a POST failure: %v", err)
+	}
+
+	_, err := pb.Record.CreateNewRecord("posts", "", map[string]any{"title": "x"})
+
+	if err == nil {
+		t.Fatal("expected the injected 429")
+	}
+
+	server.SetLatency(50 * time.Millisecond)
+	started := time.Now()
+
+	if _, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(started); elapsed < 50*time.Millisecond {
+		t.Fatalf("latency not applied, took %s", elapsed)
+	}
+
+	if len(server.Requests()) != 5 {
+		t.Fatalf("recorded %d requests, expected 5", len(server.Requests()))
+	}
+}
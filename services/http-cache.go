@@ -0,0 +1,294 @@
+package services
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_CACHE_TTL         = 30 * time.Second
+	DEFAULT_CACHE_MAX_ENTRIES = 1000
+
+	DEFAULT_CACHE_MAX_ENTRY_SIZE = 1 << 20
+)
+
+// Caches successful record list and view responses, entries are keyed by collection, endpoint, query and auth identity
+//
+// Successful writes sent through the same client evict the entries of the written collection, entries of
+// responses with expand are evicted on every write as they can hold records of other collections. Writes
+// that can touch several collections like batches clear the whole cache. Collections are matched by
+// the name or id used in the url, so mixing both for the same collection can leave stale entries until the TTL.
+// The reads UpdateIfUnchanged, Upsert and UpdateWhere base their writes on skip cached responses, other
+// requests can do the same with a client from PBClient.WithContext and WithoutCache.
+//
+// Responses are buffered to be cached, so streamed reads like StreamRecords only start once the body or
+// MaxEntrySize bytes of it arrived. Larger responses are handed on uncached and those with a larger
+// Content-Length are not buffered at all.
+type ResponseCache struct {
+	TTL        time.Duration
+	MaxEntries int
+
+	//Largest body in bytes that is cached, DEFAULT_CACHE_MAX_ENTRY_SIZE when zero
+	MaxEntrySize int64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type cacheBypassKey struct{}
+
+// Marks the requests created with the context to skip cached responses, their fresh responses are still stored
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+type cacheEntry struct {
+	key        string
+	collection string
+	expands    bool
+	status     int
+	header     http.Header
+	body       []byte
+	expires    time.Time
+}
+
+func NewResponseCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	return &ResponseCache{TTL: ttl, MaxEntries: maxEntries}
+}
+
+// Evicts every entry of the collection and every entry with expanded relations
+func (cache *ResponseCache) InvalidateCollection(collection string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.init()
+
+	for element := cache.order.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*cacheEntry)
+
+		if entry.expands || strings.EqualFold(entry.collection, collection) {
+			cache.remove(element)
+		}
+
+		element = next
+	}
+}
+
+func (cache *ResponseCache) Clear() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.entries = map[string]*list.Element{}
+	cache.order = list.New()
+}
+
+func (cache *ResponseCache) Len() int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.init()
+
+	return cache.order.Len()
+}
+
+func (cache *ResponseCache) init() {
+	if cache.entries == nil {
+		cache.entries = map[string]*list.Element{}
+		cache.order = list.New()
+	}
+}
+
+func (cache *ResponseCache) remove(element *list.Element) {
+	cache.order.Remove(element)
+	delete(cache.entries, element.Value.(*cacheEntry).key)
+}
+
+// Returns a copy of the cached response for a cacheable request
+func (cache *ResponseCache) lookup(req *http.Request) (*http.Response, bool) {
+	if cache == nil || req.Method != http.MethodGet || req.Context().Value(cacheBypassKey{}) != nil {
+		return nil, false
+	}
+
+	collection, ok := cachedCollection(req.URL.Path)
+
+	if !ok {
+		return nil, false
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.init()
+
+	element, ok := cache.entries[cacheKey(req, collection)]
+
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+
+	if time.Now().After(entry.expires) {
+		cache.remove(element)
+		return nil, false
+	}
+
+	cache.order.MoveToFront(element)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.status, http.StatusText(entry.status)),
+		StatusCode:    entry.status,
+		Header:        entry.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}, true
+}
+
+// Stores successful reads and evicts entries on every write, returns the response with a readable body
+func (cache *ResponseCache) store(req *http.Request, resp *http.Response, err error) *http.Response {
+	if cache == nil {
+		return resp
+	}
+
+	collection, cacheable := cachedCollection(req.URL.Path)
+
+	if req.Method != http.MethodGet {
+		//Failed writes evict as well, a rejected conditional update means the cached record is stale.
+		//Imports, new collections and batches can change any collection.
+		switch {
+		case collection != "" && collection != "import":
+			cache.InvalidateCollection(collection)
+		case strings.Contains(req.URL.Path, "/api/collections") || strings.HasSuffix(req.URL.Path, "/api/batch"):
+			cache.Clear()
+		}
+
+		return resp
+	}
+
+	if err != nil || resp == nil || !cacheable || resp.StatusCode != http.StatusOK {
+		return resp
+	}
+
+	maxSize := cache.MaxEntrySize
+
+	if maxSize <= 0 {
+		maxSize = DEFAULT_CACHE_MAX_ENTRY_SIZE
+	}
+
+	if resp.ContentLength > maxSize {
+		return resp
+	}
+
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+
+	//Hand the rest of a body too large to cache back unread
+	if readErr != nil || int64(len(body)) > maxSize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+
+		return resp
+	}
+
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	ttl := cache.TTL
+
+	if ttl <= 0 {
+		ttl = DEFAULT_CACHE_TTL
+	}
+
+	maxEntries := cache.MaxEntries
+
+	if maxEntries <= 0 {
+		maxEntries = DEFAULT_CACHE_MAX_ENTRIES
+	}
+
+	entry := &cacheEntry{
+		key:        cacheKey(req, collection),
+		collection: collection,
+		expands:    req.URL.Query().Get("expand") != "",
+		status:     resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       body,
+		expires:    time.Now().Add(ttl),
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.init()
+
+	if existing, ok := cache.entries[entry.key]; ok {
+		cache.remove(existing)
+	}
+
+	cache.entries[entry.key] = cache.order.PushFront(entry)
+
+	for cache.order.Len() > maxEntries {
+		cache.remove(cache.order.Back())
+	}
+
+	return resp
+}
+
+// Returns the collection a path under /api/collections/ refers to and whether it is a record list or view endpoint
+func cachedCollection(path string) (string, bool) {
+	index := strings.Index(path, "/api/collections/")
+
+	if index < 0 {
+		return "", false
+	}
+
+	parts := strings.Split(strings.Trim(path[index+len("/api/collections/"):], "/"), "/")
+
+	if parts[0] == "" {
+		return "", false
+	}
+
+	return parts[0], len(parts) >= 2 && len(parts) <= 3 && parts[1] == "records"
+}
+
+func cacheKey(req *http.Request, collection string) string {
+	return strings.Join([]string{authIdentity(req.Header.Get("Authorization")), collection, req.URL.Path, req.URL.Query().Encode()}, "\n")
+}
+
+// Identifies the caller by a hash of the whole token, claims are not trusted as the client cannot verify them
+func authIdentity(token string) string {
+	if token == "" {
+		return "guest"
+	}
+
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Evicts the entries of the collections whenever another client changes them, blocks until the context is
+// cancelled or the realtime connection drops. The http client must not have a request timeout set.
+func (cache *ResponseCache) WatchRealtime(ctx context.Context, client *PBClient, token string, collections ...string) error {
+	topics := make([]string, len(collections))
+
+	for i, collection := range collections {
+		topics[i] = collection + "/*"
+	}
+
+	return client.SubscribeRealtime(ctx, token, topics, func(event RealtimeEvent) {
+		collection, _, _ := strings.Cut(event.Topic, "/")
+		cache.InvalidateCollection(collection)
+	})
+}
@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func cachedPosts(t *testing.T, cache *services.ResponseCache) (*pbtest.Server, *services.Pocketbase) {
+	t.Helper()
+
+	server, pb, _ := pbtest.NewClient(t, services.WithCache(cache))
+	server.AddCollection(pbtest.Collection{Name: "posts", ListRule: pbtest.Rule(""), ViewRule: pbtest.Rule(""), Fields: []map[string]any{{"name": "title", "type": "text"}}})
+
+	return server, pb
+}
+
+func TestCacheServesRepeatedReads(t *testing.T) {
+	server, pb := cachedPosts(t, services.NewResponseCache(0, 0))
+	record := server.Seed("posts", map[string]any{"title": "a"})[0]
+
+	for i := 0; i < 2; i++ {
+		if viewed, err := pb.Record.ViewRecord("posts", record["id"].(string), ""); err != nil || viewed["title"] != "a" {
+			t.Fatalf("view = %v, %v", viewed, err)
+		}
+	}
+
+	if len(server.Requests()) != 1 {
+		t.Fatalf("sent %d requests, expected the second view from the cache", len(server.Requests()))
+	}
+
+	res, err := pb.Client.SendHTTPRequest("GET", server.URL+"/api/collections/posts/records/"+record["id"].(string), map[string]string{}, map[string]any{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res.Body.Close()
+
+	if res.Status != "200 OK" || len(server.Requests()) != 1 {
+		t.Fatalf("cached status = %q after %d requests", res.Status, len(server.Requests()))
+	}
+}
+
+func TestCacheSkipsLargeResponses(t *testing.T) {
+	cache := services.NewResponseCache(0, 0)
+	cache.MaxEntrySize = 512
+
+	server, pb := cachedPosts(t, cache)
+
+	for i := 0; i < 20; i++ {
+		server.Seed("posts", map[string]any{"title": strings.Repeat("x", 64)})
+	}
+
+	for i := 0; i < 2; i++ {
+		list, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{})
+
+		if err != nil || len(list.Items) != 20 {
+			t.Fatalf("list = %d items, %v", len(list.Items), err)
+		}
+	}
+
+	if cache.Len() != 0 || len(server.Requests()) != 2 {
+		t.Fatalf("cached %d entries after %d requests, expected the large list uncached", cache.Len(), len(server.Requests()))
+	}
+}
+
+func TestCacheHandsOnChunkedResponsesAboveTheLimit(t *testing.T) {
+	body := `{"items":[` + strings.Repeat(`{"title":"xxxxxxxx"},`, 100) + `{}]}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//Flushing first drops the Content-Length
+		w.(http.Flusher).Flush()
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	cache := services.NewResponseCache(0, 0)
+	cache.MaxEntrySize = 256
+
+	pb, err := services.New(server.URL, services.WithCache(cache))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := pb.Client.SendHTTPRequest("GET", server.URL+"/api/collections/posts/records", map[string]string{}, map[string]any{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	if err != nil || string(read) != body {
+		t.Fatalf("read %d of %d bytes, %v", len(read), len(body), err)
+	}
+
+	if cache.Len() != 0 {
+		t.Fatal("cached a body above MaxEntrySize")
+	}
+}
+
+func TestUpdateIfUnchangedSkipsCachedReads(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t, services.WithCache(services.NewResponseCache(0, 0)))
+	server.AddCollection(pbtest.Collection{Name: "posts", Fields: []map[string]any{{"name": "title", "type": "text"}}})
+
+	record := server.Seed("posts", map[string]any{"title": "a", "updated": "2024-01-01 00:00:00.000Z"})[0]
+	id := record["id"].(string)
+
+	//Cache the record, then change it through a client without the cache
+	if _, err := pb.Record.ViewRecord("posts", id, token); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := services.New(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := other.Record.UpdateRecord("posts", id, token, map[string]any{"title": "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pb.Record.UpdateIfUnchanged("posts", id, token, record["updated"].(string), map[string]any{"title": "c"})
+
+	var conflict *services.ConflictError
+
+	if !errors.As(err, &conflict) || conflict.Current["title"] != "b" {
+		t.Fatalf("expected a conflict with the fresh record, got %v", err)
+	}
+
+	if stored, _ := server.Record("posts", id); stored["title"] != "b" {
+		t.Fatalf("stale update overwrote the record: %v", stored)
+	}
+}
+
+func TestRejectedWritesEvictCachedRecords(t *testing.T) {
+	server, pb := cachedPosts(t, services.NewResponseCache(0, 0))
+	record := server.Seed("posts", map[string]any{"title": "a"})[0]
+	id := record["id"].(string)
+
+	if _, err := pb.Record.ViewRecord("posts", id, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	server.Fail(pbtest.Failure{Method: "PATCH", Status: http.StatusBadRequest, Times: 1})
+
+	if _, err := pb.Record.UpdateRecord("posts", id, "", map[string]any{"title": "b"}); err == nil {
+		t.Fatal("expected the injected failure")
+	}
+
+	server.ResetRequests()
+
+	if _, err := pb.Record.ViewRecord("posts", id, ""); err != nil || len(server.Requests()) != 1 {
+		t.Fatalf("view after a rejected write = %v, %d requests", err, len(server.Requests()))
+	}
+}
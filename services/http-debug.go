@@ -0,0 +1,210 @@
+package services
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing breakdown of a single request collected through httptrace
+//
+// Total runs until the response headers are received, reading the body is not included.
+type RequestTiming struct {
+	DNS              time.Duration
+	Connect          time.Duration
+	TLS              time.Duration
+	TimeToFirstByte  time.Duration
+	Total            time.Duration
+	ReusedConnection bool
+}
+
+// Callbacks can run concurrently when several addresses are dialed at once, mu guards every field
+type requestTrace struct {
+	mu           sync.Mutex
+	started      time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+// Attaches a client trace to the request, the returned trace is filled in while the request runs
+func traceRequest(req *http.Request) (*http.Request, *requestTrace) {
+	trace := &requestTrace{started: time.Now()}
+
+	mark := func(field *time.Time) {
+		trace.mu.Lock()
+		defer trace.mu.Unlock()
+
+		//With happy eyeballs the first dial to start and the first to finish are kept
+		if field.IsZero() {
+			*field = time.Now()
+		}
+	}
+
+	clientTrace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { mark(&trace.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { mark(&trace.dnsDone) },
+		ConnectStart:      func(string, string) { mark(&trace.connectStart) },
+		ConnectDone:       func(string, string, error) { mark(&trace.connectDone) },
+		TLSHandshakeStart: func() { mark(&trace.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { mark(&trace.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			trace.mu.Lock()
+			trace.reused = info.Reused
+			trace.mu.Unlock()
+		},
+		GotFirstResponseByte: func() { mark(&trace.firstByte) },
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace)), trace
+}
+
+func (trace *requestTrace) timing(finished time.Time) RequestTiming {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+
+	span := func(start time.Time, end time.Time) time.Duration {
+		if start.IsZero() || end.IsZero() {
+			return 0
+		}
+
+		return end.Sub(start)
+	}
+
+	return RequestTiming{
+		DNS:              span(trace.dnsStart, trace.dnsDone),
+		Connect:          span(trace.connectStart, trace.connectDone),
+		TLS:              span(trace.tlsStart, trace.tlsDone),
+		TimeToFirstByte:  span(trace.started, trace.firstByte),
+		Total:            finished.Sub(trace.started),
+		ReusedConnection: trace.reused,
+	}
+}
+
+func (timing RequestTiming) String() string {
+	return fmt.Sprintf("dns=%s connect=%s tls=%s ttfb=%s total=%s reused=%t",
+		timing.DNS, timing.Connect, timing.TLS, timing.TimeToFirstByte, timing.Total, timing.ReusedConnection)
+}
+
+// Builds a curl command equivalent to the request with credentials, passwords and secrets masked
+func CurlCommand(req *http.Request) (string, error) {
+	var command strings.Builder
+
+	//File tokens are passed in the query so they get masked as well
+	url := *req.URL
+	query := url.Query()
+
+	if query.Has("token") {
+		query.Set("token", REDACTED)
+		url.RawQuery = query.Encode()
+	}
+
+	command.WriteString("curl -X " + req.Method + " " + shellQuote(url.String()))
+
+	headers := RedactHeaders(req.Header)
+	names := make([]string, 0, len(headers))
+
+	for k := range headers {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	for _, k := range names {
+		for _, v := range headers[k] {
+			command.WriteString(" \\\n  -H " + shellQuote(k+": "+v))
+		}
+	}
+
+	body, err := debugBody(req)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(body) > 0 {
+		command.WriteString(" \\\n  --data-raw " + shellQuote(body))
+	}
+
+	return command.String(), nil
+}
+
+// Reads a copy of the request body for the curl dump, JSON bodies are redacted and others are summarised
+//
+// JSON that can not be decoded is omitted as it could not be redacted either.
+func debugBody(req *http.Request) (string, error) {
+	if req.GetBody == nil || req.ContentLength == 0 {
+		return "", nil
+	}
+
+	reader, err := req.GetBody()
+
+	if err != nil {
+		return "", err
+	}
+
+	defer reader.Close()
+
+	if !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		return fmt.Sprintf("<%d bytes of %s omitted>", req.ContentLength, req.Header.Get("Content-Type")), nil
+	}
+
+	raw, err := io.ReadAll(reader)
+
+	if err != nil {
+		return "", err
+	}
+
+	var body any
+
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return fmt.Sprintf("<%d bytes of malformed JSON omitted>", len(raw)), nil
+	}
+
+	if object, ok := body.(map[string]any); ok && len(object) == 0 {
+		return "", nil
+	}
+
+	redacted, err := json.Marshal(redactValue(body))
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(redacted), nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// Writes the curl command and timing of a finished request to the debug writer
+func (client *PBClient) writeDebug(req *http.Request, res *http.Response, err error, trace *requestTrace) {
+	command, curlErr := CurlCommand(req)
+
+	if curlErr != nil {
+		command = fmt.Sprintf("# failed to build curl command: %s", curlErr.Error())
+	}
+
+	status := "no response"
+
+	if err != nil {
+		status = "error: " + err.Error()
+	} else if res != nil {
+		status = res.Status
+	}
+
+	fmt.Fprintf(client.DebugWriter, "%s\n# %s %s -> %s\n# %s\n\n", command, req.Method, req.URL.Path, status, trace.timing(time.Now()))
+}
@@ -0,0 +1,46 @@
+package services_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+func TestCurlCommandRedactsJSONArrays(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://localhost/api/batch", strings.NewReader(`[{"email":"a@b.c","password":"hunter2"}]`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "secret-token")
+
+	command, err := services.CurlCommand(req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{"hunter2", "secret-token"} {
+		if strings.Contains(command, secret) {
+			t.Errorf("command leaks %q:\n%s", secret, command)
+		}
+	}
+
+	if !strings.Contains(command, "a@b.c") {
+		t.Errorf("command lost the non secret values:\n%s", command)
+	}
+}
+
+func TestCurlCommandOmitsMalformedJSON(t *testing.T) {
+	req, _ := http.NewRequest("POST", "http://localhost/api/collections/users/records", strings.NewReader(`{"password":"hunter2"`))
+	req.Header.Set("Content-Type", "application/json")
+
+	command, err := services.CurlCommand(req)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(command, "hunter2") || !strings.Contains(command, "malformed JSON omitted") {
+		t.Errorf("malformed body was not omitted:\n%s", command)
+	}
+}
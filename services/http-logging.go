@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const REDACTED = "[REDACTED]"
+
+// Header names that never get logged with their value
+var sensitiveHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// Body keys that never get logged with their value, compared case insensitively
+var sensitiveBodyKeys = map[string]bool{
+	"password":        true,
+	"passwordconfirm": true,
+	"oldpassword":     true,
+	"clientsecret":    true,
+	"secret":          true,
+	"token":           true,
+}
+
+// Returns a copy of the headers with all credentials masked
+func RedactHeaders(headers http.Header) http.Header {
+	redacted := http.Header{}
+
+	for k, v := range headers {
+		if sensitiveHeaders[strings.ToLower(k)] {
+			redacted[k] = []string{REDACTED}
+			continue
+		}
+
+		redacted[k] = append([]string{}, v...)
+	}
+
+	return redacted
+}
+
+// Returns a copy of the body with passwords, secrets and tokens masked, nested values included
+func RedactBody(body map[string]any) map[string]any {
+	redacted := make(map[string]any, len(body))
+
+	for k, v := range body {
+		if sensitiveBodyKeys[strings.ToLower(k)] {
+			redacted[k] = REDACTED
+			continue
+		}
+
+		redacted[k] = redactValue(v)
+	}
+
+	return redacted
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return RedactBody(v)
+	case []any:
+		items := make([]any, len(v))
+
+		for i, item := range v {
+			items[i] = redactValue(item)
+		}
+
+		return items
+	case []map[string]any:
+		items := make([]map[string]any, len(v))
+
+		for i, item := range v {
+			items[i] = RedactBody(item)
+		}
+
+		return items
+	case OAuthProviderOptions:
+		v.ClientSecret = REDACTED
+		return v
+	default:
+		return v
+	}
+}
+
+// Keeps the client secret out of structured logs
+func (o OAuthProviderOptions) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("name", o.Name),
+		slog.String("clientId", o.ClientId),
+		slog.String("clientSecret", REDACTED),
+		slog.String("authURL", o.AuthURL),
+		slog.String("tokenURL", o.TokenURL),
+		slog.String("userInfoURL", o.UserInfoURL),
+		slog.String("displayName", o.DisplayName),
+	)
+}
+
+func headersLogValue(headers http.Header) slog.Value {
+	attrs := make([]slog.Attr, 0, len(headers))
+
+	for k, v := range RedactHeaders(headers) {
+		attrs = append(attrs, slog.String(k, strings.Join(v, ", ")))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// Writes a debug record for a finished request, does nothing without a logger
+func (client *PBClient) logRequest(req *http.Request, res *http.Response, err error, started time.Time, retries int) {
+	if client == nil || client.Logger == nil {
+		return
+	}
+
+	ctx := req.Context()
+
+	if !client.Logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Duration("duration", time.Since(started)),
+		slog.Int("retries", retries),
+		slog.Any("headers", headersLogValue(req.Header)),
+	}
+
+	if res != nil {
+		attrs = append(attrs, slog.Int("status", res.StatusCode))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	client.Logger.LogAttrs(ctx, slog.LevelDebug, "pocketbase request", attrs...)
+}
+
+// Logs errors that happen before a request could be sent
+func (client *PBClient) logError(msg string, err error) {
+	if client == nil || client.Logger == nil {
+		return
+	}
+
+	client.Logger.LogAttrs(context.Background(), slog.LevelDebug, msg, slog.String("error", err.Error()))
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RateLimitAudienceAll   = ""
+	RateLimitAudienceGuest = "@guest"
+	RateLimitAudienceAuth  = "@auth"
+)
+
+// A single rate limit rule, matches the format used in the PocketBase settings
+//
+// Label can be a path ("/api/batch"), a path prefix ending in a slash ("/api/"), a method scoped path ("POST /api/batch"),
+// a collection action ("posts:create") or a wildcard collection action ("*:create").
+type RateLimitRule struct {
+	Label       string `json:"label"`
+	Audience    string `json:"audience"`
+	Duration    int64  `json:"duration"`
+	MaxRequests int    `json:"maxRequests"`
+}
+
+type RateLimitSettings struct {
+	Enabled bool            `json:"enabled"`
+	Rules   []RateLimitRule `json:"rules"`
+}
+
+// Client side token bucket limiter, each rule gets its own bucket
+type RateLimiter struct {
+	mu      sync.Mutex
+	rules   []RateLimitRule
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens     float64
+	capacity   float64
+	rate       float64
+	lastRefill time.Time
+}
+
+func NewRateLimiter(rules ...RateLimitRule) *RateLimiter {
+	limiter := &RateLimiter{}
+	limiter.SetRules(rules)
+	return limiter
+}
+
+// Replaces the current rules and resets all buckets
+func (limiter *RateLimiter) SetRules(rules []RateLimitRule) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	limiter.rules = append([]RateLimitRule{}, rules...)
+	limiter.buckets = map[string]*tokenBucket{}
+}
+
+func (limiter *RateLimiter) Rules() []RateLimitRule {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return append([]RateLimitRule{}, limiter.rules...)
+}
+
+// Loads the rules from the rate limit settings of the instance, requires a superuser token
+func (limiter *RateLimiter) LoadFromSettings(baseURL string, token string) error {
+	var client *PBClient
+	return limiter.loadFromSettings(client, baseURL, token)
+}
+
+func (limiter *RateLimiter) loadFromSettings(client *PBClient, baseURL string, token string) error {
+	settings, err := client.FetchRateLimitSettings(baseURL, token)
+
+	if err != nil {
+		return err
+	}
+
+	if !settings.Enabled {
+		limiter.SetRules(nil)
+		return nil
+	}
+
+	limiter.SetRules(settings.Rules)
+	return nil
+}
+
+func FetchRateLimitSettings(baseURL string, token string) (RateLimitSettings, error) {
+	var client *PBClient
+	return client.FetchRateLimitSettings(baseURL, token)
+}
+
+// Fetches the rate limit settings through the client pipeline, requires a superuser token
+func (client *PBClient) FetchRateLimitSettings(baseURL string, token string) (RateLimitSettings, error) {
+	apiUrl := fmt.Sprintf("%s/api/settings?fields=rateLimits", baseURL)
+
+	res, err := client.SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
+
+	if err != nil {
+		return RateLimitSettings{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		pbErr := DecodePocketBaseErrorResponse(res)
+
+		return RateLimitSettings{}, errors.New(pbErr.Message)
+	}
+
+	settingsRes := struct {
+		RateLimits RateLimitSettings `json:"rateLimits"`
+	}{}
+
+	defer res.Body.Close()
+
+	err = json.NewDecoder(res.Body).Decode(&settingsRes)
+
+	if err != nil {
+		return RateLimitSettings{}, err
+	}
+
+	return settingsRes.RateLimits, nil
+}
+
+// Finds the most specific rule for the request, collection actions win over wildcard
+// actions which win over the longest matching path label
+func (limiter *RateLimiter) MatchRule(method string, path string, authenticated bool) (RateLimitRule, bool) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	return limiter.matchRule(method, path, authenticated)
+}
+
+func (limiter *RateLimiter) matchRule(method string, path string, authenticated bool) (RateLimitRule, bool) {
+	path = apiPath(path)
+
+	for _, tag := range rateLimitTags(method, path) {
+		if rule, ok := limiter.findRule(tag, authenticated); ok {
+			return rule, true
+		}
+	}
+
+	best := RateLimitRule{}
+	bestScore := -1
+
+	for _, rule := range limiter.rules {
+		if !audienceMatches(rule.Audience, authenticated) {
+			continue
+		}
+
+		length, ok := pathLabelMatches(rule.Label, method, path)
+
+		if !ok {
+			continue
+		}
+
+		//At the same prefix length prefer the method scoped label and then the audience scoped rule
+		score := length * 4
+
+		if strings.Contains(rule.Label, " ") {
+			score += 2
+		}
+
+		if rule.Audience != RateLimitAudienceAll {
+			score++
+		}
+
+		if score > bestScore {
+			best = rule
+			bestScore = score
+		}
+	}
+
+	return best, bestScore >= 0
+}
+
+func (limiter *RateLimiter) findRule(tag string, authenticated bool) (RateLimitRule, bool) {
+	found := RateLimitRule{}
+	ok := false
+
+	for _, rule := range limiter.rules {
+		if rule.Label != tag || !audienceMatches(rule.Audience, authenticated) {
+			continue
+		}
+
+		if rule.Audience != RateLimitAudienceAll {
+			return rule, true
+		}
+
+		if !ok {
+			found = rule
+			ok = true
+		}
+	}
+
+	return found, ok
+}
+
+// Blocks until the request is allowed by its matching rule or the context is done
+func (limiter *RateLimiter) Wait(ctx context.Context, method string, path string, authenticated bool) error {
+	limiter.mu.Lock()
+
+	rule, ok := limiter.matchRule(method, path, authenticated)
+
+	if !ok || rule.MaxRequests <= 0 || rule.Duration <= 0 {
+		limiter.mu.Unlock()
+		return nil
+	}
+
+	key := rule.Label + "|" + rule.Audience
+	bucket, exists := limiter.buckets[key]
+
+	if !exists {
+		bucket = &tokenBucket{
+			tokens:     float64(rule.MaxRequests),
+			capacity:   float64(rule.MaxRequests),
+			rate:       float64(rule.MaxRequests) / float64(rule.Duration),
+			lastRefill: time.Now(),
+		}
+		limiter.buckets[key] = bucket
+	}
+
+	delay := bucket.reserve(time.Now())
+
+	limiter.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		limiter.mu.Lock()
+		bucket.tokens++
+		limiter.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// Takes a token from the bucket and returns how long the caller has to wait for it
+func (bucket *tokenBucket) reserve(now time.Time) time.Duration {
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = min(bucket.capacity, bucket.tokens+elapsed*bucket.rate)
+	bucket.lastRefill = now
+
+	bucket.tokens--
+
+	if bucket.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-bucket.tokens / bucket.rate * float64(time.Second))
+}
+
+func audienceMatches(audience string, authenticated bool) bool {
+	switch audience {
+	case RateLimitAudienceGuest:
+		return !authenticated
+	case RateLimitAudienceAuth:
+		return authenticated
+	default:
+		return true
+	}
+}
+
+// Strips any subpath the instance is deployed under so only the /api/... part remains
+func apiPath(path string) string {
+	if index := strings.Index(path, "/api/"); index > 0 {
+		return path[index:]
+	}
+
+	return path
+}
+
+// Returns the matched length if the label is a path label matching the request, labels ending in a slash
+// match every path below them and other labels only the exact path
+func pathLabelMatches(label string, method string, path string) (int, bool) {
+	labelMethod, labelPath, hasMethod := strings.Cut(label, " ")
+
+	if !hasMethod {
+		labelPath = labelMethod
+	} else if !strings.EqualFold(labelMethod, method) {
+		return 0, false
+	}
+
+	if !strings.HasPrefix(labelPath, "/") {
+		return 0, false
+	}
+
+	if strings.HasSuffix(labelPath, "/") {
+		if !strings.HasPrefix(path, labelPath) {
+			return 0, false
+		}
+	} else if path != labelPath {
+		return 0, false
+	}
+
+	return len(labelPath), true
+}
+
+// Builds the collection action tags for the request ordered from most to least specific
+func rateLimitTags(method string, path string) []string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	if len(parts) < 4 || parts[0] != "api" || parts[1] != "collections" {
+		return nil
+	}
+
+	collection := parts[2]
+	actions := []string{}
+
+	switch {
+	case parts[3] == "records" && len(parts) == 4:
+		switch method {
+		case http.MethodGet:
+			actions = append(actions, "list")
+		case http.MethodPost:
+			actions = append(actions, "create")
+		}
+	case parts[3] == "records" && len(parts) == 5:
+		switch method {
+		case http.MethodGet:
+			actions = append(actions, "view")
+		case http.MethodPatch:
+			actions = append(actions, "update")
+		case http.MethodDelete:
+			actions = append(actions, "delete")
+		}
+	case len(parts) == 4:
+		switch parts[3] {
+		case "auth-methods":
+			actions = append(actions, "listAuthMethods")
+		case "auth-refresh":
+			actions = append(actions, "authRefresh")
+		case "auth-with-password":
+			actions = append(actions, "authWithPassword", "auth")
+		case "auth-with-oauth2":
+			actions = append(actions, "authWithOAuth2", "auth")
+		case "auth-with-otp":
+			actions = append(actions, "authWithOTP", "auth")
+		default:
+			actions = append(actions, kebabToCamel(parts[3]))
+		}
+	}
+
+	tags := make([]string, 0, len(actions)*2)
+
+	for _, action := range actions {
+		tags = append(tags, collection+":"+action, "*:"+action)
+	}
+
+	return tags
+}
+
+func kebabToCamel(value string) string {
+	parts := strings.Split(value, "-")
+
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) > 0 {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,184 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func TestRateLimitLabelMatching(t *testing.T) {
+	cases := []struct {
+		name          string
+		labels        []string
+		method        string
+		path          string
+		authenticated bool
+		expected      string
+	}{
+		{"exact path", []string{"/api/batch"}, "POST", "/api/batch", false, "/api/batch"},
+		{"exact path does not match below it", []string{"/api/batch"}, "POST", "/api/batch/extra", false, ""},
+		{"exact path does not match a longer name", []string{"/api/health"}, "GET", "/api/healthz", false, ""},
+		{"slash matches below it", []string{"/api/"}, "GET", "/api/settings", false, "/api/"},
+		{"longest prefix wins", []string{"/api/", "/api/collections/"}, "GET", "/api/collections/posts", false, "/api/collections/"},
+		{"exact path beats a prefix", []string{"/api/", "/api/settings"}, "GET", "/api/settings", false, "/api/settings"},
+		{"method scoped label", []string{"/api/batch", "POST /api/batch"}, "POST", "/api/batch", false, "POST /api/batch"},
+		{"other method", []string{"POST /api/batch"}, "GET", "/api/batch", false, ""},
+		{"subpath deployment", []string{"/api/"}, "GET", "/pb/api/settings", false, "/api/"},
+		{"non path label", []string{"api/"}, "GET", "/api/settings", false, ""},
+		{"collection action beats path", []string{"/api/", "posts:create"}, "POST", "/api/collections/posts/records", false, "posts:create"},
+		{"collection action beats wildcard", []string{"*:create", "posts:create"}, "POST", "/api/collections/posts/records", false, "posts:create"},
+		{"wildcard beats path", []string{"/api/", "*:view"}, "GET", "/api/collections/posts/records/abc", false, "*:view"},
+		{"other collection", []string{"tags:list"}, "GET", "/api/collections/posts/records", false, ""},
+		{"specific auth action beats auth", []string{"*:auth", "*:authWithPassword"}, "POST", "/api/collections/users/auth-with-password", false, "*:authWithPassword"},
+		{"specific wildcard action beats collection auth", []string{"*:authWithPassword", "users:auth"}, "POST", "/api/collections/users/auth-with-password", false, "*:authWithPassword"},
+		{"auth falls back", []string{"users:auth"}, "POST", "/api/collections/users/auth-with-otp", false, "users:auth"},
+		{"kebab action", []string{"users:requestPasswordReset"}, "POST", "/api/collections/users/request-password-reset", false, "users:requestPasswordReset"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules := make([]services.RateLimitRule, len(c.labels))
+
+			for i, label := range c.labels {
+				rules[i] = services.RateLimitRule{Label: label, Duration: 1, MaxRequests: 1}
+			}
+
+			rule, ok := services.NewRateLimiter(rules...).MatchRule(c.method, c.path, c.authenticated)
+
+			if c.expected == "" {
+				if ok {
+					t.Fatalf("matched %q, expected no rule", rule.Label)
+				}
+
+				return
+			}
+
+			if !ok || rule.Label != c.expected {
+				t.Fatalf("matched %q (%t), expected %q", rule.Label, ok, c.expected)
+			}
+		})
+	}
+}
+
+func TestRateLimitAudiences(t *testing.T) {
+	limiter := services.NewRateLimiter(
+		services.RateLimitRule{Label: "posts:list", Audience: services.RateLimitAudienceAll, Duration: 1, MaxRequests: 1},
+		services.RateLimitRule{Label: "posts:list", Audience: services.RateLimitAudienceGuest, Duration: 1, MaxRequests: 2},
+		services.RateLimitRule{Label: "/api/", Audience: services.RateLimitAudienceAuth, Duration: 1, MaxRequests: 3},
+	)
+
+	cases := []struct {
+		authenticated bool
+		path          string
+		expected      int
+	}{
+		{false, "/api/collections/posts/records", 2},
+		{true, "/api/collections/posts/records", 1},
+		{true, "/api/settings", 3},
+		{false, "/api/settings", 0},
+	}
+
+	for _, c := range cases {
+		rule, _ := limiter.MatchRule("GET", c.path, c.authenticated)
+
+		if rule.MaxRequests != c.expected {
+			t.Errorf("%s authenticated %t matched %v, expected the rule allowing %d", c.path, c.authenticated, rule, c.expected)
+		}
+	}
+}
+
+func TestRateLimitWaitUsesOneBucketPerRule(t *testing.T) {
+	limiter := services.NewRateLimiter(
+		services.RateLimitRule{Label: "posts:create", Duration: 60, MaxRequests: 2},
+		services.RateLimitRule{Label: "*:create", Duration: 60, MaxRequests: 1},
+	)
+
+	ctx := context.Background()
+	create := func(ctx context.Context, collection string) error {
+		return limiter.Wait(ctx, "POST", "/api/collections/"+collection+"/records", false)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := create(ctx, "posts"); err != nil {
+			t.Fatalf("request %d waited: %v", i, err)
+		}
+	}
+
+	//posts:create is empty, tags:create only uses the wildcard bucket
+	if err := create(ctx, "tags"); err != nil {
+		t.Fatalf("wildcard bucket shared with posts:create: %v", err)
+	}
+
+	blocked, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+
+	if err := create(blocked, "posts"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the empty bucket to wait until the deadline, got %v", err)
+	}
+
+	if elapsed := time.Since(started); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("waited %s", elapsed)
+	}
+
+	if err := create(blocked, "comments"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the wildcard bucket to be empty, got %v", err)
+	}
+
+	if err := limiter.Wait(ctx, "GET", "/api/collections/posts/records", false); err != nil {
+		t.Fatalf("unmatched request waited: %v", err)
+	}
+}
+
+func TestRateLimitWaitRefills(t *testing.T) {
+	limiter := services.NewRateLimiter(services.RateLimitRule{Label: "/api/", Duration: 1, MaxRequests: 20})
+
+	for i := 0; i < 20; i++ {
+		if err := limiter.Wait(context.Background(), "GET", "/api/settings", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//One token comes back every 50ms
+	started := time.Now()
+
+	if err := limiter.Wait(context.Background(), "GET", "/api/settings", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(started); elapsed < 30*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("waited %s for a refill, expected about 50ms", elapsed)
+	}
+}
+
+func TestLoadRateLimiterFromSettingsUsesTheClient(t *testing.T) {
+	sent := []string{}
+	recorder := services.Middleware{BeforeSend: func(req *http.Request) error {
+		sent = append(sent, req.URL.Path)
+		return nil
+	}}
+
+	server, pb, token := pbtest.NewClient(t, services.WithMiddleware(recorder))
+	server.SetSettings(map[string]any{"rateLimits": map[string]any{
+		"enabled": true,
+		"rules":   []any{map[string]any{"label": "/api/", "duration": 1, "maxRequests": 5}},
+	}})
+
+	if err := pb.LoadRateLimiterFromSettings(token); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent) != 1 || sent[0] != "/api/settings" {
+		t.Fatalf("client middleware saw %v", sent)
+	}
+
+	if rules := pb.Client.RateLimiter.Rules(); len(rules) != 1 || rules[0].MaxRequests != 5 {
+		t.Fatalf("loaded %v", rules)
+	}
+}
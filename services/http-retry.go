@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Controls how failed requests are retried, a nil policy never retries
+//
+// Statuses in RetryStatuses and network errors are only retried for idempotent methods since the server
+// may have already handled the request, statuses in RetryWriteStatuses are retried for every method.
+// A Retry-After longer than MaxBackoff is not waited for, the response is returned to the caller instead.
+type RetryPolicy struct {
+	MaxRetries         int
+	MinBackoff         time.Duration
+	MaxBackoff         time.Duration
+	RetryStatuses      []int
+	RetryWriteStatuses []int
+}
+
+// Retries 429 for every method as the rate limiter rejects requests before they are handled, 503 only for idempotent methods
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxRetries:         3,
+		MinBackoff:         200 * time.Millisecond,
+		MaxBackoff:         5 * time.Second,
+		RetryStatuses:      []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		RetryWriteStatuses: []int{http.StatusTooManyRequests},
+	}
+}
+
+// Returns how long to wait before retrying the attempt, false when it should not be retried
+func (policy *RetryPolicy) retryDelay(req *http.Request, res *http.Response, err error, attempt int) (time.Duration, bool) {
+	if policy == nil || attempt >= policy.MaxRetries {
+		return 0, false
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || !isIdempotent(req.Method) {
+			return 0, false
+		}
+
+		return policy.backoff(attempt), true
+	}
+
+	if res == nil {
+		return 0, false
+	}
+
+	statuses := policy.RetryWriteStatuses
+
+	if isIdempotent(req.Method) {
+		statuses = append(slices.Clone(policy.RetryStatuses), statuses...)
+	}
+
+	if !slices.Contains(statuses, res.StatusCode) {
+		return 0, false
+	}
+
+	if seconds, parseErr := strconv.Atoi(res.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+		delay := time.Duration(seconds) * time.Second
+
+		//A server asking for a day would otherwise stall the call for a day
+		if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+			return 0, false
+		}
+
+		return delay, true
+	}
+
+	return policy.backoff(attempt), true
+}
+
+// Exponential backoff with full jitter between MinBackoff and MaxBackoff
+func (policy *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.MinBackoff << attempt
+
+	if policy.MaxBackoff > 0 && (delay > policy.MaxBackoff || delay <= 0) {
+		delay = policy.MaxBackoff
+	}
+
+	if delay <= policy.MinBackoff {
+		return policy.MinBackoff
+	}
+
+	return policy.MinBackoff + time.Duration(rand.Int63n(int64(delay-policy.MinBackoff)))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// Returns a copy of the request with a fresh body so it can be sent again
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("request-body-not-rewindable")
+	}
+
+	body, err := req.GetBody()
+
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Body = body
+
+	return retry, nil
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
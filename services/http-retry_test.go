@@ -0,0 +1,102 @@
+package services_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// Answers every request with the status until it was called failures times, then with an empty list
+func flakyServer(t *testing.T, status int, failures int32, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	calls := &atomic.Int32{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+
+			w.WriteHeader(status)
+			w.Write([]byte(`{"status":0,"message":"failed","data":{}}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"page":1,"perPage":30,"totalItems":0,"totalPages":0,"items":[],"id":"abc"}`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, calls
+}
+
+func retryClient(t *testing.T, url string) *services.Pocketbase {
+	t.Helper()
+
+	policy := services.DefaultRetryPolicy()
+	policy.MinBackoff = time.Millisecond
+	policy.MaxBackoff = 10 * time.Millisecond
+
+	pb, err := services.New(url, services.WithRetryPolicy(policy))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pb
+}
+
+func TestRetryIdempotentOn503(t *testing.T) {
+	server, calls := flakyServer(t, http.StatusServiceUnavailable, 2, "")
+
+	if _, err := retryClient(t, server.URL).Record.ListRecords("posts", "", services.PocketBaseListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls.Load() != 3 {
+		t.Fatalf("made %d calls, expected 3", calls.Load())
+	}
+}
+
+func TestNoRetryForWritesOn503(t *testing.T) {
+	server, calls := flakyServer(t, http.StatusServiceUnavailable, 1, "")
+
+	if _, err := retryClient(t, server.URL).Record.CreateNewRecord("posts", "", map[string]any{"title": "x"}); err == nil {
+		t.Fatal("expected the 503 to be returned")
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("made %d calls, a POST must not be retried on 503", calls.Load())
+	}
+}
+
+func TestRetryWritesOn429(t *testing.T) {
+	server, calls := flakyServer(t, http.StatusTooManyRequests, 1, "")
+
+	if _, err := retryClient(t, server.URL).Record.CreateNewRecord("posts", "", map[string]any{"title": "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls.Load() != 2 {
+		t.Fatalf("made %d calls, expected 2", calls.Load())
+	}
+}
+
+func TestRetryAfterAboveMaxBackoffIsNotWaitedFor(t *testing.T) {
+	server, calls := flakyServer(t, http.StatusTooManyRequests, 1, "86400")
+	started := time.Now()
+
+	if _, err := retryClient(t, server.URL).Record.ListRecords("posts", "", services.PocketBaseListOptions{}); err == nil {
+		t.Fatal("expected the 429 to be returned")
+	}
+
+	if calls.Load() != 1 || time.Since(started) > time.Second {
+		t.Fatalf("made %d calls in %s", calls.Load(), time.Since(started))
+	}
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Reads a list response one item at a time so large pages are never held in memory at once
+//
+// Next returns io.EOF after the last item, Meta is complete from then on. With useNumber numbers are
+// decoded as json.Number so big integers keep their precision.
+type ListDecoder struct {
+	decoder  *json.Decoder
+	meta     PocketBaseListResponse
+	started  bool
+	inItems  bool
+	finished bool
+}
+
+func NewListDecoder(reader io.Reader, useNumber bool) *ListDecoder {
+	decoder := json.NewDecoder(reader)
+
+	if useNumber {
+		decoder.UseNumber()
+	}
+
+	return &ListDecoder{decoder: decoder}
+}
+
+// Page, counts and paging of the response without the items
+func (d *ListDecoder) Meta() PocketBaseListResponse {
+	return d.meta
+}
+
+func (d *ListDecoder) Next() (map[string]any, error) {
+	if d.finished {
+		return nil, io.EOF
+	}
+
+	if !d.started {
+		if err := d.expect(json.Delim('{')); err != nil {
+			return nil, err
+		}
+
+		d.started = true
+	}
+
+	for {
+		if d.inItems {
+			if d.decoder.More() {
+				item := map[string]any{}
+
+				if err := d.decoder.Decode(&item); err != nil {
+					return nil, fmt.Errorf("invalid-list-response|%w", err)
+				}
+
+				return item, nil
+			}
+
+			if err := d.expect(json.Delim(']')); err != nil {
+				return nil, err
+			}
+
+			d.inItems = false
+		}
+
+		token, err := d.decoder.Token()
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid-list-response|%w", err)
+		}
+
+		if token == json.Delim('}') {
+			d.finished = true
+			return nil, io.EOF
+		}
+
+		if err := d.field(token); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (d *ListDecoder) field(key json.Token) error {
+	var target any
+
+	switch key {
+	case "items":
+		token, err := d.decoder.Token()
+
+		if err != nil {
+			return fmt.Errorf("invalid-list-response|%w", err)
+		}
+
+		//A null items list has nothing to read
+		d.inItems = token == json.Delim('[')
+
+		if !d.inItems && token != nil {
+			return errors.New("invalid-list-response|items is not a list")
+		}
+
+		return nil
+	case "page":
+		target = &d.meta.Page
+	case "perPage":
+		target = &d.meta.PerPage
+	case "totalItems":
+		target = &d.meta.TotalItems
+	case "totalPages":
+		target = &d.meta.TotalPages
+	default:
+		target = &json.RawMessage{}
+	}
+
+	if err := d.decoder.Decode(target); err != nil {
+		return fmt.Errorf("invalid-list-response|%w", err)
+	}
+
+	return nil
+}
+
+func (d *ListDecoder) expect(delim json.Delim) error {
+	token, err := d.decoder.Token()
+
+	if err != nil {
+		return fmt.Errorf("invalid-list-response|%w", err)
+	}
+
+	if token != delim {
+		return fmt.Errorf("invalid-list-response|expected %s, found %v", delim, token)
+	}
+
+	return nil
+}
+
+// Calls fn with every item of the list response, stops at the first error of fn or the decoder
+func DecodeListStream(reader io.Reader, useNumber bool, fn func(item map[string]any) error) (PocketBaseListResponse, error) {
+	decoder := NewListDecoder(reader, useNumber)
+
+	for {
+		item, err := decoder.Next()
+
+		//Only the EOF of Next itself ends the list, a wrapped one comes from a truncated body
+		if err == io.EOF {
+			return decoder.Meta(), nil
+		}
+
+		if err != nil {
+			return decoder.Meta(), err
+		}
+
+		if err := fn(item); err != nil {
+			return decoder.Meta(), err
+		}
+	}
+}
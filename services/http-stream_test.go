@@ -0,0 +1,152 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/services"
+)
+
+// Decodes the body into its items, fn errors are never returned
+func streamItems(body string, useNumber bool) ([]map[string]any, services.PocketBaseListResponse, error) {
+	items := []map[string]any{}
+
+	meta, err := services.DecodeListStream(strings.NewReader(body), useNumber, func(item map[string]any) error {
+		items = append(items, item)
+		return nil
+	})
+
+	return items, meta, err
+}
+
+func TestListStreamReadsMetadataAroundTheItems(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		items int
+	}{
+		{"metadata first", `{"page":2,"perPage":3,"totalItems":7,"totalPages":3,"items":[{"id":"a"},{"id":"b"},{"id":"c"}]}`, 3},
+		{"metadata last", `{"items":[{"id":"a"},{"id":"b"},{"id":"c"}],"page":2,"perPage":3,"totalItems":7,"totalPages":3}`, 3},
+		{"metadata around", `{"page":2,"perPage":3,"items":[{"id":"a"}],"extra":{"nested":[1,2]},"totalItems":7,"totalPages":3}`, 1},
+		{"empty items", `{"page":2,"perPage":3,"totalItems":7,"totalPages":3,"items":[]}`, 0},
+		{"null items", `{"page":2,"perPage":3,"totalItems":7,"totalPages":3,"items":null}`, 0},
+		{"no items", `{"page":2,"perPage":3,"totalItems":7,"totalPages":3}`, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			items, meta, err := streamItems(c.body, false)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(items) != c.items {
+				t.Fatalf("decoded %d items, expected %d", len(items), c.items)
+			}
+
+			if meta.Page != 2 || meta.PerPage != 3 || meta.TotalItems != 7 || meta.TotalPages != 3 {
+				t.Fatalf("meta = %+v", meta)
+			}
+		})
+	}
+}
+
+func TestListStreamReturnsMalformedBodies(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		items int
+	}{
+		{"empty body", ``, 0},
+		{"truncated before the items", `{"page":1,"perPage":3,`, 0},
+		{"truncated between items", `{"page":1,"items":[{"id":"a"},`, 1},
+		{"truncated inside an item", `{"page":1,"items":[{"id":"a"},{"id":"b","title":"hel`, 1},
+		{"truncated after the items", `{"page":1,"items":[{"id":"a"}]`, 1},
+		{"invalid item", `{"items":[{"id":"a"},{"id":"b","views":12x},{"id":"c"}],"page":1}`, 1},
+		{"item is not an object", `{"items":[{"id":"a"},"b"],"page":1}`, 1},
+		{"items is not a list", `{"items":{"id":"a"}}`, 0},
+		{"not an object", `[{"id":"a"}]`, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			items, _, err := streamItems(c.body, false)
+
+			if err == nil || !strings.HasPrefix(err.Error(), "invalid-list-response") {
+				t.Fatalf("expected invalid-list-response, got %v", err)
+			}
+
+			if len(items) != c.items {
+				t.Fatalf("decoded %d items before the error, expected %d", len(items), c.items)
+			}
+		})
+	}
+}
+
+func TestListStreamStopsAtTheCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	seen := 0
+
+	_, err := services.DecodeListStream(strings.NewReader(`{"items":[{"id":"a"},{"id":"b"},{"id":"c"}]}`), false, func(item map[string]any) error {
+		seen++
+
+		if item["id"] == "b" {
+			return stop
+		}
+
+		return nil
+	})
+
+	if !errors.Is(err, stop) || seen != 2 {
+		t.Fatalf("saw %d items, %v", seen, err)
+	}
+}
+
+func TestListDecoderKeepsBigNumbers(t *testing.T) {
+	decoder := services.NewListDecoder(strings.NewReader(`{"items":[{"n":9007199254740993}],"totalItems":1}`), true)
+
+	item, err := decoder.Next()
+
+	if err != nil || item["n"] != json.Number("9007199254740993") {
+		t.Fatalf("item = %v, %v", item, err)
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF after the last item, got %v", err)
+	}
+
+	if decoder.Meta().TotalItems != 1 {
+		t.Fatalf("meta = %+v", decoder.Meta())
+	}
+
+	if _, err := decoder.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF once finished, got %v", err)
+	}
+}
+
+func TestListRecordsReturnsTruncatedPagesAsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"page":1,"perPage":3,"items":[{"id":"a"},{"id":"b","title":"hel`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	pb, err := services.New(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{})
+
+	if err == nil {
+		t.Fatalf("expected an error instead of a page of %d items", len(page.Items))
+	}
+}
@@ -2,11 +2,78 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
+// Shared request pipeline used by every service, a nil client sends requests without any extras
+type PBClient struct {
+	BaseURL     string
+	HTTPClient  *http.Client
+	AuthStore   AuthStore
+	RateLimiter *RateLimiter
+	RetryPolicy *RetryPolicy
+	Middleware  []Middleware
+	Logger      *slog.Logger
+	DebugWriter io.Writer
+	Cache       *ResponseCache
+
+	//Default headers added to every request that does not set them itself
+	Headers map[string]string
+
+	batch *batchSupport
+	ctx   context.Context
+}
+
+// Hooks that run around every request sent through a client, either hook can be left nil
+//
+// BeforeSend hooks run in order before the request is sent, returning an error aborts the request.
+// AfterSend hooks run in reverse order with the response or error and can replace both.
+type Middleware struct {
+	BeforeSend func(req *http.Request) error
+	AfterSend  func(req *http.Request, res *http.Response, err error) (*http.Response, error)
+}
+
+// Returns a copy of the client with the middleware appended to its chain, used for per call middleware
+func (client *PBClient) With(middleware ...Middleware) *PBClient {
+	clone := PBClient{}
+
+	if client != nil {
+		clone = *client
+	}
+
+	clone.Middleware = append(append([]Middleware{}, clone.Middleware...), middleware...)
+
+	return &clone
+}
+
+// Returns a copy of the client that creates every request with the context, cancelling it aborts them
+func (client *PBClient) WithContext(ctx context.Context) *PBClient {
+	clone := client.With()
+	clone.ctx = ctx
+
+	return clone
+}
+
+func (client *PBClient) context() context.Context {
+	if client == nil || client.ctx == nil {
+		return context.Background()
+	}
+
+	return client.ctx
+}
+
+// Appends middleware to the chain of the client
+func (client *PBClient) Use(middleware ...Middleware) {
+	client.Middleware = append(client.Middleware, middleware...)
+}
+
 // Check if expand or fields args have values and construct a url query including it
 func AddExpandAndFieldsToURL(expand string, fields string) string {
 	query := ""
@@ -26,18 +93,29 @@ func AddExpandAndFieldsToURL(expand string, fields string) string {
 
 // Sends an HTTP request to the provided url
 func SendHTTPRequest(method string, url string, headers map[string]string, options map[string]any) (http.Response, error) {
+	var client *PBClient
+	return client.SendHTTPRequest(method, url, headers, options)
+}
+
+func SendAuthenticatedHTTPRequest(method string, url string, headers map[string]string, options map[string]any, token string) (http.Response, error) {
+	var client *PBClient
+	return client.SendAuthenticatedHTTPRequest(method, url, headers, options, token)
+}
+
+// Sends an HTTP request to the provided url through the client pipeline
+func (client *PBClient) SendHTTPRequest(method string, url string, headers map[string]string, options map[string]any) (http.Response, error) {
 
 	//Marshal the provided into JSON for the body of the request.
 	body, err := json.Marshal(options)
 	if err != nil {
-		fmt.Println(err)
+		client.logError("failed to encode request body", err)
 		return http.Response{}, err
 	}
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(client.context(), method, url, bytes.NewBuffer(body))
 
 	if err != nil {
-		fmt.Println(err)
+		client.logError("failed to create request", err)
 		return http.Response{}, err
 	}
 
@@ -48,24 +126,173 @@ func SendHTTPRequest(method string, url string, headers map[string]string, optio
 		req.Header.Set(k, v)
 	}
 
-	httpClient := &http.Client{}
+	return client.Do(req)
+}
+
+// Sends a multipart body with the token, an empty token falls back to the token in the auth store of the client
+func (client *PBClient) SendMultipartRequest(method string, url string, headers map[string]string, body *bytes.Buffer, contentType string, token string) (http.Response, error) {
+	req, err := http.NewRequestWithContext(client.context(), method, url, body)
+
+	if err != nil {
+		client.logError("failed to create request", err)
+		return http.Response{}, err
+	}
+
+	if token == "" && client != nil && client.AuthStore != nil {
+		token = client.AuthStore.Token()
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", token)
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	return client.Do(req)
+}
+
+// Runs a prepared request through the middleware chain, rate limiter and retry policy, every request type is sent through here
+func (client *PBClient) Do(req *http.Request) (http.Response, error) {
+	middleware := []Middleware{}
+
+	if client != nil {
+		middleware = client.Middleware
+
+		for k, v := range client.Headers {
+			if req.Header.Get(k) == "" {
+				req.Header.Set(k, v)
+			}
+		}
+	}
+
+	for _, m := range middleware {
+		if m.BeforeSend == nil {
+			continue
+		}
+
+		if err := m.BeforeSend(req); err != nil {
+			return http.Response{}, err
+		}
+	}
+
+	started := time.Now()
+	retries := 0
+
+	var cache *ResponseCache
+
+	if client != nil {
+		cache = client.Cache
+	}
+
+	var err error
+
+	resp, cached := cache.lookup(req)
+
+	for !cached {
+		resp, err = client.send(req)
+
+		var policy *RetryPolicy
+
+		if client != nil {
+			policy = client.RetryPolicy
+		}
+
+		delay, retry := policy.retryDelay(req, resp, err, retries)
+
+		if !retry {
+			break
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if sleepErr := sleepContext(req.Context(), delay); sleepErr != nil {
+			resp, err = nil, sleepErr
+			break
+		}
+
+		if req, err = rewindRequest(req); err != nil {
+			resp = nil
+			break
+		}
+
+		retries++
+	}
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		if middleware[i].AfterSend == nil {
+			continue
+		}
+
+		resp, err = middleware[i].AfterSend(req, resp, err)
+	}
+
+	if !cached {
+		resp = cache.store(req, resp, err)
+	}
+
+	client.logRequest(req, resp, err, started, retries)
 
-	resp, err := httpClient.Do(req)
 	if err != nil {
-		fmt.Println(err)
 		return http.Response{}, err
 	}
 
+	if resp == nil {
+		return http.Response{}, errors.New("missing-response")
+	}
+
 	if resp.StatusCode == 429 {
-		return http.Response{}, fmt.Errorf("request-limit-reached|%s", url)
+		resp.Body.Close()
+		return http.Response{}, fmt.Errorf("request-limit-reached|%s", req.URL.String())
 	}
 
 	return *resp, nil
 }
 
-func SendAuthenticatedHTTPRequest(method string, url string, headers map[string]string, options map[string]any, token string) (http.Response, error) {
+// Sends a single attempt of the request, waiting for the rate limiter first
+func (client *PBClient) send(req *http.Request) (*http.Response, error) {
+	//Wait after the hooks so rewritten urls and added auth headers pick the right rule
+	if client != nil && client.RateLimiter != nil {
+		err := client.RateLimiter.Wait(req.Context(), req.Method, req.URL.Path, req.Header.Get("Authorization") != "")
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var trace *requestTrace
+
+	if client != nil && client.DebugWriter != nil {
+		req, trace = traceRequest(req)
+	}
+
+	httpClient := &http.Client{}
+
+	if client != nil && client.HTTPClient != nil {
+		httpClient = client.HTTPClient
+	}
+
+	resp, err := httpClient.Do(req)
+
+	if trace != nil {
+		client.writeDebug(req, resp, err, trace)
+	}
+
+	return resp, err
+}
+
+// Sends the request with the token, an empty token falls back to the token in the auth store of the client
+func (client *PBClient) SendAuthenticatedHTTPRequest(method string, url string, headers map[string]string, options map[string]any, token string) (http.Response, error) {
+	if token == "" && client != nil && client.AuthStore != nil {
+		token = client.AuthStore.Token()
+	}
+
 	headers["Authorization"] = token
-	return SendHTTPRequest(method, url, headers, options)
+	return client.SendHTTPRequest(method, url, headers, options)
 }
 
 func DecodePocketBaseRecord(response http.Response) map[string]any {
@@ -77,12 +304,18 @@ func DecodePocketBaseRecord(response http.Response) map[string]any {
 	return record
 }
 
+// Decodes the whole list ignoring decode errors, use DecodeListStream to see them
 func DecodePocketBaseListResponse(response http.Response) PocketBaseListResponse {
-	record := PocketBaseListResponse{}
-
 	defer response.Body.Close()
 
-	json.NewDecoder(response.Body).Decode(&record)
+	items := []map[string]any{}
+
+	record, _ := DecodeListStream(response.Body, false, func(item map[string]any) error {
+		items = append(items, item)
+		return nil
+	})
+
+	record.Items = items
 	return record
 }
 
@@ -50,14 +50,32 @@ type AuthSuccessResponse struct {
 }
 
 type PBAuth struct {
-	BaseURL   string `json:"baseURL"`
-	AuthToken string `json:"authToken"`
+	BaseURL   string    `json:"baseURL"`
+	AuthToken string    `json:"authToken"`
+	Client    *PBClient `json:"-"`
+}
+
+// Returns a copy of the service that runs the extra middleware on every request made through it
+// Without an auth store tokens from authentications made through the copy are only stored on the copy
+func (auth *PBAuth) WithMiddleware(middleware ...Middleware) AuthService {
+	clone := *auth
+	clone.Client = auth.Client.With(middleware...)
+	return &clone
+}
+
+// Returns the token of the last successful authentication, read from the auth store when the client has one
+func (auth *PBAuth) Token() string {
+	if auth.Client != nil && auth.Client.AuthStore != nil {
+		return auth.Client.AuthStore.Token()
+	}
+
+	return auth.AuthToken
 }
 
 func (auth *PBAuth) GetPBCollectionsAuthMethods(collection string, fields string) (AuthMethodResponse, error) {
 	apiURL := fmt.Sprintf("%s/api/collections/%s/auth-methods/?fields=%s", auth.BaseURL, collection, fields)
 
-	res, err := SendHTTPRequest("GET", apiURL, map[string]string{}, map[string]any{})
+	res, err := auth.Client.SendHTTPRequest("GET", apiURL, map[string]string{}, map[string]any{})
 
 	if err != nil {
 		return AuthMethodResponse{}, err
@@ -86,7 +104,7 @@ func (auth *PBAuth) AuthWithPasswordForCollection(collection string, expand stri
 		"password": password,
 	}
 
-	res, err := SendHTTPRequest("POST", urlBase, map[string]string{}, body)
+	res, err := auth.Client.SendHTTPRequest("POST", urlBase, map[string]string{}, body)
 
 	if err != nil {
 		return AuthSuccessResponse{}, err
@@ -103,6 +121,7 @@ func (auth *PBAuth) AuthWithPasswordForCollection(collection string, expand stri
 		json.NewDecoder(res.Body).Decode(&authSuccessResponse)
 
 		auth.AuthToken = authSuccessResponse.Token
+		auth.Client.saveAuth(authSuccessResponse)
 
 		return authSuccessResponse, nil
 	case http.StatusBadRequest, http.StatusNotFound:
@@ -116,14 +135,19 @@ func (auth *PBAuth) AuthWithPasswordForCollection(collection string, expand stri
 	return AuthSuccessResponse{}, errors.New("unknown-response")
 }
 
+// Refreshes the token, an empty token refreshes the current one
 func (auth *PBAuth) RefreshAuth(collection string, token string) (AuthSuccessResponse, error) {
+	if token == "" {
+		token = auth.Token()
+	}
+
 	headers := map[string]string{
 		"Authorization": fmt.Sprintf("%s", token),
 	}
 
 	apiURL := fmt.Sprintf("%s/api/collections/%s/auth-refresh", auth.BaseURL, collection)
 
-	res, err := SendHTTPRequest("POST", apiURL, headers, map[string]any{})
+	res, err := auth.Client.SendHTTPRequest("POST", apiURL, headers, map[string]any{})
 
 	if err != nil {
 		return AuthSuccessResponse{}, err
@@ -137,6 +161,9 @@ func (auth *PBAuth) RefreshAuth(collection string, token string) (AuthSuccessRes
 		authRefreshSuccess := AuthSuccessResponse{}
 		json.NewDecoder(res.Body).Decode(&authRefreshSuccess)
 
+		auth.AuthToken = authRefreshSuccess.Token
+		auth.Client.saveAuth(authRefreshSuccess)
+
 		return authRefreshSuccess, nil
 	}
 
@@ -0,0 +1,63 @@
+package services
+
+import "sync"
+
+// Holds the current auth token and record, services save successful authentications into it
+// and authenticated requests made with an empty token use the stored one
+type AuthStore interface {
+	Token() string
+	Record() map[string]any
+	Save(token string, record map[string]any)
+	Clear()
+}
+
+// Default in memory auth store, safe for concurrent use
+type MemoryAuthStore struct {
+	mu     sync.RWMutex
+	token  string
+	record map[string]any
+}
+
+var _ AuthStore = (*MemoryAuthStore)(nil)
+
+func NewMemoryAuthStore() *MemoryAuthStore {
+	return &MemoryAuthStore{}
+}
+
+func (store *MemoryAuthStore) Token() string {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.token
+}
+
+func (store *MemoryAuthStore) Record() map[string]any {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	return store.record
+}
+
+func (store *MemoryAuthStore) Save(token string, record map[string]any) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.token = token
+	store.record = record
+}
+
+func (store *MemoryAuthStore) Clear() {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.token = ""
+	store.record = nil
+}
+
+func (client *PBClient) saveAuth(auth AuthSuccessResponse) {
+	if client == nil || client.AuthStore == nil {
+		return
+	}
+
+	client.AuthStore.Save(auth.Token, auth.Record)
+}
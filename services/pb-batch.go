@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A single request of a batch, URL is relative to the instance like /api/collections/posts/records
+type BatchRequest struct {
+	Method string         `json:"method"`
+	URL    string         `json:"url"`
+	Body   map[string]any `json:"body,omitempty"`
+}
+
+type BatchResult struct {
+	Status int            `json:"status"`
+	Body   map[string]any `json:"body"`
+}
+
+// How long a rejected batch keeps fallbacks on single requests before batches are tried again
+const BATCH_UNSUPPORTED_TTL = 10 * time.Minute
+
+var ErrBatchUnsupported = errors.New("batch-unsupported")
+
+// Remembers per client that the instance rejected a batch, so fallbacks skip straight to single requests
+type batchSupport struct {
+	mu         sync.Mutex
+	rejectedAt time.Time
+}
+
+func (support *batchSupport) supported() bool {
+	if support == nil {
+		return true
+	}
+
+	support.mu.Lock()
+	defer support.mu.Unlock()
+
+	return support.rejectedAt.IsZero() || time.Since(support.rejectedAt) > BATCH_UNSUPPORTED_TTL
+}
+
+func (support *batchSupport) reject() {
+	if support == nil {
+		return
+	}
+
+	support.mu.Lock()
+	defer support.mu.Unlock()
+
+	support.rejectedAt = time.Now()
+}
+
+func BatchCreate(collection string, data map[string]any) BatchRequest {
+	return BatchRequest{Method: "POST", URL: fmt.Sprintf("/api/collections/%s/records", collection), Body: data}
+}
+
+func BatchUpdate(collection string, recordId string, data map[string]any) BatchRequest {
+	return BatchRequest{Method: "PATCH", URL: fmt.Sprintf("/api/collections/%s/records/%s", collection, recordId), Body: data}
+}
+
+// Creates the record when the id in data is unknown and updates it otherwise
+func BatchUpsert(collection string, data map[string]any) BatchRequest {
+	return BatchRequest{Method: "PUT", URL: fmt.Sprintf("/api/collections/%s/records", collection), Body: data}
+}
+
+func BatchDelete(collection string, recordId string) BatchRequest {
+	return BatchRequest{Method: "DELETE", URL: fmt.Sprintf("/api/collections/%s/records/%s", collection, recordId)}
+}
+
+// Whether batches are worth trying, false for BATCH_UNSUPPORTED_TTL after the instance rejected one
+func (record *PBRecord) BatchSupported() bool {
+	return record.Client == nil || record.Client.batch.supported()
+}
+
+// Sends the requests as a single transaction, every request fails when one does
+//
+// ErrBatchUnsupported is returned when batches are disabled on the instance, failed requests return a *ResponseError.
+func (record *PBRecord) Batch(token string, requests []BatchRequest) ([]BatchResult, error) {
+	if !record.BatchSupported() {
+		return nil, ErrBatchUnsupported
+	}
+
+	apiUrl := fmt.Sprintf("%s/api/batch", record.BaseURL)
+
+	body := map[string]any{"requests": requests}
+
+	res, err := record.Client.SendAuthenticatedHTTPRequest("POST", apiUrl, map[string]string{}, body, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		resErr := NewResponseError(res)
+
+		//Disabled batches are rejected with 403, instances without the endpoint with 404
+		if resErr.Status == http.StatusForbidden || resErr.Status == http.StatusNotFound {
+			if record.Client != nil {
+				record.Client.batch.reject()
+			}
+
+			return nil, ErrBatchUnsupported
+		}
+
+		return nil, resErr
+	}
+
+	results := []BatchResult{}
+
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	DEFAULT_BULK_WORKERS  = 4
+	DEFAULT_BULK_PER_PAGE = 500
+)
+
+// Options for UpdateWhere and DeleteWhere
+//
+// BatchSize above zero sends the changes in batch chunks when the instance supports batches, a chunk that fails is
+// retried record by record so the report names the failing records. Otherwise Workers requests run at once.
+type BulkOptions struct {
+	Workers   int
+	BatchSize int
+	PerPage   int
+	DryRun    bool
+	Progress  func(progress BulkProgress)
+}
+
+type BulkProgress struct {
+	Total     int
+	Done      int
+	Failed    int
+	LastError error
+}
+
+// Outcome of a bulk change, Ids holds every matched record and Errors the records that failed by id
+type BulkResult struct {
+	Ids       []string
+	Succeeded []string
+	Errors    map[string]error
+}
+
+func (result BulkResult) Failed() bool {
+	return len(result.Errors) > 0
+}
+
+// Updates every record matching the filter, a dry run only returns the ids that would be updated
+func (record *PBRecord) UpdateWhere(collection string, token string, matchFilter string, data map[string]any, options BulkOptions) (BulkResult, error) {
+	return record.bulk(collection, token, matchFilter, options, func(id string) BatchRequest {
+		return BatchUpdate(collection, id, data)
+	})
+}
+
+// Deletes every record matching the filter, a dry run only returns the ids that would be deleted
+func (record *PBRecord) DeleteWhere(collection string, token string, matchFilter string, options BulkOptions) (BulkResult, error) {
+	return record.bulk(collection, token, matchFilter, options, func(id string) BatchRequest {
+		return BatchDelete(collection, id)
+	})
+}
+
+func (record *PBRecord) bulk(collection string, token string, matchFilter string, options BulkOptions, request func(id string) BatchRequest) (BulkResult, error) {
+	ids, err := record.matchingIds(collection, token, matchFilter, options.PerPage)
+
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	result := BulkResult{Ids: ids, Succeeded: []string{}, Errors: map[string]error{}}
+
+	if options.DryRun || len(ids) == 0 {
+		return result, nil
+	}
+
+	tracker := &bulkTracker{result: &result, progress: BulkProgress{Total: len(ids)}, report: options.Progress}
+
+	if options.BatchSize > 0 && record.BatchSupported() {
+		remaining := record.bulkBatches(token, ids, options.BatchSize, request, tracker)
+
+		if len(remaining) == 0 {
+			return result, nil
+		}
+
+		ids = remaining
+	}
+
+	record.bulkWorkers(token, ids, max(options.Workers, 1), request, tracker)
+
+	return result, nil
+}
+
+// Collects every matching id before anything changes so updates and deletes never shift the pages being read
+func (record *PBRecord) matchingIds(collection string, token string, matchFilter string, perPage int) ([]string, error) {
+	if perPage <= 0 {
+		perPage = DEFAULT_BULK_PER_PAGE
+	}
+
+	//A short page only ends the read when it is shorter than what the server actually sends
+	perPage = min(perPage, MAX_LIST_PER_PAGE)
+
+	ids := []string{}
+
+	for page := 1; ; page++ {
+		res, err := record.uncached().ListRecords(collection, token, PocketBaseListOptions{
+			Page:      page,
+			PerPage:   perPage,
+			Filter:    matchFilter,
+			Sort:      "id",
+			Fields:    "id",
+			SkipTotal: true,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range res.Items {
+			if id, ok := item["id"].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+
+		if len(res.Items) < perPage {
+			return ids, nil
+		}
+	}
+}
+
+// Sends the ids in batch chunks, returns the ids that still have to be sent one by one
+func (record *PBRecord) bulkBatches(token string, ids []string, size int, request func(id string) BatchRequest, tracker *bulkTracker) []string {
+	remaining := []string{}
+
+	for start := 0; start < len(ids); start += size {
+		chunk := ids[start:min(start+size, len(ids))]
+		requests := make([]BatchRequest, len(chunk))
+
+		for i, id := range chunk {
+			requests[i] = request(id)
+		}
+
+		_, err := record.Batch(token, requests)
+
+		if errors.Is(err, ErrBatchUnsupported) {
+			return append(remaining, ids[start:]...)
+		}
+
+		if err != nil {
+			remaining = append(remaining, chunk...)
+			continue
+		}
+
+		for _, id := range chunk {
+			tracker.done(id, nil)
+		}
+	}
+
+	return remaining
+}
+
+func (record *PBRecord) bulkWorkers(token string, ids []string, workers int, request func(id string) BatchRequest, tracker *bulkTracker) {
+	queue := make(chan string)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(workers, len(ids)); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for id := range queue {
+				tracker.done(id, record.sendBatchRequest(token, request(id)))
+			}
+		}()
+	}
+
+	for _, id := range ids {
+		queue <- id
+	}
+
+	close(queue)
+	wg.Wait()
+}
+
+// Sends a single batch request on its own
+func (record *PBRecord) sendBatchRequest(token string, request BatchRequest) error {
+	apiUrl := fmt.Sprintf("%s%s", record.BaseURL, request.URL)
+
+	body := request.Body
+
+	if body == nil {
+		body = map[string]any{}
+	}
+
+	res, err := record.Client.SendAuthenticatedHTTPRequest(request.Method, apiUrl, map[string]string{}, body, token)
+
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return NewResponseError(res)
+	}
+
+	res.Body.Close()
+
+	return nil
+}
+
+type bulkTracker struct {
+	mu       sync.Mutex
+	result   *BulkResult
+	progress BulkProgress
+	report   func(progress BulkProgress)
+}
+
+func (tracker *bulkTracker) done(id string, err error) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	tracker.progress.Done++
+
+	if err != nil {
+		tracker.progress.Failed++
+		tracker.progress.LastError = err
+		tracker.result.Errors[id] = err
+	} else {
+		tracker.result.Succeeded = append(tracker.result.Succeeded, id)
+	}
+
+	if tracker.report != nil {
+		tracker.report(tracker.progress)
+	}
+}
@@ -0,0 +1,173 @@
+package services_test
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func addBulkPosts(t *testing.T, server *pbtest.Server, batch bool) []string {
+	t.Helper()
+
+	server.AddCollection(pbtest.Collection{Name: "posts", Fields: []map[string]any{
+		{"name": "views", "type": "number"},
+		{"name": "status", "type": "text"},
+	}})
+
+	if batch {
+		server.EnableBatch(0)
+	}
+
+	matching := []string{}
+
+	for i := 0; i < 12; i++ {
+		record := server.Seed("posts", map[string]any{"views": float64(i), "status": "draft"})[0]
+
+		//Matches views >= 9 || views < 3 && views != 0
+		if i >= 9 || i > 0 && i < 3 {
+			matching = append(matching, record["id"].(string))
+		}
+	}
+
+	slices.Sort(matching)
+
+	return matching
+}
+
+func statuses(server *pbtest.Server, status string) []string {
+	ids := []string{}
+
+	for _, record := range server.Records("posts") {
+		if record["status"] == status {
+			ids = append(ids, record["id"].(string))
+		}
+	}
+
+	slices.Sort(ids)
+
+	return ids
+}
+
+func TestUpdateWhereDryRunChangesNothing(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	matching := addBulkPosts(t, server, false)
+
+	result, err := pb.Record.UpdateWhere("posts", token, "views >= 9 || views < 3 && views != 0", map[string]any{"status": "live"}, services.BulkOptions{DryRun: true, PerPage: 2})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(result.Ids, matching) {
+		t.Fatalf("matched %v, expected %v", result.Ids, matching)
+	}
+
+	if live := statuses(server, "live"); len(live) != 0 {
+		t.Fatalf("dry run updated %v", live)
+	}
+}
+
+func TestUpdateWhereInBatches(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	matching := addBulkPosts(t, server, true)
+	progress := []int{}
+
+	result, err := pb.Record.UpdateWhere("posts", token, "views >= 9 || views < 3 && views != 0", map[string]any{"status": "live"}, services.BulkOptions{
+		BatchSize: 2,
+		Progress: func(p services.BulkProgress) {
+			progress = append(progress, p.Done)
+		},
+	})
+
+	if err != nil || result.Failed() {
+		t.Fatalf("update = %v, %v", result.Errors, err)
+	}
+
+	if live := statuses(server, "live"); !slices.Equal(live, matching) {
+		t.Fatalf("updated %v, expected %v", live, matching)
+	}
+
+	if len(progress) == 0 || progress[len(progress)-1] != len(matching) {
+		t.Fatalf("progress = %v", progress)
+	}
+}
+
+func TestDeleteWhereReportsFailedRecords(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	matching := addBulkPosts(t, server, false)
+	failing := matching[0]
+
+	server.Fail(pbtest.Failure{Method: "DELETE", Path: "/api/collections/posts/records/" + failing, Status: http.StatusBadRequest, Times: 1})
+
+	result, err := pb.Record.DeleteWhere("posts", token, "views >= 9 || views < 3 && views != 0", services.BulkOptions{Workers: 3})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Errors) != 1 || result.Errors[failing] == nil || len(result.Succeeded) != len(matching)-1 {
+		t.Fatalf("errors %v, %d succeeded", result.Errors, len(result.Succeeded))
+	}
+
+	if remaining := len(server.Records("posts")); remaining != 12-len(matching)+1 {
+		t.Fatalf("%d records left", remaining)
+	}
+}
+
+func TestUpdateWhereReadsEveryPageAboveThePerPageCap(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", Fields: []map[string]any{{"name": "views", "type": "number"}}})
+
+	for i := 0; i < services.MAX_LIST_PER_PAGE+5; i++ {
+		server.Seed("posts", map[string]any{"views": float64(i)})
+	}
+
+	result, err := pb.Record.UpdateWhere("posts", token, "views >= 0", map[string]any{"views": 0}, services.BulkOptions{PerPage: 5000, DryRun: true})
+
+	if err != nil || len(result.Ids) != services.MAX_LIST_PER_PAGE+5 {
+		t.Fatalf("matched %d ids, %v", len(result.Ids), err)
+	}
+}
+
+func TestUpdateWhereRetriesFailedBatchesOneByOne(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	matching := addBulkPosts(t, server, true)
+
+	server.Fail(pbtest.Failure{Method: "POST", Path: "/api/batch", Status: http.StatusBadRequest, Times: 1})
+
+	result, err := pb.Record.UpdateWhere("posts", token, "views >= 9 || views < 3 && views != 0", map[string]any{"status": "live"}, services.BulkOptions{BatchSize: 4})
+
+	if err != nil || result.Failed() {
+		t.Fatalf("update = %v, %v", result.Errors, err)
+	}
+
+	if live := statuses(server, "live"); !slices.Equal(live, matching) {
+		t.Fatalf("updated %v, expected %v", live, matching)
+	}
+
+	singles := 0
+
+	for _, request := range server.Requests() {
+		if request.Method == "PATCH" {
+			singles++
+		}
+	}
+
+	if singles != 4 {
+		t.Fatalf("sent %d single updates, expected the failed chunk of 4", singles)
+	}
+}
+
+func TestDeleteWhereWithoutMatches(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	addBulkPosts(t, server, false)
+
+	result, err := pb.Record.DeleteWhere("posts", token, "views > 100", services.BulkOptions{})
+
+	if err != nil || len(result.Ids) != 0 || len(server.Records("posts")) != 12 {
+		t.Fatalf("delete = %v, %v", result, err)
+	}
+}
@@ -114,21 +114,40 @@ type CollectionOptions struct {
 	ConfirmEmailChangeTemplate CollectionEmailTemplate `json:"confirmEmailChangeTemplate"`
 }
 
+// Collection as returned by the API, a nil rule is locked to superusers while an empty rule is public
 type PocketBaseCollectionResponse struct {
 	Id         string           `json:"id"`
 	Name       string           `json:"name"`
 	Type       string           `json:"type"`
 	Fields     []map[string]any `json:"fields"`
 	System     bool             `json:"system"`
-	ListRule   string           `json:"listRule"`
-	ViewRule   string           `json:"viewRule"`
-	CreateRule string           `json:"createRule"`
-	UpdateRule string           `json:"updateRule"`
-	DeleteRule string           `json:"deleteRule"`
+	ListRule   *string          `json:"listRule"`
+	ViewRule   *string          `json:"viewRule"`
+	CreateRule *string          `json:"createRule"`
+	UpdateRule *string          `json:"updateRule"`
+	DeleteRule *string          `json:"deleteRule"`
+	ViewQuery  string           `json:"viewQuery"`
+	Indexes    []string         `json:"indexes"`
+}
+
+type PocketBaseCollectionListResponse struct {
+	Page       int                            `json:"page"`
+	PerPage    int                            `json:"perPage"`
+	TotalItems int                            `json:"totalItems"`
+	TotalPages int                            `json:"totalPages"`
+	Items      []PocketBaseCollectionResponse `json:"items"`
 }
 
 type PBCollection struct {
 	BaseURL string
+	Client  *PBClient
+}
+
+// Returns a copy of the service that runs the extra middleware on every request made through it
+func (collection *PBCollection) WithMiddleware(middleware ...Middleware) CollectionService {
+	clone := *collection
+	clone.Client = collection.Client.With(middleware...)
+	return &clone
 }
 
 func (collection *PBCollection) ImportCollections(token string, collections []map[string]any, deleteMissing bool) error {
@@ -139,7 +158,7 @@ func (collection *PBCollection) ImportCollections(token string, collections []ma
 		"deleteMissing": deleteMissing,
 	}
 
-	res, err := SendAuthenticatedHTTPRequest("PUT", apiUrl, map[string]string{}, data, token)
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("PUT", apiUrl, map[string]string{}, data, token)
 
 	if err != nil {
 		return err
@@ -183,7 +202,7 @@ func (collection *PBCollection) CreateNewCollection(token string, options Collec
 		collectionOptions["viewQuery"] = options.ViewQuery
 	}
 
-	res, err := SendAuthenticatedHTTPRequest("POST", apiUrl, map[string]string{}, collectionOptions, token)
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("POST", apiUrl, map[string]string{}, collectionOptions, token)
 
 	if err != nil {
 		return PocketBaseCollectionResponse{}, err
@@ -206,23 +225,32 @@ func (collection *PBCollection) CreateNewCollection(token string, options Collec
 }
 
 // ### UPDATE COLLECTION ###
-func (collection *PBCollection) UpdateCollection(token string, desiredCollection string) (map[string]any, error) {
+func (collection *PBCollection) UpdateCollection(token string, desiredCollection string, data map[string]any) (PocketBaseCollectionResponse, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections/%s", collection.BaseURL, desiredCollection)
-	res, err := SendAuthenticatedHTTPRequest("PATCH", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("PATCH", apiUrl, map[string]string{}, data, token)
 
 	if err != nil {
-		return map[string]any{}, err
+		return PocketBaseCollectionResponse{}, err
 	}
 
-	fmt.Println(res)
-	return map[string]any{}, nil
+	if res.StatusCode != http.StatusOK {
+		pbErr := DecodePocketBaseErrorResponse(res)
+
+		return PocketBaseCollectionResponse{}, errors.New(pbErr.Message)
+	}
+
+	collectionRes := PocketBaseCollectionResponse{}
+	json.NewDecoder(res.Body).Decode(&collectionRes)
+	defer res.Body.Close()
+
+	return collectionRes, nil
 }
 
 // ### VIEW COLLECTION ###
 
 func (collection *PBCollection) ScaffoldCollections(token string) (map[string]any, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections/meta/scaffolds", collection.BaseURL)
-	res, err := SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
 		return map[string]any{}, err
@@ -241,34 +269,58 @@ func (collection *PBCollection) ScaffoldCollections(token string) (map[string]an
 	return scaffoldRes, nil
 }
 
-func (collection *PBCollection) ViewCollection(token string, desiredCollection string) {
+func (collection *PBCollection) ViewCollection(token string, desiredCollection string) (PocketBaseCollectionResponse, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections/%s", collection.BaseURL, desiredCollection)
-	res, err := SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
-		return
+		return PocketBaseCollectionResponse{}, err
+	}
+
+	//The status tells a hidden or deleted collection apart from a failed request
+	if res.StatusCode != http.StatusOK {
+		return PocketBaseCollectionResponse{}, NewResponseError(res)
 	}
 
-	fmt.Println(res)
+	collectionRes := PocketBaseCollectionResponse{}
+	json.NewDecoder(res.Body).Decode(&collectionRes)
+	defer res.Body.Close()
 
+	return collectionRes, nil
 }
 
-func (collection *PBCollection) ListCollections(token string) {
+func (collection *PBCollection) ListCollections(token string, queryOptions PocketBaseListOptions) (PocketBaseCollectionListResponse, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections", collection.BaseURL)
-	res, err := SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
+
+	queryString, hasOptions := ConstructQueryStringForAPI(queryOptions)
+
+	if hasOptions {
+		apiUrl += queryString
+	}
+
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
-		return
+		return PocketBaseCollectionListResponse{}, err
 	}
 
-	fmt.Println(res)
+	if res.StatusCode != http.StatusOK {
+		pbErr := DecodePocketBaseErrorResponse(res)
 
+		return PocketBaseCollectionListResponse{}, errors.New(pbErr.Message)
+	}
+
+	listRes := PocketBaseCollectionListResponse{}
+	json.NewDecoder(res.Body).Decode(&listRes)
+	defer res.Body.Close()
+
+	return listRes, nil
 }
 
 // ### DELETE COLLECTION ###
 func (collection *PBCollection) DeleteCollection(token string, desiredCollection string) (bool, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections/%s", collection.BaseURL, desiredCollection)
-	res, err := SendAuthenticatedHTTPRequest("DELETE", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("DELETE", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
 		return false, err
@@ -283,13 +335,19 @@ func (collection *PBCollection) DeleteCollection(token string, desiredCollection
 	return true, nil
 }
 
-func (collection *PBCollection) TruncateCollection(token string, desiredCollection string) {
+func (collection *PBCollection) TruncateCollection(token string, desiredCollection string) error {
 	apiUrl := fmt.Sprintf("%s/api/collections/%s/truncate", collection.BaseURL, desiredCollection)
-	res, err := SendAuthenticatedHTTPRequest("DELETE", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := collection.Client.SendAuthenticatedHTTPRequest("DELETE", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
-		return
+		return err
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		pbErr := DecodePocketBaseErrorResponse(res)
+
+		return errors.New(pbErr.Message)
 	}
 
-	fmt.Println(res)
+	return nil
 }
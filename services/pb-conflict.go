@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Header carrying the expected updated timestamp of UpdateIfUnchanged
+const EXPECTED_UPDATED_HEADER = "X-Expected-Updated"
+
+// Update rule condition that makes the server reject stale updates from UpdateIfUnchanged,
+// requests without the header are still allowed. Install it with EnableConditionalUpdates.
+const CONDITIONAL_UPDATE_RULE = `(@request.headers.x_expected_updated = "" || @request.headers.x_expected_updated = updated)`
+
+// Returned when the record changed since it was read, Current is the version now stored on the server
+type ConflictError struct {
+	Collection string
+	RecordId   string
+	Expected   string
+	Current    map[string]any
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("update-conflict|%s/%s was updated at %v, expected %s", e.Collection, e.RecordId, e.Current["updated"], e.Expected)
+}
+
+// Updates the record only when its updated timestamp still equals expectedUpdated
+//
+// The record is fetched and compared before writing and the expected timestamp is sent in EXPECTED_UPDATED_HEADER.
+// Collections with CONDITIONAL_UPDATE_RULE in their update rule reject a stale write on the server, without it an
+// edit landing between the compare and the write can still be overwritten. Superuser tokens bypass API rules, so
+// for them only the fetch and compare applies and that race remains even with the rule installed. Conflicts
+// return a *ConflictError and a missing record returns ErrNotFound.
+func (record *PBRecord) UpdateIfUnchanged(collection string, recordId string, token string, expectedUpdated string, data map[string]any) (map[string]any, error) {
+	current, err := record.fetch(collection, recordId, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if current["updated"] != expectedUpdated {
+		return nil, &ConflictError{Collection: collection, RecordId: recordId, Expected: expectedUpdated, Current: current}
+	}
+
+	apiUrl := fmt.Sprintf("%s/api/collections/%s/records/%s", record.BaseURL, collection, recordId)
+
+	headers := map[string]string{EXPECTED_UPDATED_HEADER: expectedUpdated}
+
+	res, err := record.Client.SendAuthenticatedHTTPRequest("PATCH", apiUrl, headers, data, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusOK {
+		return DecodePocketBaseRecord(res), nil
+	}
+
+	resErr := NewResponseError(res)
+
+	//A failed update rule looks like a missing record, fetch it again to tell the two apart
+	if resErr.IsNotFound() {
+		current, err := record.fetch(collection, recordId, token)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if current["updated"] != expectedUpdated {
+			return nil, &ConflictError{Collection: collection, RecordId: recordId, Expected: expectedUpdated, Current: current}
+		}
+	}
+
+	return nil, resErr
+}
+
+// Joins CONDITIONAL_UPDATE_RULE with && to the update rule of the collection, a rule already holding it is kept
+//
+// A superusers only rule returns an error and is left alone, superusers bypass API rules so the condition
+// would never apply to them and installing it would open updates to everyone else.
+func (collection *PBCollection) EnableConditionalUpdates(token string, desiredCollection string) (PocketBaseCollectionResponse, error) {
+	current, err := collection.ViewCollection(token, desiredCollection)
+
+	if err != nil {
+		return PocketBaseCollectionResponse{}, err
+	}
+
+	rule, err := conditionalUpdateRule(current.UpdateRule)
+
+	if err != nil {
+		return current, fmt.Errorf("%w for %s", err, desiredCollection)
+	}
+
+	if *current.UpdateRule == rule {
+		return current, nil
+	}
+
+	return collection.UpdateCollection(token, desiredCollection, map[string]any{"updateRule": rule})
+}
+
+func conditionalUpdateRule(rule *string) (string, error) {
+	switch {
+	case rule == nil:
+		return "", errors.New("superusers-only-rule|superusers bypass the update rule")
+	case strings.Contains(*rule, CONDITIONAL_UPDATE_RULE):
+		return *rule, nil
+	case strings.TrimSpace(*rule) == "":
+		return CONDITIONAL_UPDATE_RULE, nil
+	}
+
+	return fmt.Sprintf("(%s) && %s", strings.TrimSpace(*rule), CONDITIONAL_UPDATE_RULE), nil
+}
+
+// Views a record and turns a 404 into ErrNotFound
+func (record *PBRecord) fetch(collection string, recordId string, token string) (map[string]any, error) {
+	current, err := record.uncached().send("GET", fmt.Sprintf("%s/api/collections/%s/records/%s", record.BaseURL, collection, recordId), token, map[string]any{})
+
+	var resErr *ResponseError
+
+	if errors.As(err, &resErr) && resErr.IsNotFound() {
+		return nil, ErrNotFound
+	}
+
+	return current, err
+}
@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func TestEnableConditionalUpdates(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "owned", UpdateRule: pbtest.Rule("author = @request.auth.id")})
+	server.AddCollection(pbtest.Collection{Name: "public", UpdateRule: pbtest.Rule("")})
+	server.AddCollection(pbtest.Collection{Name: "locked"})
+
+	cases := map[string]string{
+		"owned":  "(author = @request.auth.id) && " + services.CONDITIONAL_UPDATE_RULE,
+		"public": services.CONDITIONAL_UPDATE_RULE,
+	}
+
+	for name, expected := range cases {
+		updated, err := pb.Collection.EnableConditionalUpdates(token, name)
+
+		if err != nil || updated.UpdateRule == nil || *updated.UpdateRule != expected {
+			t.Fatalf("%s = %v, %v", name, updated.UpdateRule, err)
+		}
+
+		//Installing it again keeps the rule as it is
+		again, err := pb.Collection.EnableConditionalUpdates(token, name)
+
+		if err != nil || *again.UpdateRule != expected {
+			t.Fatalf("%s installed twice = %v, %v", name, *again.UpdateRule, err)
+		}
+	}
+
+	locked, err := pb.Collection.EnableConditionalUpdates(token, "locked")
+
+	if err == nil || !strings.HasPrefix(err.Error(), "superusers-only-rule") || locked.UpdateRule != nil {
+		t.Fatalf("locked = %v, %v", locked.UpdateRule, err)
+	}
+}
+
+func TestUpdateIfUnchangedReportsStaleWrites(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", Fields: []map[string]any{{"name": "title", "type": "text"}}})
+	record := server.Seed("posts", map[string]any{"title": "a", "updated": "2024-01-01 00:00:00.000Z"})[0]
+
+	id := record["id"].(string)
+	updated, err := pb.Record.UpdateIfUnchanged("posts", id, token, record["updated"].(string), map[string]any{"title": "b"})
+
+	if err != nil || updated["title"] != "b" {
+		t.Fatalf("update = %v, %v", updated, err)
+	}
+
+	_, err = pb.Record.UpdateIfUnchanged("posts", id, token, record["updated"].(string), map[string]any{"title": "c"})
+
+	var conflict *services.ConflictError
+
+	if !errors.As(err, &conflict) || conflict.Current["title"] != "b" {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+
+	if _, err := pb.Record.UpdateIfUnchanged("posts", "missing", token, "", map[string]any{}); !errors.Is(err, services.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestUpdateIfUnchangedTellsRejectedWritesFromRaces(t *testing.T) {
+	server, _, token := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", Fields: []map[string]any{{"name": "title", "type": "text"}}})
+	record := server.Seed("posts", map[string]any{"title": "a", "updated": "2024-01-01 00:00:00.000Z"})[0]
+	id := record["id"].(string)
+	path := "/api/collections/posts/records/" + id
+
+	other, err := services.New(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	race := false
+
+	//The update rule rejects the PATCH with a 404, with race set another client writes first
+	pb, err := services.New(server.URL, services.WithMiddleware(services.Middleware{
+		BeforeSend: func(req *http.Request) error {
+			if req.Method != http.MethodPatch {
+				return nil
+			}
+
+			if race {
+				if _, err := other.Record.UpdateRecord("posts", id, token, map[string]any{"title": "other"}); err != nil {
+					return err
+				}
+			}
+
+			server.Fail(pbtest.Failure{Method: http.MethodPatch, Path: path, Status: http.StatusNotFound, Times: 1})
+
+			return nil
+		},
+	}))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pb.Record.UpdateIfUnchanged("posts", id, token, record["updated"].(string), map[string]any{"title": "b"})
+
+	var resErr *services.ResponseError
+
+	if !errors.As(err, &resErr) || !resErr.IsNotFound() {
+		t.Fatalf("expected the rejected write to return the 404, got %v", err)
+	}
+
+	race = true
+	_, err = pb.Record.UpdateIfUnchanged("posts", id, token, record["updated"].(string), map[string]any{"title": "b"})
+
+	var conflict *services.ConflictError
+
+	if !errors.As(err, &conflict) || conflict.Current["title"] != "other" {
+		t.Fatalf("expected the write that raced the fetch to conflict, got %v", err)
+	}
+}
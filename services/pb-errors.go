@@ -0,0 +1,58 @@
+package services
+
+import "net/http"
+
+const (
+	// Reported in the error data when a unique index rejects a value
+	ERROR_CODE_NOT_UNIQUE = "validation_not_unique"
+)
+
+// Error response with the status and field errors kept, Error returns the message like the plain errors of the other methods
+type ResponseError struct {
+	Status  int
+	Message string
+	Data    map[string]any
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// Whether any error in the data, nested ones included, has the code
+func (e *ResponseError) HasCode(code string) bool {
+	return hasErrorCode(e.Data, code)
+}
+
+func (e *ResponseError) IsNotFound() bool {
+	return e.Status == http.StatusNotFound
+}
+
+// Decodes the error body of the response, the status comes from the response itself
+func NewResponseError(response http.Response) *ResponseError {
+	pbErr := DecodePocketBaseErrorResponse(response)
+
+	return &ResponseError{Status: response.StatusCode, Message: pbErr.Message, Data: pbErr.Data}
+}
+
+func hasErrorCode(value any, code string) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		if v["code"] == code {
+			return true
+		}
+
+		for _, nested := range v {
+			if hasErrorCode(nested, code) {
+				return true
+			}
+		}
+	case []any:
+		for _, nested := range v {
+			if hasErrorCode(nested, code) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type PBFile struct {
+	BaseURL string
+	Client  *PBClient
+}
+
+type fileTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// Returns a copy of the service that runs the extra middleware on every request made through it
+func (file *PBFile) WithMiddleware(middleware ...Middleware) FileService {
+	clone := *file
+	clone.Client = file.Client.With(middleware...)
+	return &clone
+}
+
+// Url of a stored file, protected files also need a file token from RequestToken
+func (file *PBFile) URL(collection string, recordId string, filename string, fileToken string) string {
+	apiUrl := fmt.Sprintf("%s/api/files/%s/%s/%s", file.BaseURL, collection, recordId, url.PathEscape(filename))
+
+	if fileToken != "" {
+		apiUrl += "?token=" + url.QueryEscape(fileToken)
+	}
+
+	return apiUrl
+}
+
+// Requests a short lived token for protected files with the auth token
+func (file *PBFile) RequestToken(token string) (string, error) {
+	apiUrl := fmt.Sprintf("%s/api/files/token", file.BaseURL)
+
+	res, err := file.Client.SendAuthenticatedHTTPRequest("POST", apiUrl, map[string]string{}, map[string]any{}, token)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		pbErr := DecodePocketBaseErrorResponse(res)
+		return "", errors.New(pbErr.Message)
+	}
+
+	tokenRes := fileTokenResponse{}
+
+	if err := json.NewDecoder(res.Body).Decode(&tokenRes); err != nil {
+		return "", err
+	}
+
+	return tokenRes.Token, nil
+}
+
+// Copies the content of a stored file into the writer, returns the number of bytes written
+func (file *PBFile) Download(collection string, recordId string, filename string, fileToken string, writer io.Writer) (int64, error) {
+	req, err := http.NewRequestWithContext(file.Client.context(), "GET", file.URL(collection, recordId, filename, fileToken), nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := file.Client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, NewResponseError(res)
+	}
+
+	return io.Copy(writer, res.Body)
+}
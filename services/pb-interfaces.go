@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"io"
+)
+
+// Interfaces for every service so consumers can swap in test doubles, see the mocks package
+
+type AuthService interface {
+	WithMiddleware(middleware ...Middleware) AuthService
+	Token() string
+	GetPBCollectionsAuthMethods(collection string, fields string) (AuthMethodResponse, error)
+	AuthWithPasswordForCollection(collection string, expand string, fields string, identity string, password string) (AuthSuccessResponse, error)
+	RefreshAuth(collection string, token string) (AuthSuccessResponse, error)
+}
+
+type CollectionService interface {
+	WithMiddleware(middleware ...Middleware) CollectionService
+	ImportCollections(token string, collections []map[string]any, deleteMissing bool) error
+	CreateNewCollection(token string, options CollectionOptions) (PocketBaseCollectionResponse, error)
+	UpdateCollection(token string, desiredCollection string, data map[string]any) (PocketBaseCollectionResponse, error)
+	ScaffoldCollections(token string) (map[string]any, error)
+	ViewCollection(token string, desiredCollection string) (PocketBaseCollectionResponse, error)
+	ListCollections(token string, queryOptions PocketBaseListOptions) (PocketBaseCollectionListResponse, error)
+	DeleteCollection(token string, desiredCollection string) (bool, error)
+	TruncateCollection(token string, desiredCollection string) error
+	EnableConditionalUpdates(token string, desiredCollection string) (PocketBaseCollectionResponse, error)
+}
+
+type RecordService interface {
+	WithMiddleware(middleware ...Middleware) RecordService
+	CreateAuthRecord(collection string, email string, password string, passwordConfirm string, token string) (map[string]any, error)
+	CreateNewRecord(collection string, token string, data map[string]any) (map[string]any, error)
+	ListRecords(collection string, token string, queryOptions PocketBaseListOptions) (PocketBaseListResponse, error)
+	StreamRecords(collection string, token string, queryOptions PocketBaseListOptions, useNumber bool, fn func(record map[string]any) error) (PocketBaseListResponse, error)
+	PrefetchRecords(ctx context.Context, collection string, token string, queryOptions PocketBaseListOptions, options PrefetchOptions, fn func(page int, items []map[string]any) error) error
+	FetchAllRecords(ctx context.Context, collection string, token string, queryOptions PocketBaseListOptions, options PrefetchOptions) ([]map[string]any, error)
+	ViewRecord(collection string, recordId string, token string) (map[string]any, error)
+	DeleteRecord(collection string, recordId string, token string) (bool, error)
+	UpdateRecord(collection string, recordId string, token string, updatedData map[string]any) (map[string]any, error)
+	UpdateRecordWith(collection string, recordId string, token string, update *RecordUpdate) (map[string]any, error)
+	UpdateIfUnchanged(collection string, recordId string, token string, expectedUpdated string, data map[string]any) (map[string]any, error)
+	Batch(token string, requests []BatchRequest) ([]BatchResult, error)
+	Upsert(collection string, token string, matchFilter string, data map[string]any) (UpsertResult, error)
+	FindOrCreate(collection string, token string, matchFilter string, defaults map[string]any) (UpsertResult, error)
+	UpdateWhere(collection string, token string, matchFilter string, data map[string]any, options BulkOptions) (BulkResult, error)
+	DeleteWhere(collection string, token string, matchFilter string, options BulkOptions) (BulkResult, error)
+}
+
+type FileService interface {
+	WithMiddleware(middleware ...Middleware) FileService
+	URL(collection string, recordId string, filename string, fileToken string) string
+	RequestToken(token string) (string, error)
+	Download(collection string, recordId string, filename string, fileToken string, writer io.Writer) (int64, error)
+}
+
+var (
+	_ AuthService       = (*PBAuth)(nil)
+	_ CollectionService = (*PBCollection)(nil)
+	_ RecordService     = (*PBRecord)(nil)
+	_ FileService       = (*PBFile)(nil)
+)
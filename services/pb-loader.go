@@ -0,0 +1,215 @@
+package services
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/JGugino/pb-go/filter"
+)
+
+const (
+	DEFAULT_LOADER_WINDOW = 2 * time.Millisecond
+
+	// Escaped filter length per list request, keeps the url well below the common 8KB limit of proxies
+	DEFAULT_LOADER_MAX_FILTER_LENGTH = 6000
+
+	// Every id of a request has to fit on its single page
+	loaderMaxIdsPerRequest = MAX_LIST_PER_PAGE
+)
+
+// Coalesces ViewRecord calls made within a short window into a single ListRecords call per collection
+//
+// Concurrent calls for the same record share a single lookup but every call gets its own copy of the record, results
+// are not cached once they are returned.
+// Fields, when set, has to include id so the results can be matched back to the calls.
+type RecordLoader struct {
+	Records         RecordService
+	Token           string
+	Window          time.Duration
+	MaxFilterLength int
+	Expand          string
+	Fields          string
+
+	mu      sync.Mutex
+	pending map[string][]string
+	calls   map[string]*loaderCall
+}
+
+type loaderCall struct {
+	done   chan struct{}
+	record map[string]any
+	err    error
+}
+
+func NewRecordLoader(records RecordService, token string, window time.Duration) *RecordLoader {
+	return &RecordLoader{Records: records, Token: token, Window: window}
+}
+
+// Creates a loader on top of the record service of the client
+func (pb *Pocketbase) NewRecordLoader(token string, window time.Duration) *RecordLoader {
+	return NewRecordLoader(pb.Record, token, window)
+}
+
+// Waits for the window to close and returns the record, ErrNotFound when the id does not exist
+func (loader *RecordLoader) ViewRecord(collection string, recordId string) (map[string]any, error) {
+	key := collection + "/" + recordId
+
+	loader.mu.Lock()
+
+	if loader.calls == nil {
+		loader.calls = map[string]*loaderCall{}
+		loader.pending = map[string][]string{}
+	}
+
+	call, ok := loader.calls[key]
+
+	if !ok {
+		call = &loaderCall{done: make(chan struct{})}
+		loader.calls[key] = call
+
+		if len(loader.pending[collection]) == 0 {
+			time.AfterFunc(loader.window(), func() { loader.flush(collection) })
+		}
+
+		loader.pending[collection] = append(loader.pending[collection], recordId)
+	}
+
+	loader.mu.Unlock()
+
+	<-call.done
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	//Callers of the same lookup must not see each others changes, expanded records included
+	return copyValue(call.record).(map[string]any), nil
+}
+
+func (loader *RecordLoader) window() time.Duration {
+	if loader.Window <= 0 {
+		return DEFAULT_LOADER_WINDOW
+	}
+
+	return loader.Window
+}
+
+func (loader *RecordLoader) flush(collection string) {
+	loader.mu.Lock()
+	ids := loader.pending[collection]
+	delete(loader.pending, collection)
+	loader.mu.Unlock()
+
+	for _, chunk := range loader.chunk(ids) {
+		found, err := loader.fetch(collection, chunk)
+
+		loader.mu.Lock()
+
+		for _, id := range chunk {
+			key := collection + "/" + id
+			call := loader.calls[key]
+			delete(loader.calls, key)
+
+			switch record, ok := found[id]; {
+			case err != nil:
+				call.err = err
+			case ok:
+				call.record = record
+			default:
+				call.err = ErrNotFound
+			}
+
+			close(call.done)
+		}
+
+		loader.mu.Unlock()
+	}
+}
+
+// Splits the ids so the escaped id filter of every request stays within MaxFilterLength
+func (loader *RecordLoader) chunk(ids []string) [][]string {
+	maxLength := loader.MaxFilterLength
+
+	if maxLength <= 0 {
+		maxLength = DEFAULT_LOADER_MAX_FILTER_LENGTH
+	}
+
+	chunks := [][]string{}
+	current := []string{}
+	length := 0
+
+	for _, id := range ids {
+		//Every id adds its comparison and the joining ||
+		size := len(url.QueryEscape(filter.Eq("id", id).String() + " || "))
+
+		if len(current) > 0 && (length+size > maxLength || len(current) >= loaderMaxIdsPerRequest) {
+			chunks = append(chunks, current)
+			current = []string{}
+			length = 0
+		}
+
+		current = append(current, id)
+		length += size
+	}
+
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+func (loader *RecordLoader) fetch(collection string, ids []string) (map[string]map[string]any, error) {
+	values := make([]any, len(ids))
+
+	for i, id := range ids {
+		values[i] = id
+	}
+
+	res, err := loader.Records.ListRecords(collection, loader.Token, PocketBaseListOptions{
+		Page:      1,
+		PerPage:   len(ids),
+		Filter:    filter.In("id", values...).String(),
+		Expand:    loader.Expand,
+		Fields:    loader.Fields,
+		SkipTotal: true,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]map[string]any, len(res.Items))
+
+	for _, item := range res.Items {
+		if id, ok := item["id"].(string); ok {
+			found[id] = item
+		}
+	}
+
+	return found, nil
+}
+
+func copyValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+
+		for k, v := range value {
+			copied[k] = copyValue(v)
+		}
+
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+
+		for i, v := range value {
+			copied[i] = copyValue(v)
+		}
+
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,190 @@
+package services_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func loadedPosts(t *testing.T, count int) (*pbtest.Server, *services.Pocketbase, []string) {
+	t.Helper()
+
+	server, pb, _ := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", ListRule: pbtest.Rule(""), Fields: []map[string]any{
+		{"name": "title", "type": "text"},
+		{"name": "tags", "type": "json"},
+	}})
+
+	ids := make([]string, count)
+
+	for i := range ids {
+		ids[i] = server.Seed("posts", map[string]any{"title": "post", "tags": []any{"go"}})[0]["id"].(string)
+	}
+
+	server.ResetRequests()
+
+	return server, pb, ids
+}
+
+// Views every id at once and returns the records and errors in the order of the ids
+func loadAll(loader *services.RecordLoader, ids []string) ([]map[string]any, []error) {
+	records := make([]map[string]any, len(ids))
+	errs := make([]error, len(ids))
+
+	var wg sync.WaitGroup
+
+	for i, id := range ids {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			records[i], errs[i] = loader.ViewRecord("posts", id)
+		}()
+	}
+
+	wg.Wait()
+
+	return records, errs
+}
+
+func listRequests(server *pbtest.Server) int {
+	count := 0
+
+	for _, request := range server.Requests() {
+		if request.Method == "GET" && request.Path == "/api/collections/posts/records" {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestLoaderCoalescesCallsWithinTheWindow(t *testing.T) {
+	server, pb, ids := loadedPosts(t, 5)
+	loader := pb.NewRecordLoader("", 50*time.Millisecond)
+
+	//The first id is asked for twice and shares one lookup
+	records, errs := loadAll(loader, append([]string{ids[0]}, ids...))
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+
+	if requests := listRequests(server); requests != 1 {
+		t.Fatalf("sent %d list requests, expected 1", requests)
+	}
+
+	if records[0]["id"] != ids[0] || records[1]["id"] != ids[0] || records[5]["id"] != ids[4] {
+		t.Fatalf("records do not match their ids: %v", records)
+	}
+
+	//Callers of the same record get their own copies
+	records[0]["title"] = "changed"
+	records[0]["tags"].([]any)[0] = "changed"
+
+	if records[1]["title"] != "post" || records[1]["tags"].([]any)[0] != "go" {
+		t.Fatalf("changes of one caller leaked into another: %v", records[1])
+	}
+
+	//A call after the window starts a new lookup
+	if _, err := loader.ViewRecord("posts", ids[0]); err != nil || listRequests(server) != 2 {
+		t.Fatalf("second window = %d requests, %v", listRequests(server), err)
+	}
+}
+
+func TestLoaderReturnsNotFoundPerMissingId(t *testing.T) {
+	server, pb, ids := loadedPosts(t, 2)
+	loader := pb.NewRecordLoader("", 20*time.Millisecond)
+
+	records, errs := loadAll(loader, []string{ids[0], "missing000001", ids[1], "missing000002"})
+
+	if errs[0] != nil || errs[2] != nil || records[0]["id"] != ids[0] || records[2]["id"] != ids[1] {
+		t.Fatalf("existing records = %v, %v", records, errs)
+	}
+
+	for _, i := range []int{1, 3} {
+		if !errors.Is(errs[i], services.ErrNotFound) || records[i] != nil {
+			t.Fatalf("missing id %d = %v, %v", i, records[i], errs[i])
+		}
+	}
+
+	if requests := listRequests(server); requests != 1 {
+		t.Fatalf("sent %d list requests, expected 1", requests)
+	}
+}
+
+func TestLoaderSharesRequestErrors(t *testing.T) {
+	server, pb, ids := loadedPosts(t, 3)
+	loader := pb.NewRecordLoader("", 20*time.Millisecond)
+
+	server.Fail(pbtest.Failure{Method: "GET", Path: "/api/collections/posts/records", Status: http.StatusInternalServerError, Times: 1})
+
+	_, errs := loadAll(loader, ids)
+
+	for i, err := range errs {
+		if err == nil || errors.Is(err, services.ErrNotFound) {
+			t.Fatalf("call %d = %v, expected the request error", i, err)
+		}
+	}
+}
+
+func TestLoaderChunksByFilterLength(t *testing.T) {
+	server, pb, ids := loadedPosts(t, 10)
+	loader := pb.NewRecordLoader("", 50*time.Millisecond)
+
+	//Room for three escaped id comparisons per request
+	loader.MaxFilterLength = 3 * len(`id+%3D+%22`+ids[0]+`%22+%7C%7C+`)
+
+	_, errs := loadAll(loader, ids)
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("call %d failed: %v", i, err)
+		}
+	}
+
+	if requests := listRequests(server); requests != 4 {
+		t.Fatalf("sent %d list requests for 10 ids, expected 4", requests)
+	}
+
+	joins := 0
+
+	for _, request := range server.Requests() {
+		if count := strings.Count(request.Query, "%7C%7C"); count <= 2 {
+			joins += count
+		} else {
+			t.Fatalf("request holds more than 3 ids: %s", request.Query)
+		}
+	}
+
+	//10 ids in 4 requests are joined 6 times
+	if joins != 6 {
+		t.Fatalf("ids joined %d times, expected 6", joins)
+	}
+}
+
+func TestLoaderChunksByPageSize(t *testing.T) {
+	server, pb, ids := loadedPosts(t, services.MAX_LIST_PER_PAGE+1)
+	loader := pb.NewRecordLoader("", 100*time.Millisecond)
+	loader.MaxFilterLength = 1 << 20
+
+	records, errs := loadAll(loader, ids)
+
+	for i, err := range errs {
+		if err != nil || records[i]["id"] != ids[i] {
+			t.Fatalf("call %d = %v, %v", i, records[i], err)
+		}
+	}
+
+	if requests := listRequests(server); requests != 2 {
+		t.Fatalf("sent %d list requests for %d ids, expected 2", requests, len(ids))
+	}
+}
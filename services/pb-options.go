@@ -0,0 +1,249 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DEFAULT_USER_AGENT = "pb-go"
+
+// Configures the client built by New
+type Option func(config *clientConfig) error
+
+type clientConfig struct {
+	client   *PBClient
+	timeouts Timeouts
+}
+
+// Timeouts applied to the http client, zero values are left unset
+//
+// Request covers the whole exchange including reading the body, the others are applied to the transport.
+type Timeouts struct {
+	Request        time.Duration
+	Dial           time.Duration
+	TLSHandshake   time.Duration
+	ResponseHeader time.Duration
+}
+
+// Builds a client for the instance at baseURL, the url may include a subpath the instance is served under
+func New(baseURL string, options ...Option) (*Pocketbase, error) {
+	normalized, err := NormalizeBaseURL(baseURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	config := &clientConfig{
+		client: &PBClient{
+			BaseURL:   normalized,
+			AuthStore: NewMemoryAuthStore(),
+			Headers: map[string]string{
+				"User-Agent": DEFAULT_USER_AGENT,
+			},
+		},
+	}
+
+	for _, option := range options {
+		if err := option(config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.applyTimeouts(); err != nil {
+		return nil, err
+	}
+
+	pb := &Pocketbase{Client: config.client}
+	pb.initServices()
+
+	return pb, nil
+}
+
+// Validates the base url and strips trailing slashes, query and fragment are not allowed
+func NormalizeBaseURL(baseURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(baseURL))
+
+	if err != nil {
+		return "", fmt.Errorf("invalid-base-url|%w", err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("invalid-base-url|scheme must be http or https, got %q", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return "", errors.New("invalid-base-url|missing host")
+	}
+
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", errors.New("invalid-base-url|query and fragment are not allowed")
+	}
+
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = ""
+
+	return parsed.String(), nil
+}
+
+// Uses the http client for every request, timeouts set through options are applied to a copy of it
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(config *clientConfig) error {
+		if httpClient == nil {
+			return errors.New("invalid-option|nil http client")
+		}
+
+		config.client.HTTPClient = httpClient
+		return nil
+	}
+}
+
+// Stores authentications in the store instead of the default in memory store
+func WithAuthStore(store AuthStore) Option {
+	return func(config *clientConfig) error {
+		config.client.AuthStore = store
+		return nil
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(config *clientConfig) error {
+		config.client.Logger = logger
+		return nil
+	}
+}
+
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(config *clientConfig) error {
+		config.client.RetryPolicy = policy
+		return nil
+	}
+}
+
+func WithRateLimiter(limiter *RateLimiter) Option {
+	return func(config *clientConfig) error {
+		config.client.RateLimiter = limiter
+		return nil
+	}
+}
+
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(config *clientConfig) error {
+		config.client.Use(middleware...)
+		return nil
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return WithHeader("User-Agent", userAgent)
+}
+
+// Sets the Accept-Language header so the server translates its messages
+func WithLanguage(language string) Option {
+	return WithHeader("Accept-Language", language)
+}
+
+// Adds a default header sent with every request, per call headers take precedence
+func WithHeader(key string, value string) Option {
+	return func(config *clientConfig) error {
+		config.client.Headers[http.CanonicalHeaderKey(key)] = value
+		return nil
+	}
+}
+
+func WithHeaders(headers map[string]string) Option {
+	return func(config *clientConfig) error {
+		for k, v := range headers {
+			config.client.Headers[http.CanonicalHeaderKey(k)] = v
+		}
+
+		return nil
+	}
+}
+
+// Limits the whole request including reading the response body
+func WithTimeout(timeout time.Duration) Option {
+	return func(config *clientConfig) error {
+		config.timeouts.Request = timeout
+		return nil
+	}
+}
+
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(config *clientConfig) error {
+		config.timeouts = timeouts
+		return nil
+	}
+}
+
+// Caches record reads, see ResponseCache for how entries are evicted
+func WithCache(cache *ResponseCache) Option {
+	return func(config *clientConfig) error {
+		config.client.Cache = cache
+		return nil
+	}
+}
+
+func WithDebugWriter(writer io.Writer) Option {
+	return func(config *clientConfig) error {
+		config.client.DebugWriter = writer
+		return nil
+	}
+}
+
+// Copies the http client and its transport so the timeouts never leak into a client owned by the caller
+func (config *clientConfig) applyTimeouts() error {
+	timeouts := config.timeouts
+
+	if timeouts == (Timeouts{}) {
+		return nil
+	}
+
+	httpClient := &http.Client{}
+
+	if config.client.HTTPClient != nil {
+		copied := *config.client.HTTPClient
+		httpClient = &copied
+	}
+
+	if timeouts.Request > 0 {
+		httpClient.Timeout = timeouts.Request
+	}
+
+	if timeouts.Dial > 0 || timeouts.TLSHandshake > 0 || timeouts.ResponseHeader > 0 {
+		var transport *http.Transport
+
+		switch t := httpClient.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return errors.New("invalid-option|transport timeouts need an *http.Transport")
+		}
+
+		if timeouts.Dial > 0 {
+			transport.DialContext = (&net.Dialer{Timeout: timeouts.Dial, KeepAlive: 30 * time.Second}).DialContext
+		}
+
+		if timeouts.TLSHandshake > 0 {
+			transport.TLSHandshakeTimeout = timeouts.TLSHandshake
+		}
+
+		if timeouts.ResponseHeader > 0 {
+			transport.ResponseHeaderTimeout = timeouts.ResponseHeader
+		}
+
+		httpClient.Transport = transport
+	}
+
+	config.client.HTTPClient = httpClient
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+const DEFAULT_PREFETCH_WORKERS = 4
+
+// Options for PrefetchRecords, Unordered hands pages over as soon as they arrive instead of in page order
+type PrefetchOptions struct {
+	Workers   int
+	Unordered bool
+}
+
+type prefetchedPage struct {
+	page  int
+	items []map[string]any
+	err   error
+}
+
+// Reads every page of the list, the first page gives totalPages and the rest are fetched by Workers at once
+//
+// fn gets every page in order unless Unordered is set and is never called concurrently. At most Workers pages
+// are fetched or waiting for fn at once, so a slow page holds back the read instead of piling up later pages.
+// Cancelling the context or an error from fn stops the read and cancels the requests in flight. Requests go
+// through the client so the rate limiter applies. Records created or deleted during the read can shift the
+// pages like any offset paging.
+func (record *PBRecord) PrefetchRecords(ctx context.Context, collection string, token string, queryOptions PocketBaseListOptions, options PrefetchOptions, fn func(page int, items []map[string]any) error) error {
+	var wg sync.WaitGroup
+
+	//Workers stop once the context is cancelled, none of them outlives the read
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	//Every request made for this read carries the context so cancelling it aborts them and the rate limiter wait
+	records := &PBRecord{BaseURL: record.BaseURL, Client: record.Client.WithContext(ctx)}
+
+	queryOptions.Page = 1
+	queryOptions.SkipTotal = false
+
+	first, err := records.ListRecords(collection, token, queryOptions)
+
+	if err != nil {
+		return err
+	}
+
+	if err := fn(1, first.Items); err != nil {
+		return err
+	}
+
+	if first.TotalPages <= 1 {
+		return nil
+	}
+
+	workers := options.Workers
+
+	if workers <= 0 {
+		workers = DEFAULT_PREFETCH_WORKERS
+	}
+
+	pages := make(chan int)
+	results := make(chan prefetchedPage)
+
+	//Taken when a page is handed to a worker and returned once fn got it
+	slots := make(chan struct{}, workers)
+
+	for i := 0; i < min(workers, first.TotalPages-1); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for page := range pages {
+				pageOptions := queryOptions
+				pageOptions.Page = page
+				pageOptions.SkipTotal = true
+
+				res, err := records.ListRecords(collection, token, pageOptions)
+
+				select {
+				case results <- prefetchedPage{page: page, items: res.Items, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(pages)
+
+		for page := 2; page <= first.TotalPages; page++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case pages <- page:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	buffered := map[int][]map[string]any{}
+	next := 2
+
+	for next <= first.TotalPages {
+		var result prefetchedPage
+		var ok bool
+
+		select {
+		case result, ok = <-results:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if !ok {
+			return ctx.Err()
+		}
+
+		if result.err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return result.err
+		}
+
+		if options.Unordered {
+			if err := fn(result.page, result.items); err != nil {
+				return err
+			}
+
+			<-slots
+			next++
+			continue
+		}
+
+		buffered[result.page] = result.items
+
+		for items, ready := buffered[next]; ready; items, ready = buffered[next] {
+			delete(buffered, next)
+
+			if err := fn(next, items); err != nil {
+				return err
+			}
+
+			<-slots
+			next++
+		}
+	}
+
+	return nil
+}
+
+// Reads every page with PrefetchRecords and returns the records in page order
+func (record *PBRecord) FetchAllRecords(ctx context.Context, collection string, token string, queryOptions PocketBaseListOptions, options PrefetchOptions) ([]map[string]any, error) {
+	pages := map[int][]map[string]any{}
+	total := 0
+
+	options.Unordered = true
+
+	err := record.PrefetchRecords(ctx, collection, token, queryOptions, options, func(page int, items []map[string]any) error {
+		pages[page] = items
+		total += len(items)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]any, 0, total)
+
+	for page := 1; page <= len(pages); page++ {
+		items = append(items, pages[page]...)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,137 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func prefetchServer(t *testing.T, records int, middleware ...services.Middleware) (*pbtest.Server, *services.Pocketbase) {
+	t.Helper()
+
+	server, pb, _ := pbtest.NewClient(t, services.WithMiddleware(middleware...))
+	server.AddCollection(pbtest.Collection{Name: "posts", ListRule: pbtest.Rule(""), Fields: []map[string]any{{"name": "views", "type": "number"}}})
+
+	for i := 0; i < records; i++ {
+		server.Seed("posts", map[string]any{"views": float64(i)})
+	}
+
+	return server, pb
+}
+
+func TestPrefetchBoundsPagesAheadOfFn(t *testing.T) {
+	var started atomic.Int32
+
+	server, pb := prefetchServer(t, 100, services.Middleware{
+		BeforeSend: func(req *http.Request) error {
+			started.Add(1)
+			return nil
+		},
+	})
+
+	server.SetLatency(time.Millisecond)
+
+	options := services.PocketBaseListOptions{PerPage: 5, Sort: "views"}
+	next := 1
+
+	err := pb.Record.PrefetchRecords(context.Background(), "posts", "", options, services.PrefetchOptions{Workers: 2}, func(page int, items []map[string]any) error {
+		if page != next {
+			t.Fatalf("got page %d, expected %d", page, next)
+		}
+
+		next++
+
+		//A slow consumer, the workers must wait for it instead of reading ahead
+		time.Sleep(5 * time.Millisecond)
+
+		if ahead := int(started.Load()) - page; ahead > 2 {
+			t.Fatalf("%d pages requested ahead of page %d", ahead, page)
+		}
+
+		return nil
+	})
+
+	if err != nil || next != 21 {
+		t.Fatalf("read up to page %d, %v", next-1, err)
+	}
+}
+
+func TestPrefetchCancelsWithoutTouchingOtherRequests(t *testing.T) {
+	var cancelled atomic.Int32
+
+	_, pb := prefetchServer(t, 50, services.Middleware{
+		AfterSend: func(req *http.Request, res *http.Response, err error) (*http.Response, error) {
+			if req.Context().Err() != nil {
+				cancelled.Add(1)
+			}
+
+			return res, err
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := errors.New("stop")
+
+	err := pb.Record.PrefetchRecords(ctx, "posts", "", services.PocketBaseListOptions{PerPage: 5}, services.PrefetchOptions{}, func(page int, items []map[string]any) error {
+		if page == 2 {
+			return stop
+		}
+
+		return nil
+	})
+
+	cancel()
+
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected the error from fn, got %v", err)
+	}
+
+	cancelled.Store(0)
+
+	//Requests after the read are not bound to its cancelled context
+	if _, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{}); err != nil || cancelled.Load() != 0 {
+		t.Fatalf("list after the read = %v, %d cancelled", err, cancelled.Load())
+	}
+}
+
+func TestFetchAllRecordsAboveThePerPageCap(t *testing.T) {
+	_, pb := prefetchServer(t, services.MAX_LIST_PER_PAGE+5)
+
+	items, err := pb.Record.FetchAllRecords(context.Background(), "posts", "", services.PocketBaseListOptions{PerPage: 5000, Sort: "views"}, services.PrefetchOptions{})
+
+	if err != nil || len(items) != services.MAX_LIST_PER_PAGE+5 {
+		t.Fatalf("fetched %d records, %v", len(items), err)
+	}
+
+	for i, item := range items {
+		if item["views"] != float64(i) {
+			t.Fatalf("record %d has views %v, pages are out of order", i, item["views"])
+		}
+	}
+}
+
+func TestPrefetchReturnsFailedPages(t *testing.T) {
+	server, pb := prefetchServer(t, 20)
+	pages := 0
+
+	err := pb.Record.PrefetchRecords(context.Background(), "posts", "", services.PocketBaseListOptions{PerPage: 5}, services.PrefetchOptions{Workers: 2}, func(page int, items []map[string]any) error {
+		pages++
+
+		//The first page succeeds and the next request a worker sends fails
+		if page == 1 {
+			server.FailNext(1, http.StatusInternalServerError)
+		}
+
+		return nil
+	})
+
+	if err == nil || pages >= 4 {
+		t.Fatalf("read %d pages, %v", pages, err)
+	}
+}
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/JGugino/pb-go/filter"
+)
+
+// Returned by First and single record lookups when nothing matched
+var ErrNotFound = errors.New("not-found")
+
+// Page size used by All and by queries without an explicit page
+const DEFAULT_QUERY_PER_PAGE = 200
+
+// Largest page PocketBase returns, a larger perPage is capped by the server
+const MAX_LIST_PER_PAGE = 1000
+
+// Chainable query for the records of a collection, values passed to Where are always bound as literals
+//
+// Build one with pb.Records for plain maps or RecordsOf/NewRecordQuery for records decoded into T.
+// A query keeps the first error it runs into and returns it from Compile and every fetching method.
+type RecordQuery[T any] struct {
+	records     RecordService
+	collections CollectionService
+	collection  string
+	token       string
+	where       filter.Expr
+	sort        []string
+	expand      []string
+	fields      []string
+	page        int
+	perPage     int
+	skipTotal   bool
+	schema      *CollectionSchema
+	err         error
+}
+
+// A page of records decoded into T
+type RecordPage[T any] struct {
+	Page       int
+	PerPage    int
+	TotalItems int
+	TotalPages int
+	Items      []T
+}
+
+// Starts a query for the collection returning records as maps
+func (pb *Pocketbase) Records(collection string) *RecordQuery[map[string]any] {
+	return RecordsOf[map[string]any](pb, collection)
+}
+
+// Starts a query for the collection returning records decoded into T
+func RecordsOf[T any](pb *Pocketbase, collection string) *RecordQuery[T] {
+	query := NewRecordQuery[T](pb.Record, collection)
+	query.collections = pb.Collection
+	return query
+}
+
+// Starts a query on top of any RecordService, LoadSchema is unavailable unless a schema is set with WithSchema
+func NewRecordQuery[T any](records RecordService, collection string) *RecordQuery[T] {
+	return &RecordQuery[T]{records: records, collection: collection}
+}
+
+// Token sent with the requests, without one the token in the auth store is used
+func (query *RecordQuery[T]) Token(token string) *RecordQuery[T] {
+	query.token = token
+	return query
+}
+
+// Adds the expressions joined with && to the filter
+func (query *RecordQuery[T]) Where(exprs ...filter.Expr) *RecordQuery[T] {
+	query.where = filter.AndAll(query.where, filter.AndAll(exprs...))
+	return query
+}
+
+// Adds the expressions joined with && as an alternative to everything before it
+func (query *RecordQuery[T]) OrWhere(exprs ...filter.Expr) *RecordQuery[T] {
+	query.where = filter.OrAll(query.where, filter.AndAll(exprs...))
+	return query
+}
+
+// Adds a raw filter string with && to the filter, it is parsed so syntax errors show up before sending
+func (query *RecordQuery[T]) WhereRaw(raw string) *RecordQuery[T] {
+	expr, err := filter.Parse(raw)
+
+	if err != nil {
+		query.fail(err)
+		return query
+	}
+
+	return query.Where(expr)
+}
+
+// Sort fields, prefix with - for descending order
+func (query *RecordQuery[T]) Sort(fields ...string) *RecordQuery[T] {
+	query.sort = append(query.sort, fields...)
+	return query
+}
+
+func (query *RecordQuery[T]) Expand(relations ...string) *RecordQuery[T] {
+	query.expand = append(query.expand, relations...)
+	return query
+}
+
+func (query *RecordQuery[T]) Fields(fields ...string) *RecordQuery[T] {
+	query.fields = append(query.fields, fields...)
+	return query
+}
+
+// Sets the page and its size, sizes above MAX_LIST_PER_PAGE are capped to it
+func (query *RecordQuery[T]) Page(page int, perPage int) *RecordQuery[T] {
+	query.page = page
+	query.perPage = perPage
+	return query
+}
+
+// Skips counting the total, List then returns -1 for TotalItems and TotalPages
+func (query *RecordQuery[T]) SkipTotal() *RecordQuery[T] {
+	query.skipTotal = true
+	return query
+}
+
+// Checks field names against the schema when compiling
+func (query *RecordQuery[T]) WithSchema(schema *CollectionSchema) *RecordQuery[T] {
+	query.schema = schema
+	return query
+}
+
+// Fetches the collection schema so field names are checked when compiling, requires a token allowed to view collections
+func (query *RecordQuery[T]) LoadSchema() *RecordQuery[T] {
+	if query.collections == nil {
+		query.fail(errors.New("missing-collection-service"))
+		return query
+	}
+
+	schema, err := LoadCollectionSchema(query.collections, query.token, query.collection)
+
+	if err != nil {
+		query.fail(err)
+		return query
+	}
+
+	return query.WithSchema(schema)
+}
+
+func (query *RecordQuery[T]) fail(err error) {
+	if query.err == nil {
+		query.err = err
+	}
+}
+
+// Builds the list options, field names are checked against the schema when one is set
+func (query *RecordQuery[T]) Compile() (PocketBaseListOptions, error) {
+	if query.err != nil {
+		return PocketBaseListOptions{}, query.err
+	}
+
+	options := PocketBaseListOptions{
+		Page:      max(query.page, 1),
+		PerPage:   query.perPage,
+		Sort:      strings.Join(query.sort, ","),
+		Expand:    strings.Join(query.expand, ","),
+		Fields:    strings.Join(query.fields, ","),
+		SkipTotal: query.skipTotal,
+	}
+
+	if options.PerPage <= 0 {
+		options.PerPage = DEFAULT_QUERY_PER_PAGE
+	}
+
+	//Asking for more than the server sends would make All stop after a full page
+	options.PerPage = min(options.PerPage, MAX_LIST_PER_PAGE)
+
+	if query.where != nil {
+		if err := filter.Validate(query.where); err != nil {
+			return PocketBaseListOptions{}, err
+		}
+
+		options.Filter = query.where.String()
+	}
+
+	if err := query.checkFields(); err != nil {
+		return PocketBaseListOptions{}, err
+	}
+
+	return options, nil
+}
+
+func (query *RecordQuery[T]) checkFields() error {
+	if query.schema == nil {
+		return nil
+	}
+
+	names := []string{}
+
+	if query.where != nil {
+		names = append(names, filter.Fields(query.where)...)
+	}
+
+	for _, sort := range query.sort {
+		sort = strings.TrimLeft(sort, "+-")
+
+		if !strings.HasPrefix(sort, "@") {
+			names = append(names, sort)
+		}
+	}
+
+	for _, field := range query.fields {
+		//Fields may carry a modifier like description:excerpt(200), expand.* selects from the expanded records
+		field, _, _ = strings.Cut(field, ":")
+
+		if field != "*" && field != "expand" && !strings.HasPrefix(field, "expand.") {
+			names = append(names, field)
+		}
+	}
+
+	for _, relation := range query.expand {
+		names = append(names, relation)
+
+		if field, ok := query.schema.Field(strings.Split(relation, ".")[0]); ok && field.Type != "relation" {
+			return fmt.Errorf("invalid-expand|%q is not a relation field of %s", field.Name, query.schema.Name)
+		}
+	}
+
+	for _, name := range names {
+		if err := query.schema.CheckField(strings.TrimSpace(name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Fetches the page set with Page, the first page when none was set
+func (query *RecordQuery[T]) List() (RecordPage[T], error) {
+	options, err := query.Compile()
+
+	if err != nil {
+		return RecordPage[T]{}, err
+	}
+
+	return query.fetch(options)
+}
+
+// Fetches every matching record page by page, the page size set with Page is used as batch size
+func (query *RecordQuery[T]) All() ([]T, error) {
+	options, err := query.Compile()
+
+	if err != nil {
+		return nil, err
+	}
+
+	options.Page = 1
+	options.SkipTotal = true
+
+	items := []T{}
+
+	for {
+		page, err := query.fetch(options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Items...)
+
+		if len(page.Items) < options.PerPage {
+			return items, nil
+		}
+
+		options.Page++
+	}
+}
+
+// Fetches the first matching record, ErrNotFound when nothing matched
+func (query *RecordQuery[T]) First() (T, error) {
+	var record T
+
+	options, err := query.Compile()
+
+	if err != nil {
+		return record, err
+	}
+
+	options.Page = 1
+	options.PerPage = 1
+	options.SkipTotal = true
+
+	page, err := query.fetch(options)
+
+	if err != nil {
+		return record, err
+	}
+
+	if len(page.Items) == 0 {
+		return record, ErrNotFound
+	}
+
+	return page.Items[0], nil
+}
+
+// Counts the matching records while fetching only a single id
+func (query *RecordQuery[T]) Count() (int, error) {
+	options, err := query.Compile()
+
+	if err != nil {
+		return 0, err
+	}
+
+	options.Page = 1
+	options.PerPage = 1
+	options.Fields = "id"
+	options.Expand = ""
+	options.SkipTotal = false
+
+	res, err := query.records.ListRecords(query.collection, query.token, options)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.TotalItems, nil
+}
+
+func (query *RecordQuery[T]) fetch(options PocketBaseListOptions) (RecordPage[T], error) {
+	res, err := query.records.ListRecords(query.collection, query.token, options)
+
+	if err != nil {
+		return RecordPage[T]{}, err
+	}
+
+	items, err := DecodeRecords[T](res.Items)
+
+	if err != nil {
+		return RecordPage[T]{}, err
+	}
+
+	return RecordPage[T]{
+		Page:       res.Page,
+		PerPage:    res.PerPage,
+		TotalItems: res.TotalItems,
+		TotalPages: res.TotalPages,
+		Items:      items,
+	}, nil
+}
+
+// Decodes a single record into T, see DecodeRecords
+func DecodeRecord[T any](record map[string]any) (T, error) {
+	items, err := DecodeRecords[T]([]map[string]any{record})
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return items[0], nil
+}
+
+// Turns a typed record into the map the record methods send, the reverse of DecodeRecord
+func EncodeRecord(record any) (map[string]any, error) {
+	if data, ok := record.(map[string]any); ok {
+		return data, nil
+	}
+
+	encoded, err := json.Marshal(record)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]any{}
+
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return nil, fmt.Errorf("invalid-record|%w", err)
+	}
+
+	return data, nil
+}
+
+// Decodes records into T through their JSON form, maps are returned as they are
+func DecodeRecords[T any](records []map[string]any) ([]T, error) {
+	items := make([]T, len(records))
+
+	for i, record := range records {
+		if item, ok := any(record).(T); ok {
+			items[i] = item
+			continue
+		}
+
+		encoded, err := json.Marshal(record)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(encoded, &items[i]); err != nil {
+			return nil, fmt.Errorf("invalid-record|%w", err)
+		}
+	}
+
+	return items, nil
+}
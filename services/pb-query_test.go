@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/JGugino/pb-go/filter"
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func TestWhereRawKeepsPrecedence(t *testing.T) {
+	cases := []struct {
+		query    *services.RecordQuery[map[string]any]
+		expected string
+	}{
+		{services.NewRecordQuery[map[string]any](nil, "posts").WhereRaw("a = 1 || b = 2 && c = 3"), "a = 1 || b = 2 && c = 3"},
+		{services.NewRecordQuery[map[string]any](nil, "posts").WhereRaw("(a = 1 || b = 2) && c = 3"), "(a = 1 || b = 2) && c = 3"},
+		{services.NewRecordQuery[map[string]any](nil, "posts").Where(filter.Eq("d", 4)).WhereRaw("a = 1 || b = 2"), "d = 4 && (a = 1 || b = 2)"},
+		{services.NewRecordQuery[map[string]any](nil, "posts").WhereRaw("a = 1 && b = 2").OrWhere(filter.Eq("c", 3)), "a = 1 && b = 2 || c = 3"},
+	}
+
+	for _, c := range cases {
+		options, err := c.query.Compile()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if options.Filter != c.expected {
+			t.Errorf("compiled %q, expected %q", options.Filter, c.expected)
+		}
+	}
+}
+
+func TestQueryReturnsTheSameRecordsAsTheRawFilter(t *testing.T) {
+	server, pb, _ := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", ListRule: pbtest.Rule("")})
+
+	for i := 0; i < 10; i++ {
+		server.Seed("posts", map[string]any{"n": float64(i)})
+	}
+
+	raw := "n = 0 || n > 5 && n < 8"
+	records, err := pb.Records("posts").WhereRaw(raw).Sort("n").All()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := pb.Record.ListRecords("posts", "", services.PocketBaseListOptions{Filter: raw, Sort: "n"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 3 || len(listed.Items) != 3 {
+		t.Fatalf("query returned %d records and the raw filter %d, expected 3", len(records), len(listed.Items))
+	}
+}
+
+func TestQueryAllReadsPastThePerPageCap(t *testing.T) {
+	server, pb, _ := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", ListRule: pbtest.Rule("")})
+
+	for i := 0; i < services.MAX_LIST_PER_PAGE+5; i++ {
+		server.Seed("posts", map[string]any{"n": float64(i)})
+	}
+
+	query := pb.Records("posts").Page(1, 5000)
+	options, err := query.Compile()
+
+	if err != nil || options.PerPage != services.MAX_LIST_PER_PAGE {
+		t.Fatalf("compiled perPage %d, %v", options.PerPage, err)
+	}
+
+	records, err := query.All()
+
+	if err != nil || len(records) != services.MAX_LIST_PER_PAGE+5 {
+		t.Fatalf("all returned %d records, %v", len(records), err)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// A record change received over the realtime connection
+type RealtimeEvent struct {
+	Topic  string         `json:"-"`
+	Action string         `json:"action"`
+	Record map[string]any `json:"record"`
+}
+
+// Connects to /api/realtime, subscribes to the topics and calls handle for every event
+//
+// Topics are "collection/*" for every record or "collection/id" for a single one. It blocks until the
+// context is cancelled or the server closes the stream, so the http client must not have a request timeout.
+func (client *PBClient) SubscribeRealtime(ctx context.Context, token string, topics []string, handle func(event RealtimeEvent)) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/realtime", client.BaseURL), nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+
+	res, err := client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.New("realtime-connect-failed")
+	}
+
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	name := ""
+	data := []string{}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		case line == "":
+			if err := client.dispatchRealtime(token, topics, name, strings.Join(data, "\n"), handle); err != nil {
+				return err
+			}
+
+			name = ""
+			data = data[:0]
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return scanner.Err()
+}
+
+func (client *PBClient) dispatchRealtime(token string, topics []string, name string, data string, handle func(event RealtimeEvent)) error {
+	if name == "" {
+		return nil
+	}
+
+	//The first event carries the client id the subscriptions are registered for
+	if name == "PB_CONNECT" {
+		connect := struct {
+			ClientId string `json:"clientId"`
+		}{}
+
+		if err := json.Unmarshal([]byte(data), &connect); err != nil {
+			return err
+		}
+
+		body := map[string]any{"clientId": connect.ClientId, "subscriptions": topics}
+
+		res, err := client.SendAuthenticatedHTTPRequest("POST", fmt.Sprintf("%s/api/realtime", client.BaseURL), map[string]string{}, body, token)
+
+		if err != nil {
+			return err
+		}
+
+		if res.StatusCode != http.StatusNoContent {
+			return NewResponseError(res)
+		}
+
+		return nil
+	}
+
+	event := RealtimeEvent{Topic: name}
+
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return err
+	}
+
+	handle(event)
+
+	return nil
+}
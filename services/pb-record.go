@@ -8,6 +8,14 @@ import (
 
 type PBRecord struct {
 	BaseURL string
+	Client  *PBClient
+}
+
+// Returns a copy of the service that runs the extra middleware on every request made through it
+func (record *PBRecord) WithMiddleware(middleware ...Middleware) RecordService {
+	clone := *record
+	clone.Client = record.Client.With(middleware...)
+	return &clone
 }
 
 // ### CREATE RECORDS ###
@@ -20,7 +28,7 @@ func (record *PBRecord) CreateAuthRecord(collection string, email string, passwo
 		"passwordConfirm": passwordConfirm,
 	}
 
-	res, err := SendAuthenticatedHTTPRequest("POST", apiURL, map[string]string{}, body, token)
+	res, err := record.Client.SendAuthenticatedHTTPRequest("POST", apiURL, map[string]string{}, body, token)
 
 	if err != nil {
 		return map[string]any{}, err
@@ -41,7 +49,7 @@ func (record *PBRecord) CreateAuthRecord(collection string, email string, passwo
 func (record *PBRecord) CreateNewRecord(collection string, token string, data map[string]any) (map[string]any, error) {
 	apiURL := fmt.Sprintf("%s/api/collections/%s/records", record.BaseURL, collection)
 
-	res, err := SendAuthenticatedHTTPRequest("POST", apiURL, map[string]string{}, data, token)
+	res, err := record.Client.SendAuthenticatedHTTPRequest("POST", apiURL, map[string]string{}, data, token)
 
 	if err != nil {
 		return map[string]any{}, err
@@ -69,7 +77,7 @@ func (record *PBRecord) ListRecords(collection string, token string, queryOption
 		apiUrl += queryString
 	}
 
-	res, err := SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := record.Client.SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
 		return PocketBaseListResponse{}, err
@@ -78,19 +86,60 @@ func (record *PBRecord) ListRecords(collection string, token string, queryOption
 	status := res.StatusCode
 
 	if status == http.StatusOK {
-		record := DecodePocketBaseListResponse(res)
+		defer res.Body.Close()
 
-		return record, nil
+		items := []map[string]any{}
+
+		list, err := DecodeListStream(res.Body, false, func(item map[string]any) error {
+			items = append(items, item)
+			return nil
+		})
+
+		if err != nil {
+			return PocketBaseListResponse{}, err
+		}
+
+		list.Items = items
+
+		return list, nil
 	}
 
 	errRes := DecodePocketBaseErrorResponse(res)
 	return PocketBaseListResponse{}, errors.New(errRes.Message)
 }
 
+// Lists a page calling fn with every record as it is decoded, the returned response has no items
+//
+// With useNumber numbers are decoded as json.Number instead of float64 so big integers keep their precision.
+func (record *PBRecord) StreamRecords(collection string, token string, queryOptions PocketBaseListOptions, useNumber bool, fn func(record map[string]any) error) (PocketBaseListResponse, error) {
+	apiUrl := fmt.Sprintf("%s/api/collections/%s/records", record.BaseURL, collection)
+
+	queryString, hasOptions := ConstructQueryStringForAPI(queryOptions)
+
+	if hasOptions {
+		apiUrl += queryString
+	}
+
+	res, err := record.Client.SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
+
+	if err != nil {
+		return PocketBaseListResponse{}, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		errRes := DecodePocketBaseErrorResponse(res)
+		return PocketBaseListResponse{}, errors.New(errRes.Message)
+	}
+
+	defer res.Body.Close()
+
+	return DecodeListStream(res.Body, useNumber, fn)
+}
+
 func (record *PBRecord) ViewRecord(collection string, recordId string, token string) (map[string]any, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections/%s/records/%s", record.BaseURL, collection, recordId)
 
-	res, err := SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := record.Client.SendAuthenticatedHTTPRequest("GET", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
 		return map[string]any{}, err
@@ -112,7 +161,7 @@ func (record *PBRecord) ViewRecord(collection string, recordId string, token str
 func (record *PBRecord) DeleteRecord(collection string, recordId string, token string) (bool, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections/%s/records/%s", record.BaseURL, collection, recordId)
 
-	res, err := SendAuthenticatedHTTPRequest("DELETE", apiUrl, map[string]string{}, map[string]any{}, token)
+	res, err := record.Client.SendAuthenticatedHTTPRequest("DELETE", apiUrl, map[string]string{}, map[string]any{}, token)
 
 	if err != nil {
 		return false, err
@@ -133,7 +182,7 @@ func (record *PBRecord) DeleteRecord(collection string, recordId string, token s
 func (record *PBRecord) UpdateRecord(collection string, recordId string, token string, updatedData map[string]any) (map[string]any, error) {
 	apiUrl := fmt.Sprintf("%s/api/collections/%s/records/%s", record.BaseURL, collection, recordId)
 
-	res, err := SendAuthenticatedHTTPRequest("PATCH", apiUrl, map[string]string{}, updatedData, token)
+	res, err := record.Client.SendAuthenticatedHTTPRequest("PATCH", apiUrl, map[string]string{}, updatedData, token)
 
 	if err != nil {
 		return map[string]any{}, err
@@ -150,3 +199,50 @@ func (record *PBRecord) UpdateRecord(collection string, recordId string, token s
 
 	return map[string]any{}, errors.New(pbErr.Message)
 }
+
+// Applies the update built with NewRecordUpdate, it is sent as multipart when it uploads files
+//
+// Without a schema attached the collection schema is loaded first, which requires a token allowed to view it.
+func (record *PBRecord) UpdateRecordWith(collection string, recordId string, token string, update *RecordUpdate) (map[string]any, error) {
+	if update.NeedsSchema() {
+		schema, err := LoadCollectionSchema(&PBCollection{BaseURL: record.BaseURL, Client: record.Client}, token, collection)
+
+		if err != nil {
+			return map[string]any{}, fmt.Errorf("missing-schema|%w", err)
+		}
+
+		update.WithSchema(schema)
+	}
+
+	if !update.HasFiles() {
+		body, err := update.Body()
+
+		if err != nil {
+			return map[string]any{}, err
+		}
+
+		return record.UpdateRecord(collection, recordId, token, body)
+	}
+
+	apiUrl := fmt.Sprintf("%s/api/collections/%s/records/%s", record.BaseURL, collection, recordId)
+
+	body, contentType, err := update.Multipart()
+
+	if err != nil {
+		return map[string]any{}, err
+	}
+
+	res, err := record.Client.SendMultipartRequest("PATCH", apiUrl, map[string]string{}, body, contentType, token)
+
+	if err != nil {
+		return map[string]any{}, err
+	}
+
+	if res.StatusCode == http.StatusOK {
+		return DecodePocketBaseRecord(res), nil
+	}
+
+	pbErr := DecodePocketBaseErrorResponse(res)
+
+	return map[string]any{}, errors.New(pbErr.Message)
+}
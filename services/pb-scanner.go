@@ -0,0 +1,252 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JGugino/pb-go/filter"
+)
+
+// Walks a collection in key order with a key > last key filter instead of page offsets, so inserts and
+// deletes during the scan never shift or repeat records
+//
+// Keys must identify a record uniquely, either id or a sortable field followed by id like created,id.
+// Records are read in ascending key order. Cursor returns a token after every page that Resume accepts
+// to continue a scan in a new process.
+type RecordScanner struct {
+	Records    RecordService
+	Collection string
+	Token      string
+	Keys       []string
+	Filter     string
+	Expand     string
+	Fields     string
+	PerPage    int
+
+	last []any
+	done bool
+}
+
+type scannerCursor struct {
+	Collection string   `json:"c"`
+	Keys       []string `json:"k"`
+	Values     []any    `json:"v"`
+	Done       bool     `json:"d,omitempty"`
+}
+
+// Scans the collection by the keys, id when none are given
+func NewRecordScanner(records RecordService, collection string, token string, keys ...string) *RecordScanner {
+	if len(keys) == 0 {
+		keys = []string{"id"}
+	}
+
+	return &RecordScanner{Records: records, Collection: collection, Token: token, Keys: keys}
+}
+
+func (pb *Pocketbase) NewRecordScanner(collection string, token string, keys ...string) *RecordScanner {
+	return NewRecordScanner(pb.Record, collection, token, keys...)
+}
+
+func (scanner *RecordScanner) Done() bool {
+	return scanner.done
+}
+
+// Returns the next page of records, an empty page once the scan is done
+func (scanner *RecordScanner) Next() ([]map[string]any, error) {
+	if scanner.done {
+		return []map[string]any{}, nil
+	}
+
+	perPage := scanner.PerPage
+
+	if perPage <= 0 {
+		perPage = DEFAULT_QUERY_PER_PAGE
+	}
+
+	//The server never sends more than MAX_LIST_PER_PAGE, a full page of that size is not the end
+	perPage = min(perPage, MAX_LIST_PER_PAGE)
+
+	options, err := scanner.options(perPage)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := scanner.Records.ListRecords(scanner.Collection, scanner.Token, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Items) < perPage {
+		scanner.done = true
+	}
+
+	if len(res.Items) > 0 {
+		last := res.Items[len(res.Items)-1]
+		values := make([]any, len(scanner.Keys))
+
+		for i, key := range scanner.Keys {
+			value, ok := last[key]
+
+			if !ok {
+				return nil, fmt.Errorf("missing-key|%s is missing from the records, add it to Fields", key)
+			}
+
+			if value == nil {
+				return nil, fmt.Errorf("null-key|%s is null on record %v, keys must be set on every record", key, last["id"])
+			}
+
+			values[i] = value
+		}
+
+		scanner.last = values
+	}
+
+	return res.Items, nil
+}
+
+// Calls fn with every remaining record, stops at the first error fn returns
+func (scanner *RecordScanner) Each(fn func(record map[string]any) error) error {
+	for !scanner.done {
+		records, err := scanner.Next()
+
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (scanner *RecordScanner) options(perPage int) (PocketBaseListOptions, error) {
+	var where filter.Expr
+
+	if strings.TrimSpace(scanner.Filter) != "" {
+		parsed, err := filter.Parse(scanner.Filter)
+
+		if err != nil {
+			return PocketBaseListOptions{}, err
+		}
+
+		where = parsed
+	}
+
+	if scanner.last != nil {
+		after, err := keysetAfter(scanner.Keys, scanner.last)
+
+		if err != nil {
+			return PocketBaseListOptions{}, err
+		}
+
+		where = filter.AndAll(where, after)
+	}
+
+	options := PocketBaseListOptions{
+		Page:      1,
+		PerPage:   perPage,
+		Sort:      strings.Join(scanner.Keys, ","),
+		Expand:    scanner.Expand,
+		Fields:    scanner.Fields,
+		SkipTotal: true,
+	}
+
+	if where != nil {
+		options.Filter = where.String()
+	}
+
+	//The keys are needed to build the cursor
+	if options.Fields != "" {
+		fields := strings.Split(options.Fields, ",")
+
+		for _, key := range scanner.Keys {
+			if !slices.Contains(fields, key) {
+				fields = append(fields, key)
+			}
+		}
+
+		options.Fields = strings.Join(fields, ",")
+	}
+
+	return options, nil
+}
+
+// Builds (k1 > v1) || (k1 = v1 && k2 > v2) || ... for the keys, null or missing values can not be compared
+func keysetAfter(keys []string, values []any) (filter.Expr, error) {
+	if len(values) != len(keys) {
+		return nil, fmt.Errorf("missing-key|%d values for the keys %v", len(values), keys)
+	}
+
+	alternatives := make([]filter.Expr, len(keys))
+
+	for i := range keys {
+		if values[i] == nil {
+			return nil, fmt.Errorf("null-key|no value to continue after for %s", keys[i])
+		}
+
+		equal := make([]filter.Expr, 0, i+1)
+
+		for j := 0; j < i; j++ {
+			equal = append(equal, filter.Eq(keys[j], values[j]))
+		}
+
+		alternatives[i] = filter.AndAll(append(equal, filter.Gt(keys[i], values[i]))...)
+	}
+
+	return filter.OrAll(alternatives...), nil
+}
+
+// Token holding the position after the last page read, empty before the first page
+func (scanner *RecordScanner) Cursor() string {
+	if scanner.last == nil && !scanner.done {
+		return ""
+	}
+
+	encoded, _ := json.Marshal(scannerCursor{Collection: scanner.Collection, Keys: scanner.Keys, Values: scanner.last, Done: scanner.done})
+
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// Continues from a token returned by Cursor, the collection and keys have to match the ones it was created with
+func (scanner *RecordScanner) Resume(cursor string) error {
+	if cursor == "" {
+		scanner.last = nil
+		scanner.done = false
+		return nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return fmt.Errorf("invalid-cursor|%w", err)
+	}
+
+	decoded := scannerCursor{}
+
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return fmt.Errorf("invalid-cursor|%w", err)
+	}
+
+	if decoded.Collection != scanner.Collection || !slices.Equal(decoded.Keys, scanner.Keys) {
+		return errors.New("invalid-cursor|the cursor was created for another collection or keys")
+	}
+
+	if decoded.Values != nil && len(decoded.Values) != len(decoded.Keys) {
+		return errors.New("invalid-cursor|key values do not match the keys")
+	}
+
+	scanner.last = decoded.Values
+	scanner.done = decoded.Done
+
+	return nil
+}
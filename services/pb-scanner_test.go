@@ -0,0 +1,101 @@
+package services_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func scannedPosts(t *testing.T) (*pbtest.Server, *services.Pocketbase) {
+	t.Helper()
+
+	server, pb, _ := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", ListRule: pbtest.Rule(""), Fields: []map[string]any{{"name": "views", "type": "number"}}})
+
+	return server, pb
+}
+
+func TestScannerKeepsFilterPrecedence(t *testing.T) {
+	server, pb := scannedPosts(t)
+
+	for i := 0; i < 15; i++ {
+		//Two records per value so the scan has to continue on id within equal views
+		server.Seed("posts", map[string]any{"views": float64(i)}, map[string]any{"views": float64(i)})
+	}
+
+	scanner := pb.NewRecordScanner("posts", "", "views", "id")
+	scanner.Filter = "views >= 12 || views < 3 && views != 0"
+	scanner.PerPage = 3
+
+	views := []float64{}
+
+	err := scanner.Each(func(record map[string]any) error {
+		views = append(views, record["views"].(float64))
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []float64{1, 1, 2, 2, 12, 12, 13, 13, 14, 14}
+
+	if len(views) != len(expected) {
+		t.Fatalf("scanned %v, expected %v", views, expected)
+	}
+
+	for i := range expected {
+		if views[i] != expected[i] {
+			t.Fatalf("scanned %v, expected %v", views, expected)
+		}
+	}
+}
+
+func TestScannerRejectsNullKeys(t *testing.T) {
+	server, pb := scannedPosts(t)
+	server.Seed("posts", map[string]any{"views": float64(1)}, map[string]any{"views": nil})
+
+	scanner := pb.NewRecordScanner("posts", "", "views", "id")
+	scanner.Filter = "views = null"
+
+	if _, err := scanner.Next(); err == nil || !strings.HasPrefix(err.Error(), "null-key") {
+		t.Fatalf("expected null-key, got %v", err)
+	}
+
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"c":"posts","k":["views","id"],"v":[null,"abc"]}`))
+	resumed := pb.NewRecordScanner("posts", "", "views", "id")
+
+	if err := resumed.Resume(cursor); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := resumed.Next(); err == nil || !strings.HasPrefix(err.Error(), "null-key") {
+		t.Fatalf("expected null-key from the resumed cursor, got %v", err)
+	}
+}
+
+func TestScannerContinuesPastThePerPageCap(t *testing.T) {
+	server, pb := scannedPosts(t)
+
+	for i := 0; i < services.MAX_LIST_PER_PAGE+5; i++ {
+		server.Seed("posts", map[string]any{"views": float64(i)})
+	}
+
+	scanner := pb.NewRecordScanner("posts", "")
+	scanner.PerPage = 5000
+
+	first, err := scanner.Next()
+
+	if err != nil || len(first) != services.MAX_LIST_PER_PAGE || scanner.Done() {
+		t.Fatalf("first page = %d records, done %t, %v", len(first), scanner.Done(), err)
+	}
+
+	rest, err := scanner.Next()
+
+	if err != nil || len(rest) != 5 || !scanner.Done() {
+		t.Fatalf("second page = %d records, done %t, %v", len(rest), scanner.Done(), err)
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Field definition of a collection, Options holds the full definition including the type specific settings
+type CollectionField struct {
+	Id       string         `json:"id"`
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	System   bool           `json:"system"`
+	Hidden   bool           `json:"hidden"`
+	Required bool           `json:"required"`
+	Options  map[string]any `json:"-"`
+
+	//Compiled pattern option, set by NewCollectionSchema so validation does not compile it for every value
+	pattern *regexp.Regexp
+}
+
+// Fields of a collection keyed by name, used to check queries and data locally before they are sent
+type CollectionSchema struct {
+	Id     string
+	Name   string
+	Type   string
+	Fields []CollectionField
+	byName map[string]int
+}
+
+func NewCollectionSchema(collection PocketBaseCollectionResponse) *CollectionSchema {
+	schema := &CollectionSchema{
+		Id:     collection.Id,
+		Name:   collection.Name,
+		Type:   collection.Type,
+		byName: map[string]int{},
+	}
+
+	for _, raw := range collection.Fields {
+		field := CollectionField{Options: raw}
+
+		encoded, _ := json.Marshal(raw)
+		json.Unmarshal(encoded, &field)
+
+		if pattern := field.OptionString("pattern"); pattern != "" {
+			field.pattern, _ = regexp.Compile(pattern)
+		}
+
+		schema.byName[field.Name] = len(schema.Fields)
+		schema.Fields = append(schema.Fields, field)
+	}
+
+	return schema
+}
+
+// Fetches the collection and builds its schema, requires a token allowed to view collections
+func LoadCollectionSchema(collections CollectionService, token string, collection string) (*CollectionSchema, error) {
+	res, err := collections.ViewCollection(token, collection)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCollectionSchema(res), nil
+}
+
+func (schema *CollectionSchema) Field(name string) (CollectionField, bool) {
+	index, ok := schema.byName[name]
+
+	if !ok {
+		return CollectionField{}, false
+	}
+
+	return schema.Fields[index], true
+}
+
+// Checks a field path like author.name, only the first segment can be checked without the related schema
+func (schema *CollectionSchema) CheckField(path string) error {
+	name, _, _ := strings.Cut(path, ".")
+
+	if name == "id" || strings.Contains(name, "_via_") {
+		return nil
+	}
+
+	if _, ok := schema.Field(name); !ok {
+		return fmt.Errorf("unknown-field|%q is not a field of %s", name, schema.Name)
+	}
+
+	return nil
+}
+
+// Id of the related collection for relation fields
+func (field CollectionField) CollectionId() string {
+	id, _ := field.Options["collectionId"].(string)
+	return id
+}
+
+// Maximum number of values, fields holding a single value return 1
+func (field CollectionField) MaxSelect() int {
+	maxSelect, _ := field.OptionNumber("maxSelect")
+	return max(int(maxSelect), 1)
+}
+
+// Numeric option of the field like min or maxSize, false when it is missing or null
+func (field CollectionField) OptionNumber(key string) (float64, bool) {
+	switch v := field.Options[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	}
+
+	return 0, false
+}
+
+func (field CollectionField) OptionString(key string) string {
+	value, _ := field.Options[key].(string)
+	return value
+}
+
+func (field CollectionField) OptionBool(key string) bool {
+	value, _ := field.Options[key].(bool)
+	return value
+}
+
+// List option of the field like values or mimeTypes, every item turned into text
+func (field CollectionField) OptionStrings(key string) []string {
+	values := []string{}
+
+	switch v := field.Options[key].(type) {
+	case []any:
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+	case []string:
+		values = append(values, v...)
+	}
+
+	return values
+}
+
+// Whether the field holds a list of values, true for select, relation and file fields with maxSelect above 1
+func (field CollectionField) IsMultiple() bool {
+	switch field.Type {
+	case "select", "relation", "file":
+		return field.MaxSelect() > 1
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JGugino/pb-go/filter"
+)
+
+// Value of a date or autodate field, an empty value decodes to the zero time and the zero time encodes to ""
+type DateTime struct {
+	time.Time
+}
+
+func NewDateTime(t time.Time) DateTime {
+	return DateTime{Time: t.UTC()}
+}
+
+// Formats the time like PocketBase, the zero time gives ""
+func (d DateTime) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.UTC().Format(filter.DateTimeLayout)
+}
+
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	var text string
+
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("invalid-datetime|%s", data)
+	}
+
+	if text == "" {
+		d.Time = time.Time{}
+		return nil
+	}
+
+	//Older instances and hand written values use RFC 3339
+	for _, layout := range []string{filter.DateTimeLayout, time.RFC3339Nano, "2006-01-02 15:04:05Z07:00"} {
+		if parsed, err := time.Parse(layout, strings.TrimSpace(text)); err == nil {
+			d.Time = parsed.UTC()
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid-datetime|%q", text)
+}
+
+// Value of a geoPoint field
+type GeoPoint struct {
+	Lon float64 `json:"lon"`
+	Lat float64 `json:"lat"`
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+)
+
+// A file to upload, Reader is read once when the request body is built
+type File struct {
+	Name   string
+	Reader io.Reader
+}
+
+func NewFile(name string, reader io.Reader) File {
+	return File{Name: name, Reader: reader}
+}
+
+// Builds the body of a record update with the PocketBase modifiers instead of hand written keys
+//
+// Set replaces a value, Increment/Decrement use "field+"/"field-", Append/Prepend use "field+"/"+field",
+// Remove and RemoveFile use "field-". Every modifier is checked against the field type of the schema and
+// the first misfit is returned by Body and Multipart. Without WithSchema, UpdateRecordWith loads the schema
+// of the collection, Unchecked skips the checks for tokens that are not allowed to view collections.
+type RecordUpdate struct {
+	keys      []string
+	values    map[string]any
+	schema    *CollectionSchema
+	checks    []func(schema *CollectionSchema) error
+	unchecked bool
+}
+
+func NewRecordUpdate() *RecordUpdate {
+	return &RecordUpdate{values: map[string]any{}}
+}
+
+// Checks every modifier against the field types of the schema, including the ones added before
+func (update *RecordUpdate) WithSchema(schema *CollectionSchema) *RecordUpdate {
+	update.schema = schema
+	return update
+}
+
+// Sends the update without checking the modifiers, the server still rejects the ones that do not fit
+func (update *RecordUpdate) Unchecked() *RecordUpdate {
+	update.unchecked = true
+	return update
+}
+
+// Whether the schema has to be loaded before the update can be checked
+func (update *RecordUpdate) NeedsSchema() bool {
+	return update.schema == nil && !update.unchecked
+}
+
+func (update *RecordUpdate) Set(field string, value any) *RecordUpdate {
+	_, isFile := value.(File)
+	_, isFiles := value.([]File)
+
+	if isFile || isFiles {
+		update.check(field, "file")
+	} else {
+		update.check(field)
+	}
+
+	return update.put(field, value)
+}
+
+// Clears the value, PocketBase stores the zero value of the field type
+func (update *RecordUpdate) Unset(field string) *RecordUpdate {
+	update.check(field)
+	return update.put(field, nil)
+}
+
+func (update *RecordUpdate) Increment(field string, by float64) *RecordUpdate {
+	update.check(field, "number")
+	return update.put(field+"+", by)
+}
+
+func (update *RecordUpdate) Decrement(field string, by float64) *RecordUpdate {
+	update.check(field, "number")
+	return update.put(field+"-", by)
+}
+
+// Adds values to the end of a multiple select, relation or file field, files are passed as File
+func (update *RecordUpdate) Append(field string, values ...any) *RecordUpdate {
+	update.checkMultiple(field, values)
+	return update.add(field+"+", values)
+}
+
+// Adds values to the start of a multiple select, relation or file field
+func (update *RecordUpdate) Prepend(field string, values ...any) *RecordUpdate {
+	update.checkMultiple(field, values)
+	return update.add("+"+field, values)
+}
+
+// Removes values from a select or relation field
+func (update *RecordUpdate) Remove(field string, values ...any) *RecordUpdate {
+	update.check(field, "select", "relation")
+	return update.add(field+"-", values)
+}
+
+// Removes files by their stored filename
+func (update *RecordUpdate) RemoveFile(field string, filenames ...string) *RecordUpdate {
+	update.check(field, "file")
+
+	values := make([]any, len(filenames))
+
+	for i, filename := range filenames {
+		values[i] = filename
+	}
+
+	return update.add(field+"-", values)
+}
+
+func (update *RecordUpdate) put(key string, value any) *RecordUpdate {
+	if _, ok := update.values[key]; !ok {
+		update.keys = append(update.keys, key)
+	}
+
+	update.values[key] = value
+	return update
+}
+
+// Merges values into the list already set for the key so repeated calls add up
+func (update *RecordUpdate) add(key string, values []any) *RecordUpdate {
+	current, _ := update.values[key].([]any)
+	return update.put(key, append(current, values...))
+}
+
+// Runs the checks of every modifier against the schema, the first misfit is returned
+func (update *RecordUpdate) validate() error {
+	if update.unchecked {
+		return nil
+	}
+
+	if update.schema == nil {
+		return errors.New("missing-schema|attach one with WithSchema, send the update with UpdateRecordWith or mark it Unchecked")
+	}
+
+	for _, check := range update.checks {
+		if err := check(update.schema); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checks the field exists and has one of the types once the schema is known, no types accepts any type
+func (update *RecordUpdate) check(name string, types ...string) {
+	update.checks = append(update.checks, func(schema *CollectionSchema) error {
+		_, err := checkFieldType(schema, name, types)
+		return err
+	})
+}
+
+func (update *RecordUpdate) checkMultiple(name string, values []any) {
+	update.checks = append(update.checks, func(schema *CollectionSchema) error {
+		for _, value := range values {
+			if _, ok := value.(File); ok {
+				if _, err := checkFieldType(schema, name, []string{"file"}); err != nil {
+					return err
+				}
+			}
+		}
+
+		field, err := checkFieldType(schema, name, []string{"select", "relation", "file"})
+
+		if err != nil {
+			return err
+		}
+
+		if field.Name != "" && !field.IsMultiple() {
+			return fmt.Errorf("invalid-modifier|%s holds a single value, use Set instead", name)
+		}
+
+		return nil
+	})
+}
+
+func checkFieldType(schema *CollectionSchema, name string, types []string) (CollectionField, error) {
+	if err := schema.CheckField(name); err != nil {
+		return CollectionField{}, err
+	}
+
+	field, ok := schema.Field(name)
+
+	if !ok || len(types) == 0 {
+		return field, nil
+	}
+
+	for _, fieldType := range types {
+		if field.Type == fieldType {
+			return field, nil
+		}
+	}
+
+	return field, fmt.Errorf("invalid-modifier|%s is a %s field, expected %v", name, field.Type, types)
+}
+
+// Whether the update uploads files and has to be sent as multipart
+func (update *RecordUpdate) HasFiles() bool {
+	for _, value := range update.values {
+		if len(filesOf(value)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Body for a JSON request, fails when the update uploads files
+func (update *RecordUpdate) Body() (map[string]any, error) {
+	if err := update.validate(); err != nil {
+		return nil, err
+	}
+
+	if update.HasFiles() {
+		return nil, errors.New("invalid-body|files can only be sent as multipart")
+	}
+
+	body := map[string]any{}
+
+	for _, key := range update.keys {
+		body[key] = update.values[key]
+	}
+
+	return body, nil
+}
+
+// Body for a multipart request, files become parts and every other value is sent in @jsonPayload
+func (update *RecordUpdate) Multipart() (*bytes.Buffer, string, error) {
+	if err := update.validate(); err != nil {
+		return nil, "", err
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+	payload := map[string]any{}
+
+	for _, key := range update.keys {
+		value := update.values[key]
+		files := filesOf(value)
+
+		if len(files) == 0 {
+			payload[key] = value
+			continue
+		}
+
+		//Mixing files with other values in a single modifier is not supported by PocketBase
+		if list, ok := value.([]any); ok && len(list) != len(files) {
+			return nil, "", fmt.Errorf("invalid-body|%s mixes files with other values", key)
+		}
+
+		for _, file := range files {
+			part, err := writer.CreateFormFile(key, file.Name)
+
+			if err != nil {
+				return nil, "", err
+			}
+
+			if _, err := io.Copy(part, file.Reader); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if len(payload) > 0 {
+		encoded, err := json.Marshal(payload)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		if err := writer.WriteField("@jsonPayload", string(encoded)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buffer, writer.FormDataContentType(), nil
+}
+
+func filesOf(value any) []File {
+	switch v := value.(type) {
+	case File:
+		return []File{v}
+	case []File:
+		return v
+	case []any:
+		files := []File{}
+
+		for _, item := range v {
+			if file, ok := item.(File); ok {
+				files = append(files, file)
+			}
+		}
+
+		return files
+	}
+
+	return nil
+}
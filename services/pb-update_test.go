@@ -0,0 +1,99 @@
+package services_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func postsSchema() *services.CollectionSchema {
+	return services.NewCollectionSchema(services.PocketBaseCollectionResponse{
+		Id:   "pbc_posts",
+		Name: "posts",
+		Type: "base",
+		Fields: []map[string]any{
+			{"name": "title", "type": "text"},
+			{"name": "views", "type": "number"},
+			{"name": "tags", "type": "select", "maxSelect": 5, "values": []any{"go", "js"}},
+			{"name": "status", "type": "select", "maxSelect": 1, "values": []any{"draft"}},
+		},
+	})
+}
+
+func TestUpdateWithoutSchemaFailsLocally(t *testing.T) {
+	_, err := services.NewRecordUpdate().Increment("title", 1).Body()
+
+	if err == nil || !strings.HasPrefix(err.Error(), "missing-schema") {
+		t.Fatalf("expected missing-schema, got %v", err)
+	}
+
+	body, err := services.NewRecordUpdate().Increment("title", 1).Unchecked().Body()
+
+	if err != nil || body["title+"] != float64(1) {
+		t.Fatalf("unchecked body = %v, %v", body, err)
+	}
+}
+
+func TestUpdateChecksModifiersAddedBeforeTheSchema(t *testing.T) {
+	cases := map[string]*services.RecordUpdate{
+		"invalid-modifier|title is a text field": services.NewRecordUpdate().Increment("title", 1),
+		"invalid-modifier|status holds a single": services.NewRecordUpdate().Append("status", "draft"),
+		"unknown-field":                          services.NewRecordUpdate().Set("missing", 1),
+	}
+
+	for expected, update := range cases {
+		_, err := update.WithSchema(postsSchema()).Body()
+
+		if err == nil || !strings.HasPrefix(err.Error(), expected) {
+			t.Errorf("expected %s, got %v", expected, err)
+		}
+	}
+
+	body, err := services.NewRecordUpdate().Increment("views", 2).Append("tags", "go").Remove("tags", "js").WithSchema(postsSchema()).Body()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body["views+"] != float64(2) || len(body["tags+"].([]any)) != 1 || len(body["tags-"].([]any)) != 1 {
+		t.Fatalf("unexpected body %v", body)
+	}
+}
+
+func TestUpdateRecordWithLoadsTheSchema(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	server.AddCollection(pbtest.Collection{Name: "posts", UpdateRule: pbtest.Rule(""), Fields: []map[string]any{
+		{"name": "title", "type": "text"},
+		{"name": "views", "type": "number"},
+	}})
+
+	record := server.Seed("posts", map[string]any{"title": "a", "views": float64(1)})[0]
+
+	//Only superusers can view the schema, the update itself would be allowed
+	_, err := pb.Record.UpdateRecordWith("posts", record["id"].(string), "", services.NewRecordUpdate().Increment("views", 2))
+
+	if err == nil || !strings.HasPrefix(err.Error(), "missing-schema") {
+		t.Fatalf("expected missing-schema without a token, got %v", err)
+	}
+
+	_, err = pb.Record.UpdateRecordWith("posts", record["id"].(string), token, services.NewRecordUpdate().Increment("title", 1))
+
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid-modifier") {
+		t.Fatalf("expected the loaded schema to reject the modifier, got %v", err)
+	}
+
+	updated, err := pb.Record.UpdateRecordWith("posts", record["id"].(string), token, services.NewRecordUpdate().Increment("views", 2))
+
+	if err != nil || updated["views"] != float64(3) {
+		t.Fatalf("update = %v, %v", updated, err)
+	}
+
+	//Unchecked updates never load the schema
+	updated, err = pb.Record.UpdateRecordWith("posts", record["id"].(string), "", services.NewRecordUpdate().Increment("views", 1).Unchecked())
+
+	if err != nil || updated["views"] != float64(4) {
+		t.Fatalf("unchecked update without a token = %v, %v", updated, err)
+	}
+}
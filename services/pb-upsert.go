@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Attempts before Upsert and FindOrCreate give up on a record that keeps changing under them
+const UPSERT_MAX_ATTEMPTS = 3
+
+type UpsertResult struct {
+	Record  map[string]any
+	Created bool
+}
+
+// Updates the record matching the filter or creates it from data when nothing matches
+//
+// The write is a single batch upsert keyed by the id of the matching record, or without an id when nothing
+// matched, whenever the instance supports batches, plain create and update requests are used otherwise.
+// When two workers create the same record at once the unique index rejects one of them, it then finds
+// and updates the record the other one created.
+func (record *PBRecord) Upsert(collection string, token string, matchFilter string, data map[string]any) (UpsertResult, error) {
+	return record.upsert(collection, token, matchFilter, data, true)
+}
+
+// Returns the record matching the filter or creates it from defaults when nothing matches
+func (record *PBRecord) FindOrCreate(collection string, token string, matchFilter string, defaults map[string]any) (UpsertResult, error) {
+	return record.upsert(collection, token, matchFilter, defaults, false)
+}
+
+func (record *PBRecord) upsert(collection string, token string, matchFilter string, data map[string]any, update bool) (UpsertResult, error) {
+	var resErr *ResponseError
+
+	for attempt := 0; attempt < UPSERT_MAX_ATTEMPTS; attempt++ {
+		existing, err := record.findOne(collection, token, matchFilter)
+
+		switch {
+		case err == nil && !update:
+			return UpsertResult{Record: existing}, nil
+		case err == nil, errors.Is(err, ErrNotFound):
+			result, err := record.write(collection, token, existing, data)
+
+			//Another worker created it first or the record was deleted after it was found, look it up again
+			if errors.As(err, &resErr) && (resErr.HasCode(ERROR_CODE_NOT_UNIQUE) || resErr.IsNotFound()) {
+				continue
+			}
+
+			return result, err
+		default:
+			return UpsertResult{}, err
+		}
+	}
+
+	return UpsertResult{}, fmt.Errorf("upsert-conflict|%s still conflicting after %d attempts", collection, UPSERT_MAX_ATTEMPTS)
+}
+
+// Writes data over the existing record or creates it when existing is nil
+func (record *PBRecord) write(collection string, token string, existing map[string]any, data map[string]any) (UpsertResult, error) {
+	id, _ := existing["id"].(string)
+
+	if record.BatchSupported() {
+		body := map[string]any{}
+
+		for k, v := range data {
+			body[k] = v
+		}
+
+		if id != "" {
+			body["id"] = id
+		}
+
+		results, err := record.Batch(token, []BatchRequest{BatchUpsert(collection, body)})
+
+		if err == nil {
+			if len(results) == 0 {
+				return UpsertResult{}, fmt.Errorf("invalid-batch-response|no result for the %s upsert", collection)
+			}
+
+			//A record deleted in the meantime is created again by the upsert
+			written := results[0].Body
+			return UpsertResult{Record: written, Created: existing == nil || written["created"] != existing["created"]}, nil
+		}
+
+		if !errors.Is(err, ErrBatchUnsupported) {
+			return UpsertResult{}, err
+		}
+	}
+
+	if existing == nil {
+		created, err := record.create(collection, token, data)
+
+		if err != nil {
+			return UpsertResult{}, err
+		}
+
+		return UpsertResult{Record: created, Created: true}, nil
+	}
+
+	updated, err := record.send("PATCH", fmt.Sprintf("%s/api/collections/%s/records/%s", record.BaseURL, collection, id), token, data)
+
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	return UpsertResult{Record: updated}, nil
+}
+
+// Returns the only record matching the filter, ErrNotFound when none does
+func (record *PBRecord) findOne(collection string, token string, matchFilter string) (map[string]any, error) {
+	res, err := record.uncached().ListRecords(collection, token, PocketBaseListOptions{Page: 1, PerPage: 2, Filter: matchFilter, SkipTotal: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(res.Items) {
+	case 0:
+		return nil, ErrNotFound
+	case 1:
+		return res.Items[0], nil
+	}
+
+	return nil, fmt.Errorf("ambiguous-match|more than one %s record matches %s", collection, matchFilter)
+}
+
+func (record *PBRecord) create(collection string, token string, data map[string]any) (map[string]any, error) {
+	return record.send("POST", fmt.Sprintf("%s/api/collections/%s/records", record.BaseURL, collection), token, data)
+}
+
+// Copy of the service whose reads skip the response cache, for reads that decide what gets written
+func (record *PBRecord) uncached() *PBRecord {
+	if record.Client == nil || record.Client.Cache == nil {
+		return record
+	}
+
+	return &PBRecord{BaseURL: record.BaseURL, Client: record.Client.WithContext(WithoutCache(record.Client.context()))}
+}
+
+// Sends a record request and keeps the error details in a *ResponseError
+func (record *PBRecord) send(method string, apiUrl string, token string, data map[string]any) (map[string]any, error) {
+	res, err := record.Client.SendAuthenticatedHTTPRequest(method, apiUrl, map[string]string{}, data, token)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, NewResponseError(res)
+	}
+
+	return DecodePocketBaseRecord(res), nil
+}
@@ -0,0 +1,133 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JGugino/pb-go/pbtest"
+	"github.com/JGugino/pb-go/services"
+)
+
+func addPages(server *pbtest.Server, batch bool) {
+	server.AddCollection(pbtest.Collection{
+		Name: "pages",
+		Fields: []map[string]any{
+			{"name": "slug", "type": "text"},
+			{"name": "title", "type": "text"},
+		},
+		Indexes: []string{"CREATE UNIQUE INDEX idx_slug ON pages (slug)"},
+	})
+
+	if batch {
+		server.EnableBatch(0)
+	}
+}
+
+func writes(server *pbtest.Server) []string {
+	paths := []string{}
+
+	for _, request := range server.Requests() {
+		if request.Method != "GET" {
+			paths = append(paths, request.Method+" "+request.Path)
+		}
+	}
+
+	return paths
+}
+
+func TestUpsertSendsBothBranchesAsOneBatch(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	addPages(server, true)
+
+	created, err := pb.Record.Upsert("pages", token, `slug = "home"`, map[string]any{"slug": "home", "title": "Home"})
+
+	if err != nil || !created.Created {
+		t.Fatalf("create = %v, %v", created, err)
+	}
+
+	updated, err := pb.Record.Upsert("pages", token, `slug = "home"`, map[string]any{"slug": "home", "title": "Start"})
+
+	if err != nil || updated.Created || updated.Record["id"] != created.Record["id"] || updated.Record["title"] != "Start" {
+		t.Fatalf("update = %v, %v", updated, err)
+	}
+
+	paths := writes(server)
+
+	if len(paths) != 2 || paths[0] != "POST /api/batch" || paths[1] != "POST /api/batch" {
+		t.Fatalf("writes = %v, expected two batches", paths)
+	}
+}
+
+func TestUpsertFallsBackWithoutBatch(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	addPages(server, false)
+
+	if _, err := pb.Record.Upsert("pages", token, `slug = "home"`, map[string]any{"slug": "home", "title": "Home"}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := pb.Record.Upsert("pages", token, `slug = "home"`, map[string]any{"slug": "home", "title": "Start"})
+
+	if err != nil || updated.Created || updated.Record["title"] != "Start" {
+		t.Fatalf("update = %v, %v", updated, err)
+	}
+
+	//Only the first write tries the batch, the rejection is remembered by the client
+	paths := writes(server)
+	expected := []string{"POST /api/batch", "POST /api/collections/pages/records", "PATCH /api/collections/pages/records/" + updated.Record["id"].(string)}
+
+	if len(paths) != len(expected) {
+		t.Fatalf("writes = %v, expected %v", paths, expected)
+	}
+
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Fatalf("writes = %v, expected %v", paths, expected)
+		}
+	}
+
+	//Another client still tries batches against the same instance
+	other, err := services.New(server.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !other.Record.(*services.PBRecord).BatchSupported() {
+		t.Fatal("batch support leaked between clients")
+	}
+}
+
+func TestFindOrCreateDoesNotUpdate(t *testing.T) {
+	server, pb, token := pbtest.NewClient(t)
+	addPages(server, true)
+	server.Seed("pages", map[string]any{"slug": "home", "title": "Home"})
+
+	found, err := pb.Record.FindOrCreate("pages", token, `slug = "home"`, map[string]any{"slug": "home", "title": "Other"})
+
+	if err != nil || found.Created || found.Record["title"] != "Home" {
+		t.Fatalf("find = %v, %v", found, err)
+	}
+
+	if paths := writes(server); len(paths) != 0 {
+		t.Fatalf("unexpected writes %v", paths)
+	}
+}
+
+func TestBatchWithoutClientReportsUnsupported(t *testing.T) {
+	server, _, token := pbtest.NewClient(t)
+	addPages(server, false)
+
+	record := &services.PBRecord{BaseURL: server.URL}
+
+	if _, err := record.Batch(token, []services.BatchRequest{services.BatchUpsert("pages", map[string]any{"slug": "home"})}); !errors.Is(err, services.ErrBatchUnsupported) {
+		t.Fatalf("expected ErrBatchUnsupported, got %v", err)
+	}
+
+	//Without a client to remember the rejection every upsert tries the batch first and falls back
+	created, err := record.Upsert("pages", token, `slug = "home"`, map[string]any{"slug": "home", "title": "Home"})
+
+	if err != nil || !created.Created {
+		t.Fatalf("upsert = %v, %v", created, err)
+	}
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 )
 
+// Builds the query string for list endpoints, values are escaped so filters can hold any character
 func ConstructQueryStringForAPI(options PocketBaseListOptions) (output string, hasOptions bool) {
 	var queryString strings.Builder
 	queryString.WriteString("?")
@@ -15,19 +17,23 @@ func ConstructQueryStringForAPI(options PocketBaseListOptions) (output string, h
 	formattedOptions = append(formattedOptions, fmt.Sprintf("perPage=%d", options.PerPage))
 
 	if len(options.Expand) > 0 {
-		formattedOptions = append(formattedOptions, fmt.Sprintf("expand=%s", options.Expand))
+		formattedOptions = append(formattedOptions, "expand="+url.QueryEscape(options.Expand))
 	}
 
 	if len(options.Fields) > 0 {
-		formattedOptions = append(formattedOptions, fmt.Sprintf("fields=%s", options.Fields))
+		formattedOptions = append(formattedOptions, "fields="+url.QueryEscape(options.Fields))
 	}
 
 	if len(options.Filter) > 0 {
-		formattedOptions = append(formattedOptions, fmt.Sprintf("filter=(%s)", options.Filter))
+		formattedOptions = append(formattedOptions, "filter="+url.QueryEscape("("+options.Filter+")"))
 	}
 
 	if len(options.Sort) > 0 {
-		formattedOptions = append(formattedOptions, fmt.Sprintf("sort=%s", options.Sort))
+		formattedOptions = append(formattedOptions, "sort="+url.QueryEscape(options.Sort))
+	}
+
+	if options.SkipTotal {
+		formattedOptions = append(formattedOptions, "skipTotal=true")
 	}
 
 	if len(formattedOptions) > 0 {
@@ -0,0 +1,632 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Shape of record ids when the related collection is unknown, PocketBase only allows these characters in ids
+var looseIdPattern = regexp.MustCompile(`^[\w-]+$`)
+
+// Checks record bodies against the collection schemas before they are sent so every problem is reported at once
+//
+// Schemas are fetched on first use and cached per collection, Forget drops a cached schema after it changed.
+// Related collections the server answers with 403 or 404 for are remembered as well, their ids are checked for
+// the loose id shape until Forget. Other failed lookups are retried on the next relation. Errors are a *ResponseError with the status, message and per field data the
+// server would send.
+type RecordValidator struct {
+	Collections CollectionService
+	Token       string
+
+	mu      sync.Mutex
+	schemas map[string]*CollectionSchema
+	missing map[string]bool
+}
+
+func NewRecordValidator(collections CollectionService, token string) *RecordValidator {
+	return &RecordValidator{Collections: collections, Token: token}
+}
+
+// Creates a validator on top of the collection service of the client, the token has to be allowed to view collections
+func (pb *Pocketbase) NewRecordValidator(token string) *RecordValidator {
+	return NewRecordValidator(pb.Collection, token)
+}
+
+// Cached schema of the collection by name or id, fetched when it is not cached yet
+func (validator *RecordValidator) Schema(collection string) (*CollectionSchema, error) {
+	validator.mu.Lock()
+	schema, ok := validator.schemas[collection]
+	validator.mu.Unlock()
+
+	if ok {
+		return schema, nil
+	}
+
+	schema, err := LoadCollectionSchema(validator.Collections, validator.Token, collection)
+
+	if err != nil {
+		return nil, err
+	}
+
+	validator.mu.Lock()
+	defer validator.mu.Unlock()
+
+	if validator.schemas == nil {
+		validator.schemas = map[string]*CollectionSchema{}
+	}
+
+	validator.schemas[schema.Id] = schema
+	validator.schemas[schema.Name] = schema
+	validator.schemas[collection] = schema
+
+	return schema, nil
+}
+
+// Drops the cached schema or failed lookup of the collection, no collection drops everything cached
+func (validator *RecordValidator) Forget(collection ...string) {
+	validator.mu.Lock()
+	defer validator.mu.Unlock()
+
+	if len(collection) == 0 {
+		validator.schemas = nil
+		validator.missing = nil
+		return
+	}
+
+	for _, name := range collection {
+		if schema, ok := validator.schemas[name]; ok {
+			delete(validator.schemas, schema.Id)
+			delete(validator.schemas, schema.Name)
+			delete(validator.missing, schema.Id)
+			delete(validator.missing, schema.Name)
+		} else if !validator.missing[name] {
+			//Failed lookups are kept under the relation collectionId, a name that was never resolved can be any of them
+			validator.missing = nil
+		}
+
+		delete(validator.missing, name)
+		delete(validator.schemas, name)
+	}
+}
+
+// Checks the body of a new record, nil when it passes
+func (validator *RecordValidator) ValidateCreate(collection string, data map[string]any) error {
+	schema, err := validator.Schema(collection)
+
+	if err != nil {
+		return err
+	}
+
+	return schema.validate(data, false, validator.related)
+}
+
+// Checks the body of an update, only the fields in data are checked as the others keep their stored values
+func (validator *RecordValidator) ValidateUpdate(collection string, data map[string]any) error {
+	schema, err := validator.Schema(collection)
+
+	if err != nil {
+		return err
+	}
+
+	return schema.validate(data, true, validator.related)
+}
+
+// Relations fall back to the loose id shape when the related collection can not be viewed
+func (validator *RecordValidator) related(collectionId string) *CollectionSchema {
+	validator.mu.Lock()
+	missing := validator.missing[collectionId]
+	validator.mu.Unlock()
+
+	if missing {
+		return nil
+	}
+
+	schema, err := validator.Schema(collectionId)
+
+	if err == nil {
+		return schema
+	}
+
+	//Timeouts and server errors are retried by the next relation instead of being remembered
+	if !hiddenCollection(err) {
+		return nil
+	}
+
+	validator.mu.Lock()
+	defer validator.mu.Unlock()
+
+	if validator.missing == nil {
+		validator.missing = map[string]bool{}
+	}
+
+	validator.missing[collectionId] = true
+
+	return nil
+}
+
+// Whether the collection lookup failed because the collection can not be viewed rather than a failed request
+func hiddenCollection(err error) bool {
+	responseErr := &ResponseError{}
+
+	if !errors.As(err, &responseErr) {
+		return false
+	}
+
+	return responseErr.Status == http.StatusForbidden || responseErr.Status == http.StatusNotFound
+}
+
+// Checks the body of a new record without a validator, relation ids are only checked for their loose shape
+func (schema *CollectionSchema) ValidateCreate(data map[string]any) error {
+	return schema.validate(data, false, nil)
+}
+
+// Checks the fields present in the body of an update without a validator
+func (schema *CollectionSchema) ValidateUpdate(data map[string]any) error {
+	return schema.validate(data, true, nil)
+}
+
+func (schema *CollectionSchema) validate(data map[string]any, partial bool, related func(collectionId string) *CollectionSchema) error {
+	errs := map[string]any{}
+
+	for _, field := range schema.Fields {
+		value, present := data[field.Name]
+
+		//Autodate fields are set by PocketBase and ignored in request bodies
+		if field.Type == "autodate" || partial && !present {
+			continue
+		}
+
+		if code, message := field.check(value, related); code != "" {
+			errs[field.Name] = map[string]any{"code": code, "message": message}
+		}
+	}
+
+	password, _ := data["password"].(string)
+	passwordConfirm, _ := data["passwordConfirm"].(string)
+
+	if schema.Type == "auth" && password != "" && password != passwordConfirm {
+		errs["passwordConfirm"] = map[string]any{"code": "validation_values_mismatch", "message": "Values don't match."}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	message := "Failed to create record."
+
+	if partial {
+		message = "Failed to update record."
+	}
+
+	return &ResponseError{Status: http.StatusBadRequest, Message: message, Data: errs}
+}
+
+// Code and message of the first problem with the value, an empty code when it is valid
+func (field CollectionField) check(value any, related func(collectionId string) *CollectionSchema) (string, string) {
+	if field.Type == "file" {
+		return field.checkFiles(value)
+	}
+
+	normalized, ok := normalizeValue(value)
+
+	if !ok {
+		return "validation_invalid_type", "Invalid value type."
+	}
+
+	if isBlank(field.Type, normalized) {
+		//Ids and autogenerated text are filled in by PocketBase when left out
+		if field.Required && field.OptionString("autogeneratePattern") == "" {
+			return "validation_required", "Cannot be blank."
+		}
+
+		return "", ""
+	}
+
+	switch field.Type {
+	case "text", "password", "editor", "email", "url":
+		text, ok := normalized.(string)
+
+		if !ok {
+			return "validation_invalid_type", "Invalid value type."
+		}
+
+		return field.checkText(text)
+	case "number":
+		number, ok := numberOf(normalized)
+
+		if !ok {
+			return "validation_invalid_type", "Invalid value type."
+		}
+
+		return field.checkNumber(number)
+	case "bool":
+		if _, ok := boolOf(normalized); !ok {
+			return "validation_invalid_type", "Invalid value type."
+		}
+	case "date":
+		return field.checkDate(normalized)
+	case "select":
+		items := listOf(normalized)
+
+		for _, item := range items {
+			if !slices.Contains(field.OptionStrings("values"), fmt.Sprint(item)) {
+				return "validation_invalid_value", fmt.Sprintf("Invalid value %s.", fmt.Sprint(item))
+			}
+		}
+
+		return field.checkCount(len(items))
+	case "relation":
+		items := listOf(normalized)
+
+		for _, item := range items {
+			id, ok := item.(string)
+
+			if !ok || !relationIdMatches(field, id, related) {
+				return "validation_invalid_format", fmt.Sprintf("Invalid relation id %v.", item)
+			}
+		}
+
+		return field.checkCount(len(items))
+	case "json":
+		if maxSize, ok := field.OptionNumber("maxSize"); ok && maxSize > 0 {
+			encoded, _ := json.Marshal(normalized)
+
+			if len(encoded) > int(maxSize) {
+				return "validation_json_size_limit", fmt.Sprintf("The maximum allowed JSON size is %d bytes.", int(maxSize))
+			}
+		}
+	case "geoPoint":
+		point, ok := normalized.(map[string]any)
+		lon, lonOk := numberOf(point["lon"])
+		lat, latOk := numberOf(point["lat"])
+
+		if !ok || !lonOk || !latOk {
+			return "validation_invalid_type", "Invalid value type."
+		}
+
+		if lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+			return "validation_invalid_geo_point", "The longitude must be between -180 and 180 and the latitude between -90 and 90."
+		}
+	}
+
+	return "", ""
+}
+
+func (field CollectionField) checkText(text string) (string, string) {
+	length := utf8.RuneCountInString(text)
+
+	if min, ok := field.OptionNumber("min"); ok && min > 0 && length < int(min) {
+		return "validation_min_text_constraint", fmt.Sprintf("Must be at least %d character(s).", int(min))
+	}
+
+	if max, ok := field.OptionNumber("max"); ok && max > 0 && length > int(max) {
+		return "validation_max_text_constraint", fmt.Sprintf("Must be less than %d character(s).", int(max))
+	}
+
+	if maxSize, ok := field.OptionNumber("maxSize"); ok && maxSize > 0 && len(text) > int(maxSize) {
+		return "validation_content_size_limit", fmt.Sprintf("The maximum allowed content size is %d bytes.", int(maxSize))
+	}
+
+	if matcher := field.patternMatcher(); matcher != nil && !matcher.MatchString(text) {
+		return "validation_invalid_format", "Invalid value format."
+	}
+
+	switch field.Type {
+	case "email":
+		address, err := mail.ParseAddress(text)
+
+		if err != nil || address.Address != text {
+			return "validation_is_email", "Must be a valid email address."
+		}
+
+		_, domain, _ := strings.Cut(text, "@")
+
+		if !domainAllowed(field, domain) {
+			return "validation_email_domain_not_allowed", "Email domain is not allowed."
+		}
+	case "url":
+		parsed, err := url.ParseRequestURI(text)
+
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return "validation_is_url", "Must be a valid url."
+		}
+
+		if !domainAllowed(field, parsed.Hostname()) {
+			return "validation_url_domain_not_allowed", "Url domain is not allowed."
+		}
+	}
+
+	return "", ""
+}
+
+// Compiled pattern option, nil without a valid pattern, fields built outside a schema compile it on every call
+func (field CollectionField) patternMatcher() *regexp.Regexp {
+	pattern := field.OptionString("pattern")
+
+	if field.pattern != nil || pattern == "" {
+		return field.pattern
+	}
+
+	matcher, _ := regexp.Compile(pattern)
+
+	return matcher
+}
+
+func (field CollectionField) checkNumber(number float64) (string, string) {
+	if field.OptionBool("onlyInt") && number != float64(int64(number)) {
+		return "validation_only_int_constraint", "Decimal numbers are not allowed."
+	}
+
+	if min, ok := field.OptionNumber("min"); ok && number < min {
+		return "validation_min_number_constraint", fmt.Sprintf("Must be larger than %s.", strconv.FormatFloat(min, 'f', -1, 64))
+	}
+
+	if max, ok := field.OptionNumber("max"); ok && number > max {
+		return "validation_max_number_constraint", fmt.Sprintf("Must be less than %s.", strconv.FormatFloat(max, 'f', -1, 64))
+	}
+
+	return "", ""
+}
+
+func (field CollectionField) checkDate(value any) (string, string) {
+	text, _ := value.(string)
+	date := DateTime{}
+
+	encoded, _ := json.Marshal(text)
+
+	if date.UnmarshalJSON(encoded) != nil {
+		return "validation_invalid_datetime", "Must be a valid datetime."
+	}
+
+	for _, bound := range []string{"min", "max"} {
+		limit := DateTime{}
+		encoded, _ := json.Marshal(field.OptionString(bound))
+
+		if limit.UnmarshalJSON(encoded) != nil || limit.IsZero() {
+			continue
+		}
+
+		if bound == "min" && date.Before(limit.Time) {
+			return "validation_min_date_constraint", "Must be after " + limit.String() + "."
+		}
+
+		if bound == "max" && date.After(limit.Time) {
+			return "validation_max_date_constraint", "Must be before " + limit.String() + "."
+		}
+	}
+
+	return "", ""
+}
+
+// Checks the number of values of a select, relation or file field
+func (field CollectionField) checkCount(count int) (string, string) {
+	if count > field.MaxSelect() {
+		return "validation_too_many_values", fmt.Sprintf("Select no more than %d.", field.MaxSelect())
+	}
+
+	if minSelect, ok := field.OptionNumber("minSelect"); ok && count < int(minSelect) {
+		return "validation_not_enough_values", fmt.Sprintf("Select at least %d.", int(minSelect))
+	}
+
+	return "", ""
+}
+
+// Files are uploaded as File and kept by their stored filename, only the uploads are checked for type and size
+func (field CollectionField) checkFiles(value any) (string, string) {
+	items := []any{}
+
+	switch v := value.(type) {
+	case nil:
+	case File, string:
+		items = append(items, v)
+	case []File:
+		for _, file := range v {
+			items = append(items, file)
+		}
+	case []string:
+		for _, filename := range v {
+			items = append(items, filename)
+		}
+	case []any:
+		items = v
+	default:
+		return "validation_invalid_type", "Invalid value type."
+	}
+
+	items = slices.DeleteFunc(items, func(item any) bool { return item == "" })
+
+	if len(items) == 0 {
+		if field.Required {
+			return "validation_required", "Cannot be blank."
+		}
+
+		return "", ""
+	}
+
+	for _, item := range items {
+		file, ok := item.(File)
+
+		if !ok {
+			if _, ok := item.(string); !ok {
+				return "validation_invalid_type", "Invalid value type."
+			}
+
+			continue
+		}
+
+		mimeTypes := field.OptionStrings("mimeTypes")
+
+		if mimeType := mimeTypeOf(file); len(mimeTypes) > 0 && mimeType != "" && !slices.Contains(mimeTypes, mimeType) {
+			return "validation_invalid_mime_type", fmt.Sprintf("%q mime type is not allowed.", mimeType)
+		}
+
+		if maxSize, ok := field.OptionNumber("maxSize"); ok && maxSize > 0 {
+			if size, known := sizeOf(file.Reader); known && size > int64(maxSize) {
+				return "validation_file_size_limit", fmt.Sprintf("Failed to upload %q - the maximum allowed file size is %d bytes.", file.Name, int64(maxSize))
+			}
+		}
+	}
+
+	return field.checkCount(len(items))
+}
+
+// Brings the value into the shape it has on the wire so typed slices, DateTime and GeoPoint are checked like plain values
+func normalizeValue(value any) (any, bool) {
+	encoded, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+
+	var normalized any
+
+	return normalized, decoder.Decode(&normalized) == nil
+}
+
+// Whether PocketBase treats the value as empty for the field type
+func isBlank(fieldType string, value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == "" || fieldType == "json" && v == "null"
+	case []any:
+		return len(v) == 0
+	case bool:
+		return !v
+	case json.Number:
+		number, _ := v.Float64()
+		return number == 0 && fieldType == "number"
+	}
+
+	return false
+}
+
+func listOf(value any) []any {
+	if list, ok := value.([]any); ok {
+		return slices.DeleteFunc(slices.Clone(list), func(item any) bool { return item == "" })
+	}
+
+	return []any{value}
+}
+
+// PocketBase casts numeric text to numbers, any other text is a type error
+func numberOf(value any) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case float64:
+		return v, true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	}
+
+	return 0, false
+}
+
+func boolOf(value any) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		return parsed, err == nil
+	}
+
+	return false, false
+}
+
+func relationIdMatches(field CollectionField, id string, related func(collectionId string) *CollectionSchema) bool {
+	if related != nil {
+		if target := related(field.CollectionId()); target != nil {
+			if idField, ok := target.Field("id"); ok {
+				code, _ := idField.checkText(id)
+				return code == ""
+			}
+		}
+	}
+
+	return looseIdPattern.MatchString(id)
+}
+
+// Checks the domain against onlyDomains and exceptDomains of an email or url field
+func domainAllowed(field CollectionField, domain string) bool {
+	domain = strings.ToLower(domain)
+
+	if only := field.OptionStrings("onlyDomains"); len(only) > 0 {
+		return slices.ContainsFunc(only, func(allowed string) bool { return strings.EqualFold(allowed, domain) })
+	}
+
+	return !slices.ContainsFunc(field.OptionStrings("exceptDomains"), func(denied string) bool { return strings.EqualFold(denied, domain) })
+}
+
+// Mime type from the extension, sniffed from the content when the extension is unknown and the reader can rewind
+func mimeTypeOf(file File) string {
+	if byExtension := mime.TypeByExtension(filepath.Ext(file.Name)); byExtension != "" {
+		mediaType, _, _ := strings.Cut(byExtension, ";")
+		return strings.TrimSpace(mediaType)
+	}
+
+	seeker, ok := file.Reader.(io.ReadSeeker)
+
+	if !ok {
+		return ""
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+
+	if err != nil {
+		return ""
+	}
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(seeker, head)
+
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return ""
+	}
+
+	mediaType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+
+	return mediaType
+}
+
+// Size of the upload when the reader knows it without being read, like bytes.Reader, strings.Reader or os.File
+func sizeOf(reader io.Reader) (int64, bool) {
+	switch r := reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+
+		if err != nil {
+			return 0, false
+		}
+
+		return info.Size(), true
+	case interface{ Size() int64 }:
+		return r.Size(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,143 @@
+package services_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/JGugino/pb-go/mocks"
+	"github.com/JGugino/pb-go/services"
+)
+
+// Comments relate to pbc_hidden, viewing it fails with the status
+func commentsCollections(lookups map[string]int, status int) *mocks.CollectionService {
+	comments := services.PocketBaseCollectionResponse{
+		Id:   "pbc_comments",
+		Name: "comments",
+		Type: "base",
+		Fields: []map[string]any{
+			{"name": "code", "type": "text", "pattern": `^[A-Z]{3}$`},
+			{"name": "post", "type": "relation", "collectionId": "pbc_hidden", "maxSelect": 1},
+		},
+	}
+
+	return &mocks.CollectionService{
+		ViewCollectionFunc: func(token string, desiredCollection string) (services.PocketBaseCollectionResponse, error) {
+			lookups[desiredCollection]++
+
+			if desiredCollection == "comments" {
+				return comments, nil
+			}
+
+			return services.PocketBaseCollectionResponse{}, &services.ResponseError{Status: status, Message: http.StatusText(status)}
+		},
+	}
+}
+
+func TestValidatorCachesFailedRelatedLookups(t *testing.T) {
+	lookups := map[string]int{}
+	validator := services.NewRecordValidator(commentsCollections(lookups, http.StatusNotFound), "token")
+
+	for i := 0; i < 3; i++ {
+		if err := validator.ValidateCreate("comments", map[string]any{"code": "ABC", "post": "abc123"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//The miss still falls back to the loose id shape
+	if err := validator.ValidateCreate("comments", map[string]any{"post": "not an id"}); err == nil {
+		t.Fatal("expected the loose id shape to reject the relation")
+	}
+
+	if lookups["pbc_hidden"] != 1 || lookups["comments"] != 1 {
+		t.Fatalf("lookups = %v, expected one per collection", lookups)
+	}
+
+	validator.Forget("pbc_hidden")
+
+	if err := validator.ValidateCreate("comments", map[string]any{"post": "abc123"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if lookups["pbc_hidden"] != 2 {
+		t.Fatalf("lookups = %v, expected Forget to retry the related collection", lookups)
+	}
+}
+
+func TestValidatorRetriesFailedRelatedRequests(t *testing.T) {
+	cases := []struct {
+		status  int
+		lookups int
+	}{
+		{http.StatusForbidden, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusInternalServerError, 3},
+		{http.StatusBadGateway, 3},
+		{http.StatusTooManyRequests, 3},
+	}
+
+	for _, c := range cases {
+		lookups := map[string]int{}
+		validator := services.NewRecordValidator(commentsCollections(lookups, c.status), "token")
+
+		for i := 0; i < 3; i++ {
+			if err := validator.ValidateCreate("comments", map[string]any{"post": "abc123"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if lookups["pbc_hidden"] != c.lookups {
+			t.Errorf("status %d looked up the related collection %d times, expected %d", c.status, lookups["pbc_hidden"], c.lookups)
+		}
+	}
+}
+
+func TestValidatorForgetsRelatedLookupsByName(t *testing.T) {
+	lookups := map[string]int{}
+	validator := services.NewRecordValidator(commentsCollections(lookups, http.StatusForbidden), "token")
+
+	validate := func() {
+		t.Helper()
+
+		if err := validator.ValidateCreate("comments", map[string]any{"post": "abc123"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	validate()
+	validate()
+
+	//The failed lookup is kept under pbc_hidden, the validator never learned it is called posts
+	validator.Forget("posts")
+	validate()
+
+	if lookups["pbc_hidden"] != 2 {
+		t.Fatalf("lookups = %v, expected Forget by name to retry the related collection", lookups)
+	}
+
+	//Forgetting a cached schema by name keeps the other failed lookups
+	validator.Forget("comments")
+	validate()
+
+	if lookups["comments"] != 2 || lookups["pbc_hidden"] != 2 {
+		t.Fatalf("lookups = %v, expected only comments to be fetched again", lookups)
+	}
+}
+
+func TestValidatorChecksCachedPatterns(t *testing.T) {
+	validator := services.NewRecordValidator(commentsCollections(map[string]int{}, http.StatusNotFound), "token")
+
+	for _, code := range []string{"ABC", "XYZ"} {
+		if err := validator.ValidateCreate("comments", map[string]any{"code": code}); err != nil {
+			t.Fatalf("%s: %v", code, err)
+		}
+	}
+
+	err := validator.ValidateCreate("comments", map[string]any{"code": "abc"})
+
+	var resErr *services.ResponseError
+
+	if !errors.As(err, &resErr) || !resErr.HasCode("validation_invalid_format") {
+		t.Fatalf("expected validation_invalid_format, got %v", err)
+	}
+}
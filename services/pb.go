@@ -1,22 +1,109 @@
 package services
 
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
 type Pocketbase struct {
-	Auth       *PBAuth       `json:"auth"`
-	Collection *PBCollection `json:"collection"`
-	Record     *PBRecord     `json:"record"`
+	BaseURL    string            `json:"baseURL"`
+	Auth       AuthService       `json:"auth"`
+	Collection CollectionService `json:"collection"`
+	Record     RecordService     `json:"record"`
+	File       FileService       `json:"file"`
+	Client     *PBClient         `json:"-"`
 }
 
+// Sets up the services for the url with an empty client, New validates the url and accepts options
 func (pb *Pocketbase) Init(url string) error {
+	pb.Client = &PBClient{BaseURL: url}
+	pb.initServices()
+
+	return nil
+}
+
+// Creates every service from the shared client, new services are added here
+func (pb *Pocketbase) initServices() {
+	url := pb.Client.BaseURL
+	pb.BaseURL = url
+
+	if pb.Client.batch == nil {
+		pb.Client.batch = &batchSupport{}
+	}
+
 	pb.Auth = &PBAuth{
 		BaseURL: url,
+		Client:  pb.Client,
 	}
 
 	pb.Collection = &PBCollection{
 		BaseURL: url,
+		Client:  pb.Client,
 	}
 
 	pb.Record = &PBRecord{
 		BaseURL: url,
+		Client:  pb.Client,
+	}
+
+	pb.File = &PBFile{
+		BaseURL: url,
+		Client:  pb.Client,
+	}
+}
+
+// Appends middleware to the chain shared by every service
+func (pb *Pocketbase) Use(middleware ...Middleware) {
+	pb.Client.Use(middleware...)
+}
+
+// Sets the auth store that successful authentications are saved into
+func (pb *Pocketbase) SetAuthStore(store AuthStore) {
+	pb.Client.AuthStore = store
+}
+
+// Sets the retry policy for failed requests, nil disables retries
+func (pb *Pocketbase) SetRetryPolicy(policy *RetryPolicy) {
+	pb.Client.RetryPolicy = policy
+}
+
+// Sets the http client used for every request, useful to swap in a custom transport
+func (pb *Pocketbase) SetHTTPClient(httpClient *http.Client) {
+	pb.Client.HTTPClient = httpClient
+}
+
+// Sets the logger that receives a debug record for every request, nil disables logging
+func (pb *Pocketbase) SetLogger(logger *slog.Logger) {
+	pb.Client.Logger = logger
+}
+
+// Writes an equivalent curl command and a timing breakdown of every request to the writer, nil disables it
+func (pb *Pocketbase) SetDebugWriter(writer io.Writer) {
+	pb.Client.DebugWriter = writer
+}
+
+// Enables the response cache for record reads, returns the cache so it can be cleared or watched over realtime
+func (pb *Pocketbase) EnableCache(ttl time.Duration, maxEntries int) *ResponseCache {
+	pb.Client.Cache = NewResponseCache(ttl, maxEntries)
+	return pb.Client.Cache
+}
+
+// Enables the client side rate limiter for every service, returns the limiter so rules can be loaded into it
+func (pb *Pocketbase) EnableRateLimiter(rules ...RateLimitRule) *RateLimiter {
+	pb.Client.RateLimiter = NewRateLimiter(rules...)
+	return pb.Client.RateLimiter
+}
+
+// Enables the rate limiter using the rules from the instance settings, requires a superuser token
+func (pb *Pocketbase) LoadRateLimiterFromSettings(token string) error {
+	limiter := NewRateLimiter()
+
+	if err := limiter.loadFromSettings(pb.Client, pb.BaseURL, token); err != nil {
+		return err
 	}
+
+	pb.Client.RateLimiter = limiter
 	return nil
 }